@@ -0,0 +1,233 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/taonic/ticketfu/config"
+	"github.com/taonic/ticketfu/temporal"
+	"github.com/taonic/ticketfu/worker"
+	"github.com/taonic/ticketfu/worker/backfill"
+	"github.com/urfave/cli/v2"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/server/common/log"
+	"go.temporal.io/server/common/log/tag"
+)
+
+const (
+	// Backfill-specific flags
+	FlagBackfillSince  = "since"
+	FlagBackfillOrg    = "org"
+	FlagBackfillStatus = "status"
+	FlagBackfillRate   = "rate"
+	FlagBackfillCursor = "cursor"
+
+	// backfillProgressInterval is how often the progress of a backfill is reported
+	backfillProgressInterval = 30 * time.Second
+)
+
+// Backfill-specific flags
+var backfillFlags = append(append([]cli.Flag{
+	&cli.StringFlag{
+		Name:  FlagBackfillSince,
+		Usage: "backfill tickets updated since this date, e.g. 2025-01-31 or 2025-01-31T09:00:00Z",
+	},
+	&cli.Int64SliceFlag{
+		Name:  FlagBackfillOrg,
+		Usage: "only backfill tickets of this organization ID. Can be repeated",
+	},
+	&cli.StringSliceFlag{
+		Name:  FlagBackfillStatus,
+		Usage: "only backfill tickets with this status, e.g. open or solved. Can be repeated",
+	},
+	&cli.Float64Flag{
+		Name:  FlagBackfillRate,
+		Usage: "ticket workflows started a second. 0 is unlimited",
+		Value: backfill.DefaultRate,
+	},
+	&cli.Int64Flag{
+		Name:  FlagBackfillCursor,
+		Usage: "resume an interrupted backfill from the cursor it reported instead of --since",
+	},
+}, temporalFlags...), commonFlags...)
+
+// NewBackfillCommand creates the command seeding historical tickets
+func NewBackfillCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "backfill",
+		Usage:  "Start the workflows of historical tickets to populate organization insights",
+		Flags:  backfillFlags,
+		Action: runBackfill,
+	}
+}
+
+// runBackfill starts the backfill workflow, or attaches to the running one,
+// and reports its progress until it completes. Interrupting the command
+// leaves the backfill running.
+func runBackfill(c *cli.Context) error {
+	input, err := NewBackfillInput(c)
+	if err != nil {
+		return err
+	}
+
+	logger := log.NewZapLogger(log.BuildZapLogger(log.Config{
+		Level:  c.String(FlagLogLevel),
+		Format: c.String(FlagLogFormat),
+	}))
+
+	tClient, err := temporal.NewClient(config.TemporalClientConfig{
+		Address:     c.String(FlagTemporalAddress),
+		Namespace:   c.String(FlagTemporalNamespace),
+		APIKey:      c.String(FlagTemporalAPIKey),
+		TLSCertPath: c.String(FlagTemporalTLSCert),
+		TLSKeyPath:  c.String(FlagTemporalTLSKey),
+	}, logger)
+	if err != nil {
+		return err
+	}
+	defer tClient.Close()
+
+	ctx, stop := signal.NotifyContext(c.Context, os.Interrupt)
+	defer stop()
+
+	workflowOptions := client.StartWorkflowOptions{
+		ID:                                       backfill.BackfillWorkflowID,
+		TaskQueue:                                worker.TaskQueue,
+		WorkflowExecutionErrorWhenAlreadyStarted: true,
+	}
+	run, err := tClient.ExecuteWorkflow(ctx, workflowOptions, backfill.BackfillWorkflow, input)
+	var alreadyStarted *serviceerror.WorkflowExecutionAlreadyStarted
+	if errors.As(err, &alreadyStarted) {
+		running, err := queryParams(ctx, tClient)
+		if err != nil {
+			return fmt.Errorf("failed to get the running backfill's parameters: %w", err)
+		}
+		if !sameBackfill(input, running) {
+			return fmt.Errorf("a backfill of %s is already running, wait for it to complete before starting another", describeBackfill(running))
+		}
+		fmt.Fprintf(c.App.Writer, "A backfill of %s is already running, waiting for it instead\n", describeBackfill(running))
+		run = tClient.GetWorkflow(ctx, backfill.BackfillWorkflowID, "")
+	} else if err != nil {
+		return fmt.Errorf("failed to start backfill: %w", err)
+	}
+
+	done := make(chan error, 1)
+	var progress backfill.Progress
+	go func() { done <- run.Get(ctx, &progress) }()
+
+	ticker := time.NewTicker(backfillProgressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-done:
+			if ctx.Err() != nil {
+				fmt.Fprintf(c.App.Writer, "Backfill continues in the background. If it's terminated, resume it with --%s=%d\n",
+					FlagBackfillCursor, lastCursor(tClient, input.Cursor))
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("backfill failed, resume it with --%s=%d: %w", FlagBackfillCursor, lastCursor(tClient, input.Cursor), err)
+			}
+			fmt.Fprintf(c.App.Writer, "Backfilled %d tickets, skipped %d not matching the filters\n", progress.Started, progress.Skipped)
+			return nil
+		case <-ticker.C:
+			if p, err := queryProgress(ctx, tClient); err == nil {
+				logger.Info("Backfilling tickets",
+					tag.NewInt("started", p.Started),
+					tag.NewInt("skipped", p.Skipped),
+					tag.NewInt64("cursor", p.Cursor))
+			}
+		}
+	}
+}
+
+// NewBackfillInput creates the backfill workflow's input from CLI context
+func NewBackfillInput(ctx *cli.Context) (backfill.BackfillInput, error) {
+	input := backfill.BackfillInput{
+		Cursor: ctx.Int64(FlagBackfillCursor),
+		Filter: backfill.Filter{
+			OrganizationIDs: ctx.Int64Slice(FlagBackfillOrg),
+			Statuses:        ctx.StringSlice(FlagBackfillStatus),
+		},
+		Rate: ctx.Float64(FlagBackfillRate),
+	}
+
+	if input.Cursor == 0 {
+		raw := ctx.String(FlagBackfillSince)
+		if raw == "" {
+			return input, fmt.Errorf("--%s or --%s is required", FlagBackfillSince, FlagBackfillCursor)
+		}
+		since, err := parseSince(raw)
+		if err != nil {
+			return input, fmt.Errorf("invalid %s: %w", FlagBackfillSince, err)
+		}
+		input.Cursor = since.Unix()
+	}
+
+	return input, nil
+}
+
+// sameBackfill reports whether the input asks for the running backfill. Its
+// rate isn't compared, as it doesn't change which tickets are backfilled.
+func sameBackfill(input, running backfill.BackfillInput) bool {
+	return input.Cursor == running.Since && input.Filter.Equal(running.Filter)
+}
+
+// describeBackfill returns the tickets a backfill covers, e.g. "tickets
+// updated since 2025-01-31T00:00:00Z of organizations [42] with statuses [open]"
+func describeBackfill(input backfill.BackfillInput) string {
+	description := "tickets updated since " + time.Unix(input.Since, 0).UTC().Format(time.RFC3339)
+	if len(input.Filter.OrganizationIDs) > 0 {
+		description += fmt.Sprintf(" of organizations %v", input.Filter.OrganizationIDs)
+	}
+	if len(input.Filter.Statuses) > 0 {
+		description += fmt.Sprintf(" with statuses %v", input.Filter.Statuses)
+	}
+	return description
+}
+
+// parseSince accepts a date or an RFC 3339 timestamp
+func parseSince(raw string) (time.Time, error) {
+	if since, err := time.Parse(time.DateOnly, raw); err == nil {
+		return since, nil
+	}
+	return time.Parse(time.RFC3339, raw)
+}
+
+func queryProgress(ctx context.Context, tClient client.Client) (backfill.Progress, error) {
+	var progress backfill.Progress
+	value, err := tClient.QueryWorkflow(ctx, backfill.BackfillWorkflowID, "", backfill.ProgressQuery)
+	if err != nil {
+		return progress, err
+	}
+	err = value.Get(&progress)
+	return progress, err
+}
+
+func queryParams(ctx context.Context, tClient client.Client) (backfill.BackfillInput, error) {
+	var params backfill.BackfillInput
+	value, err := tClient.QueryWorkflow(ctx, backfill.BackfillWorkflowID, "", backfill.ParamsQuery)
+	if err != nil {
+		return params, err
+	}
+	err = value.Get(&params)
+	return params, err
+}
+
+// lastCursor returns the cursor of the latest run, falling back to where the
+// backfill started
+func lastCursor(tClient client.Client, fallback int64) int64 {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	progress, err := queryProgress(ctx, tClient)
+	if err != nil || progress.Cursor == 0 {
+		return fallback
+	}
+	return progress.Cursor
+}
@@ -22,6 +22,7 @@ func newCliApp() *cli.App {
 	app.Commands = []*cli.Command{
 		NewWorkerCommand(),
 		NewServerCommand(),
+		NewBackfillCommand(),
 	}
 
 	// Default action if no command is provided
@@ -3,9 +3,12 @@ package cli
 import (
 	"flag"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/taonic/ticketfu/config"
+	"github.com/taonic/ticketfu/worker/backfill"
 	"github.com/urfave/cli/v2"
 )
 
@@ -16,19 +19,24 @@ func TestCliApp(t *testing.T) {
 	assert.Equal(t, Version, app.Version)
 	assert.NotNil(t, app.Action)
 
-	require.Len(t, app.Commands, 2)
+	require.Len(t, app.Commands, 3)
 
-	var workerCmd, serverCmd *cli.Command
+	var workerCmd, serverCmd, backfillCmd *cli.Command
 	for _, cmd := range app.Commands {
-		if cmd.Name == "worker" {
+		switch cmd.Name {
+		case "worker":
 			workerCmd = cmd
-		} else if cmd.Name == "server" {
+		case "server":
 			serverCmd = cmd
+		case "backfill":
+			backfillCmd = cmd
 		}
 	}
 
 	require.NotNil(t, workerCmd, "Worker command missing")
 	require.NotNil(t, serverCmd, "Server command missing")
+	require.NotNil(t, backfillCmd, "Backfill command missing")
+	assert.NotNil(t, backfillCmd.Action)
 
 	// Check subcommands
 	require.Len(t, workerCmd.Subcommands, 1)
@@ -91,3 +99,139 @@ func TestWorkerApp(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotNil(t, fxApp)
 }
+
+// TestWorkerConfigTaxonomy tests parsing the classification taxonomy flag
+func TestWorkerConfigTaxonomy(t *testing.T) {
+	app := cli.NewApp()
+
+	set := flag.NewFlagSet("test", 0)
+	set.String(FlagClassifyTaxonomy, `[{"name":"severity","values":["low","high"],"field_id":123}]`, "")
+	set.Bool(FlagClassifyDryRun, true, "")
+
+	workerConfig, err := NewWorkerConfig(cli.NewContext(app, set, nil))
+	require.NoError(t, err)
+	assert.True(t, workerConfig.TicketWorkflow.Classification.DryRun)
+	require.Len(t, workerConfig.TicketWorkflow.Classification.Taxonomy, 1)
+	assert.Equal(t, int64(123), workerConfig.TicketWorkflow.Classification.Taxonomy[0].FieldID)
+
+	set = flag.NewFlagSet("test", 0)
+	set.String(FlagClassifyTaxonomy, "not json", "")
+
+	_, err = NewWorkerConfig(cli.NewContext(app, set, nil))
+	assert.ErrorContains(t, err, FlagClassifyTaxonomy)
+}
+
+// TestWorkerConfigCustomFields tests parsing the custom field allowlist flag
+func TestWorkerConfigCustomFields(t *testing.T) {
+	app := cli.NewApp()
+
+	set := flag.NewFlagSet("test", 0)
+	set.String(FlagTicketCustomFields, `{"360001234567":"product_area"}`, "")
+
+	workerConfig, err := NewWorkerConfig(cli.NewContext(app, set, nil))
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]string{360001234567: "product_area"}, workerConfig.TicketWorkflow.CustomFields)
+
+	set = flag.NewFlagSet("test", 0)
+	set.String(FlagTicketCustomFields, `{"not-an-id":"product_area"}`, "")
+
+	_, err = NewWorkerConfig(cli.NewContext(app, set, nil))
+	assert.ErrorContains(t, err, FlagTicketCustomFields)
+}
+
+// TestAIConfigRedaction tests parsing the redaction flags
+func TestAIConfigRedaction(t *testing.T) {
+	app := cli.NewApp()
+
+	set := flag.NewFlagSet("test", 0)
+	set.Bool(FlagRedactPII, true, "")
+	set.String(FlagRedactionRules, `[{"name":"account_id","pattern":"ACC-\\d+"}]`, "")
+
+	aiConfig, err := NewAIConfig(cli.NewContext(app, set, nil))
+	require.NoError(t, err)
+	assert.True(t, aiConfig.Redaction.Enabled)
+	assert.Equal(t, []config.RedactionRule{{Name: "account_id", Pattern: `ACC-\d+`}}, aiConfig.Redaction.Rules)
+
+	set = flag.NewFlagSet("test", 0)
+	set.String(FlagRedactionRules, "not json", "")
+
+	_, err = NewAIConfig(cli.NewContext(app, set, nil))
+	assert.ErrorContains(t, err, FlagRedactionRules)
+}
+
+// TestBackfillInput tests parsing the backfill filters and cursor
+func TestBackfillInput(t *testing.T) {
+	app := cli.NewApp()
+
+	set := flag.NewFlagSet("test", 0)
+	set.String(FlagBackfillSince, "2025-01-31", "")
+	set.Var(cli.NewInt64Slice(42, 43), FlagBackfillOrg, "")
+	set.Var(cli.NewStringSlice("open", "pending"), FlagBackfillStatus, "")
+	set.Float64(FlagBackfillRate, 2, "")
+
+	input, err := NewBackfillInput(cli.NewContext(app, set, nil))
+	require.NoError(t, err)
+	assert.Equal(t, backfill.BackfillInput{
+		Cursor: time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC).Unix(),
+		Filter: backfill.Filter{OrganizationIDs: []int64{42, 43}, Statuses: []string{"open", "pending"}},
+		Rate:   2,
+	}, input)
+
+	// The cursor of an interrupted backfill takes precedence
+	set = flag.NewFlagSet("test", 0)
+	set.String(FlagBackfillSince, "2025-01-31T09:00:00Z", "")
+	set.Int64(FlagBackfillCursor, 1740826800, "")
+
+	input, err = NewBackfillInput(cli.NewContext(app, set, nil))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1740826800), input.Cursor)
+
+	set = flag.NewFlagSet("test", 0)
+	set.String(FlagBackfillSince, "last week", "")
+
+	_, err = NewBackfillInput(cli.NewContext(app, set, nil))
+	assert.ErrorContains(t, err, FlagBackfillSince)
+
+	_, err = NewBackfillInput(cli.NewContext(app, flag.NewFlagSet("test", 0), nil))
+	assert.ErrorContains(t, err, "--since or --cursor is required")
+}
+
+// TestSameBackfill tests matching the input against the running backfill
+func TestSameBackfill(t *testing.T) {
+	since := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC).Unix()
+	running := backfill.BackfillInput{
+		Since:  since,
+		Filter: backfill.Filter{OrganizationIDs: []int64{42}, Statuses: []string{"open"}},
+		Rate:   1,
+	}
+
+	assert.True(t, sameBackfill(backfill.BackfillInput{Cursor: since, Filter: running.Filter, Rate: 2}, running))
+	assert.False(t, sameBackfill(backfill.BackfillInput{Cursor: since + 1, Filter: running.Filter}, running))
+	assert.False(t, sameBackfill(backfill.BackfillInput{Cursor: since, Filter: backfill.Filter{OrganizationIDs: []int64{42}}}, running))
+
+	assert.Equal(t, "tickets updated since 2025-01-31T00:00:00Z of organizations [42] with statuses [open]", describeBackfill(running))
+}
+
+// TestAIConfigContextBudgets tests parsing the context budgets flag
+func TestAIConfigContextBudgets(t *testing.T) {
+	app := cli.NewApp()
+
+	set := flag.NewFlagSet("test", 0)
+	set.String(FlagContextBudgets, `{"openai/gpt-4o":{"context_window":64000,"output_reserve":4000}}`, "")
+
+	aiConfig, err := NewAIConfig(cli.NewContext(app, set, nil))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]config.ContextBudget{"openai/gpt-4o": {ContextWindow: 64000, OutputReserve: 4000}}, aiConfig.ContextBudgets)
+
+	set = flag.NewFlagSet("test", 0)
+	set.String(FlagContextBudgets, `{"claude":{"context_window":1000,"output_reserve":1000}}`, "")
+
+	_, err = NewAIConfig(cli.NewContext(app, set, nil))
+	assert.ErrorContains(t, err, "claude must reserve less output")
+
+	set = flag.NewFlagSet("test", 0)
+	set.String(FlagContextBudgets, "not json", "")
+
+	_, err = NewAIConfig(cli.NewContext(app, set, nil))
+	assert.ErrorContains(t, err, FlagContextBudgets)
+}
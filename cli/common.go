@@ -25,8 +25,21 @@ const (
 	FlagLLMProvider         = "llm-provider"
 	FlagLLMModel            = "llm-model"
 	FlagLLMAPIKey           = "llm-api-key"
+	FlagEmbeddingModel      = "embedding-model"
 	FlagTicketSummaryPrompt = "ticket-summary-prompt"
 	FlagOrgSummaryPrompt    = "org-summary-prompt"
+	FlagCommentDigestPrompt = "comment-digest-prompt"
+	FlagChunkSummaryPrompt  = "chunk-summary-prompt"
+	FlagResolutionPrompt    = "resolution-prompt"
+	FlagScoringPrompt       = "scoring-prompt"
+	FlagClassifyPrompt      = "classify-prompt"
+	FlagDraftReplyPrompt    = "draft-reply-prompt"
+	FlagLanguagePrompt      = "language-prompt"
+	FlagTranslatePrompt     = "translate-prompt"
+	FlagRedactPII           = "redact-pii"
+	FlagRedactionRules      = "redaction-rules"
+	FlagPromptRoutes        = "prompt-routes"
+	FlagContextBudgets      = "context-budgets"
 )
 
 // Temporal flags shared across commands
@@ -104,6 +117,32 @@ var aiFlags = []cli.Flag{
 		Usage:    "LLM's API Key",
 		Required: true,
 	},
+	&cli.StringFlag{
+		Name:    FlagEmbeddingModel,
+		EnvVars: []string{"EMBEDDING_MODEL"},
+		Usage:   "model embedding ticket summaries to find related tickets, the provider's default when empty. Anthropic has no embedding API",
+	},
+	&cli.BoolFlag{
+		Name:    FlagRedactPII,
+		EnvVars: []string{"REDACT_PII"},
+		Usage:   "redact emails, phone numbers, card numbers, API keys, IPs and the names of a ticket's people from prompts and content before they're sent to the LLM",
+		Value:   true,
+	},
+	&cli.StringFlag{
+		Name:    FlagRedactionRules,
+		EnvVars: []string{"REDACTION_RULES"},
+		Usage:   `custom redaction rules as a JSON list, e.g. [{"name":"account_id","pattern":"ACC-\d+"}]. Matches are redacted as [ACCOUNT_ID_<n>] tokens`,
+	},
+	&cli.StringFlag{
+		Name:    FlagPromptRoutes,
+		EnvVars: []string{"PROMPT_ROUTES"},
+		Usage:   "path to a YAML file of prompt routes picking the summary prompt and fields by brand, group, type, tags, custom fields or organization",
+	},
+	&cli.StringFlag{
+		Name:    FlagContextBudgets,
+		EnvVars: []string{"CONTEXT_BUDGETS"},
+		Usage:   `context budgets as a JSON object keyed by "provider/model", model or provider, e.g. {"openai/gpt-4o":{"context_window":128000,"output_reserve":16384}}. Known models have built-in budgets`,
+	},
 	&cli.StringFlag{
 		Name:     FlagTicketSummaryPrompt,
 		EnvVars:  []string{"TICKET_SUMMARY_PROMPT"},
@@ -112,6 +151,9 @@ var aiFlags = []cli.Flag{
 		Value: `
 		* you are a support engineer \n
 		* include participant names in the below summary
+		* comments with author role end-user are from the customer, private comments are internal agent notes \n
+		* attachment snippets are excerpts of attached logs, configs and stack traces, cite them as evidence \n
+		* chunk summaries summarize the earlier comments in order, the comments follow them \n
 		* brief the intent of the ticket \n
 		* summarize the ticket \n
 		* brief the next step \n
@@ -155,6 +197,108 @@ var aiFlags = []cli.Flag{
     Keep the analysis professional and actionable.
 		`,
 	},
+	&cli.StringFlag{
+		Name:     FlagCommentDigestPrompt,
+		EnvVars:  []string{"COMMENT_DIGEST_PROMPT"},
+		Usage:    "Prompt used for compacting older ticket comments into a history digest",
+		Required: false,
+		Value: `
+		* you are a support engineer \n
+		* the input contains the previous history digest of a ticket and the comments that followed it \n
+		* merge them into a single chronological digest of the conversation \n
+		* keep who said what, reported problems, findings, decisions and commitments \n
+		* drop greetings, signatures and quoted replies \n
+		* return plain text only
+		`,
+	},
+	&cli.StringFlag{
+		Name:     FlagChunkSummaryPrompt,
+		EnvVars:  []string{"CHUNK_SUMMARY_PROMPT"},
+		Usage:    "Prompt used for summarizing a chunk of a long ticket thread in map-reduce mode",
+		Required: false,
+		Value: `
+		* you are a support engineer \n
+		* the input is a chunk of consecutive comments from a long ticket thread \n
+		* summarize the chunk chronologically, it will be combined with the summaries of the other chunks \n
+		* when the input holds summaries of earlier chunks instead of comments, combine them into one chronological summary \n
+		* keep who said what, reported problems, findings, decisions and commitments \n
+		* comments with author role end-user are from the customer, private comments are internal agent notes \n
+		* drop greetings, signatures and quoted replies \n
+		* return plain text only
+		`,
+	},
+	&cli.StringFlag{
+		Name:     FlagResolutionPrompt,
+		EnvVars:  []string{"RESOLUTION_PROMPT"},
+		Usage:    "Prompt used for generating the resolution summary of a closed ticket",
+		Required: false,
+		Value: `
+		* you are a support engineer reviewing a closed ticket \n
+		* identify the root cause of the customer's problem \n
+		* describe the fix or answer that resolved it \n
+		* return the result as json object with fields: root_cause and fix
+		`,
+	},
+	&cli.StringFlag{
+		Name:     FlagScoringPrompt,
+		EnvVars:  []string{"SCORING_PROMPT"},
+		Usage:    "Prompt used for scoring the customer's sentiment and urgency on a ticket",
+		Required: false,
+		Value: `
+		* you are a support engineer assessing how the customer feels about a ticket \n
+		* only consider comments from authors with role end-user as the customer \n
+		* sentiment: the customer's current sentiment from -1 (very negative) to 1 (very positive) \n
+		* frustration_trend: whether the customer's frustration is improving, stable or worsening over the thread \n
+		* urgency: how urgent the issue is for the customer from 0 (not urgent) to 1 (critical) \n
+		* return the result as json object with fields: sentiment, frustration_trend and urgency
+		`,
+	},
+	&cli.StringFlag{
+		Name:     FlagClassifyPrompt,
+		EnvVars:  []string{"CLASSIFY_PROMPT"},
+		Usage:    "Prompt used for classifying a ticket against the configured taxonomy",
+		Required: false,
+		Value: `
+		* you are a support engineer triaging a ticket \n
+		* for each field in the taxonomy pick the single value that best describes the ticket \n
+		* only use values listed for the field and omit the field if none apply \n
+		* return the result as json object mapping each field name to its value
+		`,
+	},
+	&cli.StringFlag{
+		Name:     FlagDraftReplyPrompt,
+		EnvVars:  []string{"DRAFT_REPLY_PROMPT"},
+		Usage:    "Prompt used for drafting the next reply to the customer",
+		Required: false,
+		Value: `
+		* you are a support engineer drafting the next reply to the customer on a ticket \n
+		* base the reply on the full thread including the history digest and private notes, but never disclose private notes \n
+		* follow the agent_instruction if given, e.g. tone or points to cover \n
+		* write the reply in the ticket's language if it is known \n
+		* return only the reply text without a subject line or signature
+		`,
+	},
+	&cli.StringFlag{
+		Name:     FlagLanguagePrompt,
+		EnvVars:  []string{"LANGUAGE_PROMPT"},
+		Usage:    "Prompt used for detecting the language the customer writes a ticket in",
+		Required: false,
+		Value: `
+		* detect the language the customer writes the ticket in \n
+		* return only its BCP 47 language tag, e.g. en, fr or pt-BR
+		`,
+	},
+	&cli.StringFlag{
+		Name:     FlagTranslatePrompt,
+		EnvVars:  []string{"TRANSLATE_PROMPT"},
+		Usage:    "Prompt used for translating a summary to the requested locale",
+		Required: false,
+		Value: `
+		* translate the summary to the language of the given BCP 47 locale \n
+		* translate the JSON values only, keep the keys, structure, names, ticket numbers and product terms unchanged \n
+		* return only the translated JSON object, or only the translated text when the summary is plain text
+		`,
+	},
 }
 
 // Common flags that apply to multiple commands
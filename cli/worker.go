@@ -2,10 +2,15 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	"github.com/taonic/ticketfu/config"
 	"github.com/taonic/ticketfu/worker"
+	"github.com/taonic/ticketfu/worker/reconcile"
+	"github.com/taonic/ticketfu/worker/related"
+	"github.com/taonic/ticketfu/worker/route"
+	"github.com/taonic/ticketfu/worker/ticket"
 	"github.com/urfave/cli/v2"
 	"go.temporal.io/server/common/log"
 	"go.uber.org/fx"
@@ -14,7 +19,26 @@ import (
 
 const (
 	// Worker-specific flags
-	FlagWorkerQueue = "queue"
+	FlagWorkerQueue        = "queue"
+	FlagTicketQuietPeriod  = "ticket-quiet-period"
+	FlagTicketMaxDelay     = "ticket-max-delay"
+	FlagSentimentThreshold = "escalation-sentiment-threshold"
+	FlagUrgencyThreshold   = "escalation-urgency-threshold"
+	FlagClassifyTaxonomy   = "classify-taxonomy"
+	FlagTicketCustomFields = "ticket-custom-fields"
+	FlagClassifyDryRun     = "classify-dry-run"
+
+	FlagSummaryNote                    = "summary-note"
+	FlagSummaryNoteOnReassignment      = "summary-note-on-reassignment"
+	FlagSummaryNoteEveryPublicComments = "summary-note-every-public-comments"
+
+	FlagEmbeddingIndex      = "embedding-index"
+	FlagServeEmbeddingIndex = "serve-embedding-index"
+	FlagRelatedTickets      = "related-tickets"
+	FlagReconcileInterval   = "reconcile-interval"
+
+	FlagMapReduceSummary     = "map-reduce-summary"
+	FlagMapReduceChunkTokens = "map-reduce-chunk-tokens"
 )
 
 // Worker-specific flags
@@ -25,6 +49,94 @@ var workerFlags = append(append(append(append([]cli.Flag{
 		Usage:   "worker queue name",
 		Value:   "default",
 	},
+	&cli.DurationFlag{
+		Name:    FlagTicketQuietPeriod,
+		EnvVars: []string{"TICKET_QUIET_PERIOD"},
+		Usage:   "wait for ticket updates to settle for this long before summarizing. 0 disables debouncing",
+		Value:   ticket.DefaultWorkflowConfig.QuietPeriod,
+	},
+	&cli.DurationFlag{
+		Name:    FlagTicketMaxDelay,
+		EnvVars: []string{"TICKET_MAX_DELAY"},
+		Usage:   "maximum time a burst of ticket updates can defer summarizing",
+		Value:   ticket.DefaultWorkflowConfig.MaxDelay,
+	},
+	&cli.Float64Flag{
+		Name:    FlagSentimentThreshold,
+		EnvVars: []string{"ESCALATION_SENTIMENT_THRESHOLD"},
+		Usage:   "escalate a ticket when the customer's sentiment drops to or below this score (-1 to 1)",
+		Value:   ticket.DefaultWorkflowConfig.SentimentThreshold,
+	},
+	&cli.Float64Flag{
+		Name:    FlagUrgencyThreshold,
+		EnvVars: []string{"ESCALATION_URGENCY_THRESHOLD"},
+		Usage:   "escalate a ticket when its urgency rises to or above this score (0 to 1)",
+		Value:   ticket.DefaultWorkflowConfig.UrgencyThreshold,
+	},
+	&cli.StringFlag{
+		Name:    FlagTicketCustomFields,
+		EnvVars: []string{"TICKET_CUSTOM_FIELDS"},
+		Usage:   `custom fields to capture on tickets as a JSON object mapping field IDs to names, e.g. {"360001234567":"product_area"}. Other custom fields are ignored`,
+	},
+	&cli.StringFlag{
+		Name:    FlagClassifyTaxonomy,
+		EnvVars: []string{"CLASSIFY_TAXONOMY"},
+		Usage:   `taxonomy to classify tickets by as a JSON list, e.g. [{"name":"severity","values":["low","high"],"field_id":123}]. Fields without a field_id are written as tags. Classification is disabled when empty`,
+	},
+	&cli.BoolFlag{
+		Name:    FlagClassifyDryRun,
+		EnvVars: []string{"CLASSIFY_DRY_RUN"},
+		Usage:   "only record suggested classifications instead of writing them back to Zendesk",
+	},
+	&cli.BoolFlag{
+		Name:    FlagSummaryNote,
+		EnvVars: []string{"SUMMARY_NOTE"},
+		Usage:   "post ticket summaries back to Zendesk as internal notes",
+	},
+	&cli.BoolFlag{
+		Name:    FlagSummaryNoteOnReassignment,
+		EnvVars: []string{"SUMMARY_NOTE_ON_REASSIGNMENT"},
+		Usage:   "post the summary note when a ticket is reassigned",
+		Value:   true,
+	},
+	&cli.IntFlag{
+		Name:    FlagSummaryNoteEveryPublicComments,
+		EnvVars: []string{"SUMMARY_NOTE_EVERY_PUBLIC_COMMENTS"},
+		Usage:   "post the summary note after this many new public comments. 0 disables",
+	},
+	&cli.StringFlag{
+		Name:    FlagEmbeddingIndex,
+		EnvVars: []string{"EMBEDDING_INDEX"},
+		Usage:   "file persisting the embeddings of ticket summaries used to find related tickets",
+		Value:   related.DefaultIndexPath,
+	},
+	&cli.BoolFlag{
+		Name:    FlagServeEmbeddingIndex,
+		EnvVars: []string{"SERVE_EMBEDDING_INDEX"},
+		Usage:   "serve the embedding index to the other workers. Exactly one worker must, for related tickets to be found",
+	},
+	&cli.BoolFlag{
+		Name:    FlagRelatedTickets,
+		EnvVars: []string{"RELATED_TICKETS"},
+		Usage:   "index ticket summaries for related ticket lookups. Requires a worker started with --serve-embedding-index, which implies it",
+	},
+	&cli.DurationFlag{
+		Name:    FlagReconcileInterval,
+		EnvVars: []string{"RECONCILE_INTERVAL"},
+		Usage:   "how often to catch up on ticket updates whose webhook was missed. 0 disables reconciliation",
+		Value:   reconcile.DefaultInterval,
+	},
+	&cli.BoolFlag{
+		Name:    FlagMapReduceSummary,
+		EnvVars: []string{"MAP_REDUCE_SUMMARY"},
+		Usage:   "summarize long threads in chunks combined into the ticket summary, instead of dropping the oldest comments over the context budget",
+	},
+	&cli.IntFlag{
+		Name:    FlagMapReduceChunkTokens,
+		EnvVars: []string{"MAP_REDUCE_CHUNK_TOKENS"},
+		Usage:   "estimated tokens of comments per chunk in map-reduce mode, also the budget of the chunk summaries combined",
+		Value:   ticket.DefaultChunkTokens,
+	},
 }, temporalFlags...), commonFlags...), zendeskFlags...), aiFlags...)
 
 // NewWorkerCommand creates a new worker command with subcommands
@@ -68,8 +180,104 @@ func startWorker(c *cli.Context) error {
 
 // NewWorkerConfig creates a WorkerConfig from CLI context
 func NewWorkerConfig(ctx *cli.Context) (config.WorkerConfig, error) {
+	var taxonomy []config.TaxonomyField
+	if raw := ctx.String(FlagClassifyTaxonomy); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &taxonomy); err != nil {
+			return config.WorkerConfig{}, fmt.Errorf("invalid %s: %w", FlagClassifyTaxonomy, err)
+		}
+	}
+
+	var customFields map[int64]string
+	if raw := ctx.String(FlagTicketCustomFields); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &customFields); err != nil {
+			return config.WorkerConfig{}, fmt.Errorf("invalid %s: %w", FlagTicketCustomFields, err)
+		}
+	}
+
 	return config.WorkerConfig{
 		QueueName: ctx.String(FlagWorkerQueue),
+		TicketWorkflow: config.TicketWorkflowConfig{
+			QuietPeriod: ctx.Duration(FlagTicketQuietPeriod),
+			MaxDelay:    ctx.Duration(FlagTicketMaxDelay),
+
+			SentimentThreshold: ctx.Float64(FlagSentimentThreshold),
+			UrgencyThreshold:   ctx.Float64(FlagUrgencyThreshold),
+
+			CustomFields: customFields,
+
+			RelatedTickets: ctx.Bool(FlagRelatedTickets) || ctx.Bool(FlagServeEmbeddingIndex),
+
+			Classification: config.ClassificationConfig{
+				Taxonomy: taxonomy,
+				DryRun:   ctx.Bool(FlagClassifyDryRun),
+			},
+			SummaryNote: config.SummaryNoteConfig{
+				Enabled:             ctx.Bool(FlagSummaryNote),
+				OnReassignment:      ctx.Bool(FlagSummaryNoteOnReassignment),
+				EveryPublicComments: ctx.Int(FlagSummaryNoteEveryPublicComments),
+			},
+			MapReduce: config.MapReduceConfig{
+				Enabled:     ctx.Bool(FlagMapReduceSummary),
+				ChunkTokens: ctx.Int(FlagMapReduceChunkTokens),
+			},
+		},
+		EmbeddingIndexPath:  ctx.String(FlagEmbeddingIndex),
+		ServeEmbeddingIndex: ctx.Bool(FlagServeEmbeddingIndex),
+		ReconcileInterval:   ctx.Duration(FlagReconcileInterval),
+	}, nil
+}
+
+// NewAIConfig creates an AIConfig from CLI context
+func NewAIConfig(ctx *cli.Context) (config.AIConfig, error) {
+	var redactionRules []config.RedactionRule
+	if raw := ctx.String(FlagRedactionRules); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &redactionRules); err != nil {
+			return config.AIConfig{}, fmt.Errorf("invalid %s: %w", FlagRedactionRules, err)
+		}
+	}
+
+	var promptRoutes []config.PromptRoute
+	if path := ctx.String(FlagPromptRoutes); path != "" {
+		routes, err := route.Load(path)
+		if err != nil {
+			return config.AIConfig{}, fmt.Errorf("invalid %s: %w", FlagPromptRoutes, err)
+		}
+		promptRoutes = routes
+	}
+
+	var contextBudgets map[string]config.ContextBudget
+	if raw := ctx.String(FlagContextBudgets); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &contextBudgets); err != nil {
+			return config.AIConfig{}, fmt.Errorf("invalid %s: %w", FlagContextBudgets, err)
+		}
+		for key, budget := range contextBudgets {
+			if budget.ContextWindow <= 0 || budget.OutputReserve < 0 || budget.OutputReserve >= budget.ContextWindow {
+				return config.AIConfig{}, fmt.Errorf("invalid %s: %s must reserve less output than its positive context window", FlagContextBudgets, key)
+			}
+		}
+	}
+
+	return config.AIConfig{
+		LLMProvider:         ctx.String(FlagLLMProvider),
+		LLMModel:            ctx.String(FlagLLMModel),
+		LLMAPIKey:           ctx.String(FlagLLMAPIKey),
+		EmbeddingModel:      ctx.String(FlagEmbeddingModel),
+		TicketSummaryPrompt: ctx.String(FlagTicketSummaryPrompt),
+		OrgSummaryPrompt:    ctx.String(FlagOrgSummaryPrompt),
+		CommentDigestPrompt: ctx.String(FlagCommentDigestPrompt),
+		ChunkSummaryPrompt:  ctx.String(FlagChunkSummaryPrompt),
+		ResolutionPrompt:    ctx.String(FlagResolutionPrompt),
+		ScoringPrompt:       ctx.String(FlagScoringPrompt),
+		ClassifyPrompt:      ctx.String(FlagClassifyPrompt),
+		DraftReplyPrompt:    ctx.String(FlagDraftReplyPrompt),
+		LanguagePrompt:      ctx.String(FlagLanguagePrompt),
+		TranslatePrompt:     ctx.String(FlagTranslatePrompt),
+		Redaction: config.RedactionConfig{
+			Enabled: ctx.Bool(FlagRedactPII),
+			Rules:   redactionRules,
+		},
+		PromptRoutes:   promptRoutes,
+		ContextBudgets: contextBudgets,
 	}, nil
 }
 
@@ -94,12 +302,9 @@ func NewWorkerApp(ctx *cli.Context) (*fx.App, error) {
 		ZendeskToken:     ctx.String(FlagZendeskToken),
 	}
 
-	aiConfig := config.AIConfig{
-		LLMProvider:         ctx.String(FlagLLMProvider),
-		LLMModel:            ctx.String(FlagLLMModel),
-		LLMAPIKey:           ctx.String(FlagLLMAPIKey),
-		TicketSummaryPrompt: ctx.String(FlagTicketSummaryPrompt),
-		OrgSummaryPrompt:    ctx.String(FlagOrgSummaryPrompt),
+	aiConfig, err := NewAIConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AI config: %w", err)
 	}
 
 	temporalClientConfig := config.TemporalClientConfig{
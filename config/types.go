@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 type (
 	TemporalClientConfig struct {
 		Address     string // Temporal service address
@@ -20,8 +22,81 @@ type (
 		LLMModel    string
 		LLMAPIKey   string
 
+		// Model embedding ticket summaries, the provider's default when empty
+		EmbeddingModel string
+
 		TicketSummaryPrompt string
 		OrgSummaryPrompt    string
+		CommentDigestPrompt string
+		ChunkSummaryPrompt  string
+		ResolutionPrompt    string
+		ScoringPrompt       string
+		ClassifyPrompt      string
+		DraftReplyPrompt    string
+		LanguagePrompt      string
+		TranslatePrompt     string
+
+		Redaction RedactionConfig
+
+		// Ordered rules picking the summary prompt by ticket or organization.
+		// The default prompts apply when none match.
+		PromptRoutes []PromptRoute
+
+		// Context budgets keyed by "provider/model", model or provider,
+		// overriding the built-in ones of known models
+		ContextBudgets map[string]ContextBudget
+	}
+
+	// ContextBudget is how many tokens a model accepts, and how many of them
+	// are reserved for its response
+	ContextBudget struct {
+		ContextWindow int `json:"context_window"`
+		OutputReserve int `json:"output_reserve"`
+	}
+
+	// PromptRoute overrides the summary prompts for the tickets or
+	// organizations it matches
+	PromptRoute struct {
+		Name  string     `yaml:"name"`
+		Match RouteMatch `yaml:"match"`
+
+		TicketSummaryPrompt string `yaml:"ticket_summary_prompt"`
+		// Fields the ticket summary must have on top of intent, summary and next_step
+		SummaryFields    []SummaryField `yaml:"summary_fields"`
+		OrgSummaryPrompt string         `yaml:"org_summary_prompt"`
+	}
+
+	// RouteMatch holds the criteria a route matches on. Every criterion set
+	// must match, and a list matches if any of its values do. An empty match
+	// matches everything.
+	RouteMatch struct {
+		BrandIDs        []int64           `yaml:"brand_ids"`
+		GroupIDs        []int64           `yaml:"group_ids"`
+		Types           []string          `yaml:"types"`
+		Tags            []string          `yaml:"tags"`
+		CustomFields    map[string]string `yaml:"custom_fields"` // Keyed by the names configured in TicketWorkflowConfig.CustomFields
+		OrganizationIDs []int64           `yaml:"organization_ids"`
+	}
+
+	// SummaryField is a required field of a routed ticket summary
+	SummaryField struct {
+		Name        string `yaml:"name"`
+		Type        string `yaml:"type"` // string, number, boolean, array or object
+		Description string `yaml:"description"`
+	}
+
+	// RedactionConfig sets how sensitive values are redacted from content
+	// before it's sent to the LLM
+	RedactionConfig struct {
+		Enabled bool
+		Rules   []RedactionRule // Custom rules applied before the built-in detectors
+	}
+
+	// RedactionRule redacts the matches of a regular expression, e.g. internal
+	// account numbers, as [<NAME>_<n>] tokens
+	RedactionRule struct {
+		Name    string `json:"name"`
+		Pattern string `json:"pattern"`
 	}
 
 	ServerConfig struct {
@@ -33,6 +108,67 @@ type (
 	}
 
 	WorkerConfig struct {
-		QueueName string
+		QueueName      string
+		TicketWorkflow TicketWorkflowConfig
+
+		// File persisting the embeddings of ticket summaries used to find
+		// related tickets
+		EmbeddingIndexPath string
+		// Whether this worker serves the embedding index. It's local to the
+		// worker, so exactly one does.
+		ServeEmbeddingIndex bool
+
+		// How often tickets updated in Zendesk are reconciled in case their
+		// webhook was missed. 0 disables reconciliation
+		ReconcileInterval time.Duration
+	}
+
+	TicketWorkflowConfig struct {
+		QuietPeriod time.Duration // Wait for upsert signals to settle before processing
+		MaxDelay    time.Duration // Upper bound on how long a burst of signals can defer processing
+
+		// Escalate when the customer's sentiment drops to or below, or the
+		// urgency rises to or above, these thresholds
+		SentimentThreshold float64
+		UrgencyThreshold   float64
+
+		// Custom fields to capture on tickets, mapping field IDs to readable
+		// names. Fields not listed are dropped
+		CustomFields map[int64]string
+
+		// Whether a worker serves the related tickets index, so summaries
+		// are indexed as they're generated
+		RelatedTickets bool
+
+		Classification ClassificationConfig
+		SummaryNote    SummaryNoteConfig
+		MapReduce      MapReduceConfig
+	}
+
+	// MapReduceConfig sets how long threads are summarized hierarchically.
+	// Older comments are summarized in chunks, which are cached and combined
+	// with the latest comments into the ticket summary.
+	MapReduceConfig struct {
+		Enabled     bool
+		ChunkTokens int // Estimated tokens of comments per chunk
+	}
+
+	// SummaryNoteConfig sets when the summary is posted to Zendesk as an internal note
+	SummaryNoteConfig struct {
+		Enabled             bool
+		OnReassignment      bool // Post when the ticket is reassigned
+		EveryPublicComments int  // Post after this many new public comments. 0 disables
+	}
+
+	ClassificationConfig struct {
+		Taxonomy []TaxonomyField // Classification is disabled when empty
+		DryRun   bool            // Only record suggestions instead of writing them back to Zendesk
+	}
+
+	// TaxonomyField is a dimension tickets are classified by, e.g. product area
+	TaxonomyField struct {
+		Name    string   `json:"name"`
+		Values  []string `json:"values"`
+		FieldID int64    `json:"field_id,omitempty"` // Custom field to write the value to. Written as a "<name>_<value>" tag when unset
 	}
 )
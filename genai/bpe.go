@@ -0,0 +1,104 @@
+package genai
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkoukk/tiktoken-go"
+)
+
+// bpeDownloadTimeout bounds downloading a tiktoken encoding. tiktoken's own
+// loader downloads without a timeout, which can hang on a worker without
+// network access.
+const bpeDownloadTimeout = 10 * time.Second
+
+// bpeClient downloads tiktoken encodings
+var bpeClient = &http.Client{Timeout: bpeDownloadTimeout}
+
+func init() {
+	tiktoken.SetBpeLoader(bpeLoader{})
+}
+
+// bpeLoader loads tiktoken encodings like tiktoken's own loader, from the
+// same cache directory, but downloads them with a timeout
+type bpeLoader struct{}
+
+func (bpeLoader) LoadTiktokenBpe(bpeFile string) (map[string]int, error) {
+	contents, err := readBpeFile(bpeFile)
+	if err != nil {
+		return nil, err
+	}
+
+	ranks := make(map[string]int)
+	for _, line := range strings.Split(string(contents), "\n") {
+		if line == "" {
+			continue
+		}
+		token, rank, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("invalid encoding line %q", line)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(token)
+		if err != nil {
+			return nil, err
+		}
+		ranks[string(decoded)], err = strconv.Atoi(rank)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ranks, nil
+}
+
+// readBpeFile reads a local encoding file, or downloads one unless it was
+// cached before
+func readBpeFile(bpeFile string) ([]byte, error) {
+	if !strings.HasPrefix(bpeFile, "http://") && !strings.HasPrefix(bpeFile, "https://") {
+		return os.ReadFile(bpeFile)
+	}
+
+	cachePath := filepath.Join(bpeCacheDir(), fmt.Sprintf("%x", sha1.Sum([]byte(bpeFile))))
+	if contents, err := os.ReadFile(cachePath); err == nil {
+		return contents, nil
+	}
+
+	resp, err := bpeClient.Get(bpeFile)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", bpeFile, resp.Status)
+	}
+	contents, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Caching is best effort, e.g. on a read-only file system
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0o755); err == nil {
+		tmp := fmt.Sprintf("%s.%d.tmp", cachePath, time.Now().UnixNano())
+		if err := os.WriteFile(tmp, contents, 0o644); err == nil {
+			_ = os.Rename(tmp, cachePath)
+		}
+	}
+	return contents, nil
+}
+
+// bpeCacheDir is where tiktoken caches downloaded encodings
+func bpeCacheDir() string {
+	for _, env := range []string{"TIKTOKEN_CACHE_DIR", "DATA_GYM_CACHE_DIR"} {
+		if dir := os.Getenv(env); dir != "" {
+			return dir
+		}
+	}
+	return filepath.Join(os.TempDir(), "data-gym-cache")
+}
@@ -0,0 +1,35 @@
+package genai
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBpeLoader(t *testing.T) {
+	t.Setenv("TIKTOKEN_CACHE_DIR", t.TempDir())
+
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		w.Write([]byte("YQ== 0\nYmM= 1\n"))
+	}))
+	defer server.Close()
+
+	// Downloaded once, then read from the cache
+	for range 2 {
+		ranks, err := bpeLoader{}.LoadTiktokenBpe(server.URL + "/test.tiktoken")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"a": 0, "bc": 1}, ranks)
+	}
+	assert.Equal(t, 1, downloads)
+
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusNotFound)
+	})
+	_, err := bpeLoader{}.LoadTiktokenBpe(server.URL + "/missing.tiktoken")
+	assert.ErrorContains(t, err, "404")
+}
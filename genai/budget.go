@@ -0,0 +1,168 @@
+package genai
+
+import (
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkoukk/tiktoken-go"
+	"github.com/taonic/ticketfu/config"
+)
+
+// charsPerToken is how many characters a token is estimated to hold when the
+// provider has no local tokenizer. English averages about 4, so 3 leaves
+// headroom for JSON and other languages.
+const charsPerToken = 3
+
+var (
+	// defaultBudgets are the context budgets of known models, matched by the
+	// longest prefix of the model name
+	defaultBudgets = map[string]config.ContextBudget{
+		"gpt-3.5-turbo":  {ContextWindow: 16_385, OutputReserve: 4_096},
+		"gpt-4":          {ContextWindow: 8_192, OutputReserve: 2_048},
+		"gpt-4-turbo":    {ContextWindow: 128_000, OutputReserve: 4_096},
+		"gpt-4o":         {ContextWindow: 128_000, OutputReserve: 16_384},
+		"gpt-4.1":        {ContextWindow: 1_047_576, OutputReserve: 32_768},
+		"o1":             {ContextWindow: 200_000, OutputReserve: 100_000},
+		"o3":             {ContextWindow: 200_000, OutputReserve: 100_000},
+		"o4":             {ContextWindow: 200_000, OutputReserve: 100_000},
+		"gemini":         {ContextWindow: 1_048_576, OutputReserve: 8_192},
+		"gemini-1.5-pro": {ContextWindow: 2_097_152, OutputReserve: 8_192},
+		"claude":         {ContextWindow: 200_000, OutputReserve: 8_192},
+	}
+
+	// fallbackBudget applies to unknown models
+	fallbackBudget = config.ContextBudget{ContextWindow: 128_000, OutputReserve: 4_096}
+
+	// encodings caches the tiktoken encoding of each model, and when loading
+	// it last failed so it isn't retried before encodingRetryInterval
+	encodings   = map[string]encodingEntry{}
+	encodingsMu sync.Mutex
+)
+
+// encodingRetryInterval is how long tokens are estimated after an encoding
+// failed to load before loading it is tried again
+const encodingRetryInterval = 10 * time.Minute
+
+type (
+	// Tokenizer counts the tokens of text as a model would
+	Tokenizer interface {
+		CountTokens(text string) int
+	}
+
+	// Budget is a model's context window, to be split between the parts of a
+	// request
+	Budget struct {
+		config.ContextBudget
+		tokenizer Tokenizer
+	}
+
+	// Allocation is how many tokens each part of a request gets
+	Allocation struct {
+		Prompt   int
+		Metadata int
+		Content  int // Left for the content fitted to the budget, e.g. comments
+		Output   int
+	}
+
+	// approxTokenizer estimates tokens from the length of the text
+	approxTokenizer struct{}
+
+	// tiktokenTokenizer counts tokens with OpenAI's encodings. Models
+	// tiktoken doesn't know yet are counted with cl100k_base.
+	tiktokenTokenizer struct {
+		model string
+	}
+
+	encodingEntry struct {
+		encoding *tiktoken.Tiktoken
+		failedAt time.Time // Set when the encoding couldn't be loaded
+	}
+)
+
+// NewBudget returns the context budget of the configured model. Budgets are
+// looked up by "provider/model", then model, then provider, before falling
+// back to the built-in ones.
+func NewBudget(cfg config.AIConfig) Budget {
+	var tokenizer Tokenizer = approxTokenizer{}
+	if cfg.LLMProvider == OpenAI {
+		tokenizer = tiktokenTokenizer{model: cfg.LLMModel}
+	}
+
+	for _, key := range []string{cfg.LLMProvider + "/" + cfg.LLMModel, cfg.LLMModel, cfg.LLMProvider} {
+		if budget, ok := cfg.ContextBudgets[key]; ok {
+			return Budget{ContextBudget: budget, tokenizer: tokenizer}
+		}
+	}
+
+	return Budget{ContextBudget: defaultBudget(cfg.LLMModel), tokenizer: tokenizer}
+}
+
+// CountTokens counts the tokens of the text
+func (b Budget) CountTokens(text string) int {
+	return b.tokenizer.CountTokens(text)
+}
+
+// Allocate reserves tokens for the model's response, the prompt and the
+// metadata sent along the content, leaving the rest to the content
+func (b Budget) Allocate(prompt, metadata string) Allocation {
+	allocation := Allocation{
+		Prompt:   b.CountTokens(prompt),
+		Metadata: b.CountTokens(metadata),
+		Output:   b.OutputReserve,
+	}
+	allocation.Content = max(0, b.ContextWindow-allocation.Output-allocation.Prompt-allocation.Metadata)
+	return allocation
+}
+
+func defaultBudget(model string) config.ContextBudget {
+	budget, matched := fallbackBudget, ""
+	for prefix, b := range defaultBudgets {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(matched) {
+			budget, matched = b, prefix
+		}
+	}
+	return budget
+}
+
+// EstimateTokens estimates the tokens of the text from its length, the same
+// for every model, e.g. to size content in a workflow deterministically
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + charsPerToken - 1) / charsPerToken
+}
+
+func (approxTokenizer) CountTokens(text string) int {
+	return EstimateTokens(text)
+}
+
+// CountTokens falls back to estimating when the encoding can't be loaded,
+// e.g. without network access to download it
+func (t tiktokenTokenizer) CountTokens(text string) int {
+	if encoding := encodingFor(t.model); encoding != nil {
+		return len(encoding.EncodeOrdinary(text))
+	}
+	return approxTokenizer{}.CountTokens(text)
+}
+
+// encodingFor returns the model's encoding, nil while it can't be loaded.
+// Loads are serialized so concurrent counts don't download it again.
+func encodingFor(model string) *tiktoken.Tiktoken {
+	encodingsMu.Lock()
+	defer encodingsMu.Unlock()
+
+	if entry, ok := encodings[model]; ok && (entry.encoding != nil || time.Since(entry.failedAt) < encodingRetryInterval) {
+		return entry.encoding
+	}
+
+	encoding, err := tiktoken.EncodingForModel(model)
+	if err != nil {
+		encoding, err = tiktoken.GetEncoding(tiktoken.MODEL_CL100K_BASE)
+	}
+	if err != nil {
+		encodings[model] = encodingEntry{failedAt: time.Now()}
+		return nil
+	}
+	encodings[model] = encodingEntry{encoding: encoding}
+	return encoding
+}
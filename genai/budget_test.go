@@ -0,0 +1,121 @@
+package genai
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/taonic/ticketfu/config"
+)
+
+func TestNewBudget(t *testing.T) {
+	overrides := map[string]config.ContextBudget{
+		"openai/gpt-4o": {ContextWindow: 1000, OutputReserve: 100},
+		"gpt-4o":        {ContextWindow: 2000, OutputReserve: 200},
+		"anthropic":     {ContextWindow: 3000, OutputReserve: 300},
+	}
+
+	tests := []struct {
+		name     string
+		cfg      config.AIConfig
+		expected config.ContextBudget
+	}{
+		{
+			name:     "Provider And Model",
+			cfg:      config.AIConfig{LLMProvider: OpenAI, LLMModel: "gpt-4o", ContextBudgets: overrides},
+			expected: config.ContextBudget{ContextWindow: 1000, OutputReserve: 100},
+		},
+		{
+			name:     "Model",
+			cfg:      config.AIConfig{LLMProvider: "azure", LLMModel: "gpt-4o", ContextBudgets: overrides},
+			expected: config.ContextBudget{ContextWindow: 2000, OutputReserve: 200},
+		},
+		{
+			name:     "Provider",
+			cfg:      config.AIConfig{LLMProvider: Anthropic, LLMModel: "claude-3-5-haiku-latest", ContextBudgets: overrides},
+			expected: config.ContextBudget{ContextWindow: 3000, OutputReserve: 300},
+		},
+		{
+			name:     "Longest Built-in Prefix",
+			cfg:      config.AIConfig{LLMProvider: OpenAI, LLMModel: "gpt-4o-mini"},
+			expected: defaultBudgets["gpt-4o"],
+		},
+		{
+			name:     "Built-in Model Family",
+			cfg:      config.AIConfig{LLMProvider: GoogleAI, LLMModel: "gemini-2.0-flash"},
+			expected: defaultBudgets["gemini"],
+		},
+		{
+			name:     "Unknown Model",
+			cfg:      config.AIConfig{LLMProvider: GoogleAI, LLMModel: "gemma-3"},
+			expected: fallbackBudget,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, NewBudget(tt.cfg).ContextBudget)
+		})
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	budget := NewBudget(config.AIConfig{
+		LLMProvider:    Anthropic,
+		LLMModel:       "claude-3-5-haiku-latest",
+		ContextBudgets: map[string]config.ContextBudget{"anthropic": {ContextWindow: 100, OutputReserve: 20}},
+	})
+
+	// Estimated at 3 characters a token, rounding up
+	assert.Equal(t, 0, budget.CountTokens(""))
+	assert.Equal(t, 2, budget.CountTokens("four"))
+	assert.Equal(t, 2, budget.CountTokens("héllo"))
+
+	assert.Equal(t, Allocation{Prompt: 10, Metadata: 20, Content: 50, Output: 20},
+		budget.Allocate(string(make([]byte, 30)), string(make([]byte, 60))))
+
+	// The content gets nothing once the rest takes the whole window
+	assert.Equal(t, 0, budget.Allocate(string(make([]byte, 300)), "").Content)
+}
+
+type failingTransport struct {
+	dials int
+}
+
+func (t *failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	t.dials++
+	return nil, errors.New("network is unreachable")
+}
+
+func TestCountTokensOffline(t *testing.T) {
+	t.Setenv("TIKTOKEN_CACHE_DIR", t.TempDir())
+
+	transport := &failingTransport{}
+	client := bpeClient
+	bpeClient = &http.Client{Transport: transport}
+	t.Cleanup(func() { bpeClient = client })
+
+	encodingsMu.Lock()
+	delete(encodings, "gpt-4o")
+	encodingsMu.Unlock()
+
+	budget := NewBudget(config.AIConfig{LLMProvider: OpenAI, LLMModel: "gpt-4o"})
+
+	// Tokens are estimated once the encoding fails to download
+	assert.Equal(t, EstimateTokens("four"), budget.CountTokens("four"))
+	dials := transport.dials
+	assert.Positive(t, dials)
+
+	// and aren't downloaded again for every count
+	assert.Equal(t, EstimateTokens("héllo"), budget.CountTokens("héllo"))
+	assert.Equal(t, dials, transport.dials)
+
+	// until the retry interval elapses
+	encodingsMu.Lock()
+	encodings["gpt-4o"] = encodingEntry{failedAt: time.Now().Add(-encodingRetryInterval)}
+	encodingsMu.Unlock()
+	budget.CountTokens("four")
+	assert.Greater(t, transport.dials, dials)
+}
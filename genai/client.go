@@ -2,6 +2,7 @@ package genai
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -20,14 +21,24 @@ const (
 	Anthropic = "anthropic"
 )
 
+// ErrEmbeddingUnsupported is returned by Embed for providers without an
+// embedding API, e.g. Anthropic
+var ErrEmbeddingUnsupported = errors.New("embeddings are not supported by the LLM provider")
+
 type genAI struct {
-	logger log.Logger
-	model  llms.Model
-	Config config.AIConfig
+	logger   log.Logger
+	model    llms.Model
+	embedder embedder // nil when the provider has no embedding API
+	Config   config.AIConfig
+}
+
+type embedder interface {
+	CreateEmbedding(ctx context.Context, texts []string) ([][]float32, error)
 }
 
 type API interface {
 	GenerateContent(ctx context.Context, instruction, content string) (string, error)
+	Embed(ctx context.Context, content string) ([]float32, error)
 	GetConfig() config.AIConfig
 }
 
@@ -37,13 +48,36 @@ func NewAPI(logger log.Logger, config config.AIConfig) (API, error) {
 	}
 	ctx := context.Background()
 
+	var redactor *Redactor
+	if config.Redaction.Enabled {
+		var err error
+		if redactor, err = NewRedactor(config.Redaction.Rules); err != nil {
+			return nil, err
+		}
+	}
+
 	var model llms.Model
+	var embedder embedder
 	var err error
 	switch config.LLMProvider {
 	case OpenAI:
-		model, err = openai.New(openai.WithToken(config.LLMAPIKey), openai.WithModel(config.LLMModel))
+		opts := []openai.Option{openai.WithToken(config.LLMAPIKey), openai.WithModel(config.LLMModel)}
+		if config.EmbeddingModel != "" {
+			opts = append(opts, openai.WithEmbeddingModel(config.EmbeddingModel))
+		}
+		var llm *openai.LLM
+		if llm, err = openai.New(opts...); err == nil {
+			model, embedder = llm, llm
+		}
 	case GoogleAI:
-		model, err = googleai.New(ctx, googleai.WithAPIKey(config.LLMAPIKey), googleai.WithDefaultModel(config.LLMModel))
+		opts := []googleai.Option{googleai.WithAPIKey(config.LLMAPIKey), googleai.WithDefaultModel(config.LLMModel)}
+		if config.EmbeddingModel != "" {
+			opts = append(opts, googleai.WithDefaultEmbeddingModel(config.EmbeddingModel))
+		}
+		var llm *googleai.GoogleAI
+		if llm, err = googleai.New(ctx, opts...); err == nil {
+			model, embedder = llm, llm
+		}
 	case Anthropic:
 		model, err = anthropic.New(anthropic.WithToken(config.LLMAPIKey), anthropic.WithModel(config.LLMModel))
 	default:
@@ -56,9 +90,15 @@ func NewAPI(logger log.Logger, config config.AIConfig) (API, error) {
 	logger.Info("Configured LLM", tag.Value(config.LLMModel))
 
 	genAI := genAI{
-		logger: logger,
-		Config: config,
-		model:  model,
+		logger:   logger,
+		Config:   config,
+		model:    model,
+		embedder: embedder,
+	}
+
+	if redactor != nil {
+		logger.Info("Redacting sensitive values sent to the LLM", tag.NewInt("custom-rules", len(config.Redaction.Rules)))
+		return NewRedactingAPI(logger, &genAI, redactor), nil
 	}
 
 	return &genAI, nil
@@ -80,6 +120,22 @@ func (a *genAI) GenerateContent(ctx context.Context, instruction, content string
 	return result.String(), nil
 }
 
+// Embed returns the embedding vector of the content
+func (a *genAI) Embed(ctx context.Context, content string) ([]float32, error) {
+	if a.embedder == nil {
+		return nil, fmt.Errorf("%w: %s", ErrEmbeddingUnsupported, a.Config.LLMProvider)
+	}
+
+	vectors, err := a.embedder.CreateEmbedding(ctx, []string{content})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedding %w", err)
+	}
+	if len(vectors) != 1 || len(vectors[0]) == 0 {
+		return nil, errors.New("failed to create embedding: empty response")
+	}
+	return vectors[0], nil
+}
+
 func (a *genAI) GetConfig() config.AIConfig {
 	return a.Config
 }
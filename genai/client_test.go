@@ -204,3 +204,26 @@ func partToString(t *testing.T, cp llms.ContentPart) string {
 	}
 	return part.String()
 }
+
+// fakeEmbedder returns a fixed vector per text
+type fakeEmbedder struct{}
+
+func (fakeEmbedder) CreateEmbedding(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i := range texts {
+		vectors[i] = []float32{0.1, 0.2}
+	}
+	return vectors, nil
+}
+
+func TestEmbed(t *testing.T) {
+	api := &genAI{logger: log.NewTestLogger(), embedder: fakeEmbedder{}}
+	vector, err := api.Embed(context.Background(), "Login keeps failing")
+	assert.NoError(t, err)
+	assert.Equal(t, []float32{0.1, 0.2}, vector)
+
+	// Anthropic has no embedding API
+	api = &genAI{logger: log.NewTestLogger(), Config: config.AIConfig{LLMProvider: Anthropic}}
+	_, err = api.Embed(context.Background(), "Login keeps failing")
+	assert.ErrorIs(t, err, ErrEmbeddingUnsupported)
+}
@@ -0,0 +1,21 @@
+package genai
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// ParseLocale validates a BCP 47 locale such as "fr" or "pt-BR" and returns
+// its canonical form, which is used as the translation cache key
+func ParseLocale(locale string) (string, error) {
+	tag, err := language.Parse(strings.TrimSpace(locale))
+	if err != nil {
+		return "", fmt.Errorf("invalid locale %q: %w", locale, err)
+	}
+	if tag == language.Und {
+		return "", fmt.Errorf("invalid locale %q", locale)
+	}
+	return tag.String(), nil
+}
@@ -0,0 +1,34 @@
+package genai
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLocale(t *testing.T) {
+	testCases := []struct {
+		locale      string
+		expected    string
+		expectError bool
+	}{
+		{locale: "fr", expected: "fr"},
+		{locale: " pt-br ", expected: "pt-BR"},
+		{locale: "zh_Hant", expected: "zh-Hant"},
+		{locale: "", expectError: true},
+		{locale: "und", expectError: true},
+		{locale: "not a locale", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.locale, func(t *testing.T) {
+			locale, err := ParseLocale(tc.locale)
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, locale)
+		})
+	}
+}
@@ -0,0 +1,314 @@
+package genai
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/taonic/ticketfu/config"
+	"go.temporal.io/server/common/log"
+	"go.temporal.io/server/common/log/tag"
+)
+
+// Built-in redaction rules, applied after the configured ones
+const (
+	RedactEmail  = "email"
+	RedactAPIKey = "api_key"
+	RedactCard   = "card"
+	RedactIP     = "ip"
+	RedactPhone  = "phone"
+	RedactName   = "name"
+)
+
+var ruleName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// phonePattern matches numbers in international format, national numbers
+// with their area code in parentheses or a leading trunk 0, and North
+// American numbers. Other grouped digits, e.g. order numbers such as
+// 2024-1234-5678, are left alone.
+var phonePattern = regexp.MustCompile(strings.Join([]string{
+	`\+\d{8,15}\b`,
+	`\+\d{1,3}[\s.-]?(?:\(\d{1,4}\)|\d{1,4})(?:[\s.-]\d{2,4}){1,4}\b`,
+	`(?:\(\d{2,4}\)\s?|\b0\d{1,4}[\s.-])\d{3,4}[\s.-]\d{3,4}\b`,
+	`\b\d{3}[\s.-]\d{3}[\s.-]\d{4}\b`,
+}, "|"))
+
+// namePattern matches the people a ticket's JSON names: its requester and
+// assignee, and the authors of its comments
+var namePattern = regexp.MustCompile(`"(?:Requester|Assignee)"\s*:\s*"((?:[^"\\]|\\.)+)"|"Author"\s*:\s*\{[^{}]*?"Name"\s*:\s*"((?:[^"\\]|\\.)+)"`)
+
+type (
+	// Redactor replaces sensitive values with placeholder tokens before content
+	// is sent to the LLM
+	Redactor struct {
+		rules []rule
+	}
+
+	rule struct {
+		name    string
+		pattern *regexp.Regexp
+		valid   func(match string) bool // Optional check to drop false positives
+	}
+
+	// Redaction holds the values redacted from one piece of content so they
+	// can be restored in the LLM output
+	Redaction struct {
+		// Number of values redacted per rule
+		Counts map[string]int
+
+		tokens    map[string]string // Original value to its token
+		originals map[string]string // Token to its original value
+	}
+
+	redactingAPI struct {
+		API
+		logger   log.Logger
+		redactor *Redactor
+	}
+)
+
+// NewRedactor returns a redactor applying the custom rules followed by the
+// built-in detectors for emails, API keys, card numbers, IPs, phone numbers
+// and the names of the people on a ticket
+func NewRedactor(custom []config.RedactionRule) (*Redactor, error) {
+	var rules []rule
+	for _, r := range custom {
+		if !ruleName.MatchString(r.Name) {
+			return nil, fmt.Errorf("invalid redaction rule name %q: use lowercase letters, digits and underscores", r.Name)
+		}
+		pattern, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern for redaction rule %q: %w", r.Name, err)
+		}
+		rules = append(rules, rule{name: r.Name, pattern: pattern})
+	}
+
+	// Emails and secrets go first as they may contain digit runs the later
+	// rules would match, and IPs go before phone numbers for the same reason
+	rules = append(rules,
+		rule{name: RedactEmail, pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+		rule{name: RedactAPIKey, pattern: regexp.MustCompile(`\b(?:sk-[A-Za-z0-9_-]{20,}|AKIA[0-9A-Z]{16}|gh[pousr]_[A-Za-z0-9]{36,}|xox[abprs]-[A-Za-z0-9-]{10,}|AIza[0-9A-Za-z_-]{35})|(?i:bearer\s+[A-Za-z0-9._~+/-]{20,}=*)|(?i:\b(?:api[_-]?key|secret|token|password)\b\s*[:=]\s*[^\s"'\\,;]{8,})`)},
+		rule{name: RedactCard, pattern: regexp.MustCompile(`\b[3-6](?:[ -]?\d){12,18}\b`), valid: luhn},
+		rule{name: RedactIP, pattern: regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b|\b(?:[0-9A-Fa-f]{1,4}:){1,7}(?:(?::[0-9A-Fa-f]{1,4}){1,7}|[0-9A-Fa-f]{1,4}|:)`), valid: ipAddress},
+		rule{name: RedactPhone, pattern: phonePattern, valid: phoneNumber},
+	)
+
+	return &Redactor{rules: rules}, nil
+}
+
+// Redact replaces each sensitive value with a token such as [EMAIL_1]. The
+// same value gets the same token throughout the content.
+func (r *Redactor) Redact(content string) (string, *Redaction) {
+	redacted, redaction := r.RedactAll(content)
+	return redacted[0], redaction
+}
+
+// RedactAll redacts several pieces of content sent together, e.g. a prompt
+// and the ticket it's about, so a value gets the same token in all of them.
+// The names of the people on a ticket are redacted wherever they appear,
+// along with their first and last names on their own.
+func (r *Redactor) RedactAll(contents ...string) ([]string, *Redaction) {
+	redaction := &Redaction{
+		Counts:    make(map[string]int),
+		tokens:    make(map[string]string),
+		originals: make(map[string]string),
+	}
+
+	// Names are found before the other rules replace parts of them, and
+	// redacted after so they don't break up the emails they may be part of
+	names := personNames(contents)
+
+	redacted := make([]string, len(contents))
+	for i, content := range contents {
+		for _, rule := range r.rules {
+			content = rule.pattern.ReplaceAllStringFunc(content, func(match string) string {
+				if rule.valid != nil && !rule.valid(match) {
+					return match
+				}
+				return redaction.token(rule.name, match)
+			})
+		}
+		for _, name := range names {
+			content = replaceWord(content, name, func() string { return redaction.token(RedactName, name) })
+		}
+		redacted[i] = content
+	}
+
+	return redacted, redaction
+}
+
+func (r *Redaction) token(name, value string) string {
+	key := name + "\x00" + value
+	if token, ok := r.tokens[key]; ok {
+		return token
+	}
+
+	r.Counts[name]++
+	token := fmt.Sprintf("[%s_%d]", strings.ToUpper(name), r.Counts[name])
+	r.tokens[key] = token
+	r.originals[token] = value
+	return token
+}
+
+// Total returns the number of values redacted
+func (r *Redaction) Total() int {
+	var total int
+	for _, count := range r.Counts {
+		total += count
+	}
+	return total
+}
+
+// Restore puts the original values back in place of their tokens
+func (r *Redaction) Restore(text string) string {
+	if len(r.originals) == 0 {
+		return text
+	}
+
+	// Longer tokens first so [EMAIL_1] doesn't match inside [EMAIL_10]
+	tokens := make([]string, 0, len(r.originals))
+	for token := range r.originals {
+		tokens = append(tokens, token)
+	}
+	sort.Slice(tokens, func(i, j int) bool { return len(tokens[i]) > len(tokens[j]) })
+
+	pairs := make([]string, 0, 2*len(tokens))
+	for _, token := range tokens {
+		pairs = append(pairs, token, r.originals[token])
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// NewRedactingAPI redacts the content sent to the API and restores the
+// redacted values in what it generates
+func NewRedactingAPI(logger log.Logger, api API, redactor *Redactor) API {
+	return &redactingAPI{API: api, logger: logger, redactor: redactor}
+}
+
+// GenerateContent redacts both the instruction and the content, as prompts
+// such as a draft reply's may quote the customer too
+func (a *redactingAPI) GenerateContent(ctx context.Context, instruction, content string) (string, error) {
+	redacted, redaction := a.redactor.RedactAll(instruction, content)
+	if total := redaction.Total(); total > 0 {
+		a.logger.Info("Redacted content sent to the LLM", tag.NewInt("total", total), tag.NewAnyTag("counts", redaction.Counts))
+	}
+
+	result, err := a.API.GenerateContent(ctx, redacted[0], redacted[1])
+	if err != nil {
+		return "", err
+	}
+
+	return redaction.Restore(result), nil
+}
+
+// Embed redacts the content before embedding it so sensitive values don't
+// reach the embedding API either
+func (a *redactingAPI) Embed(ctx context.Context, content string) ([]float32, error) {
+	redacted, redaction := a.redactor.Redact(content)
+	if total := redaction.Total(); total > 0 {
+		a.logger.Info("Redacted content sent for embedding", tag.NewInt("total", total), tag.NewAnyTag("counts", redaction.Counts))
+	}
+
+	return a.API.Embed(ctx, redacted)
+}
+
+// personNames returns the names of the people on a ticket found in the
+// contents, along with their parts of at least three letters starting with a
+// capital, longest first so full names are redacted before their parts
+func personNames(contents []string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	for _, content := range contents {
+		for _, match := range namePattern.FindAllStringSubmatch(content, -1) {
+			name := strings.TrimSpace(match[1] + match[2])
+			add(name)
+			for _, part := range strings.Fields(name) {
+				if first, _ := utf8.DecodeRuneInString(part); unicode.IsUpper(first) && utf8.RuneCountInString(part) >= 3 {
+					add(part)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	return names
+}
+
+// replaceWord replaces the whole-word occurrences of word in s, so the name
+// Ann isn't redacted from Annual
+func replaceWord(s, word string, replacement func() string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(s, word)
+		if i < 0 {
+			break
+		}
+		end := i + len(word)
+		before, _ := utf8.DecodeLastRuneInString(s[:i])
+		after, _ := utf8.DecodeRuneInString(s[end:])
+		b.WriteString(s[:i])
+		if wordRune(before) || wordRune(after) {
+			b.WriteString(word)
+		} else {
+			b.WriteString(replacement())
+		}
+		s = s[end:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+// wordRune reports whether r continues a word, or a token such as [NAME_1]
+func wordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// luhn reports whether the digits in s pass the Luhn checksum card numbers use
+func luhn(s string) bool {
+	var sum, n int
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
+
+// phoneNumber reports whether s has as many digits as a phone number, which
+// rules out longer grouped numbers such as invalid card numbers
+func phoneNumber(s string) bool {
+	var n int
+	for _, c := range s {
+		if c >= '0' && c <= '9' {
+			n++
+		}
+	}
+	return n >= 7 && n <= 15
+}
+
+func ipAddress(s string) bool {
+	addr, err := netip.ParseAddr(s)
+	return err == nil && !addr.IsLoopback() && !addr.IsUnspecified()
+}
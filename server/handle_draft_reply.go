@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/taonic/ticketfu/worker/ticket"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/server/common/log/tag"
+)
+
+// DraftReplyTimeout bounds how long a request waits for the draft to be generated
+const DraftReplyTimeout = 45 * time.Second
+
+type DraftReplyRequest struct {
+	Instruction string `json:"instruction"`
+}
+
+func (h *HTTPServer) handleDraftReply(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	ticketID := vars["ticketId"]
+
+	// The instruction is optional so an empty body is accepted
+	var req DraftReplyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+
+	h.logger.Debug("Handling draft reply", tag.Value(ticketID))
+
+	workflowID := fmt.Sprintf(ticket.TicketWorkflowIDTemplate, ticketID)
+
+	ctx, cancel := context.WithTimeout(r.Context(), DraftReplyTimeout)
+	defer cancel()
+
+	handle, err := h.temporalClient.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+		WorkflowID:   workflowID,
+		UpdateName:   ticket.DraftReplyUpdate,
+		Args:         []interface{}{ticket.DraftReplyInput{Instruction: req.Instruction}},
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+	})
+
+	var output ticket.DraftReplyOutput
+	if err == nil {
+		err = handle.Get(ctx, &output)
+	}
+
+	if err != nil {
+		h.logger.Error("Failed to draft reply", tag.Error(err))
+		var notFound *serviceerror.NotFound
+		if errors.As(err, &notFound) {
+			h.writeNotRunning(r.Context(), w, workflowID)
+			return
+		}
+		http.Error(w, "Failed to draft reply", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(output)
+}
+
+// writeNotRunning tells a ticket whose workflow has completed, as closed
+// tickets' workflows do, from one that doesn't exist
+func (h *HTTPServer) writeNotRunning(ctx context.Context, w http.ResponseWriter, workflowID string) {
+	desc, err := h.temporalClient.DescribeWorkflowExecution(ctx, workflowID, "")
+	if err == nil && desc.GetWorkflowExecutionInfo().GetStatus() == enumspb.WORKFLOW_EXECUTION_STATUS_COMPLETED {
+		http.Error(w, "Ticket is closed", http.StatusConflict)
+		return
+	}
+	http.Error(w, "Ticket not found", http.StatusNotFound)
+}
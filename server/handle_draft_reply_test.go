@@ -0,0 +1,145 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/taonic/ticketfu/config"
+	"github.com/taonic/ticketfu/worker/ticket"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/mocks"
+	"go.temporal.io/server/common/log"
+)
+
+func TestHandleDraftReply(t *testing.T) {
+	testCases := []struct {
+		name           string
+		body           string
+		setupMock      func(*mocks.Client)
+		expectedStatus int
+		expectedDraft  string
+		expectedError  string
+	}{
+		{
+			name: "Draft With Instruction",
+			body: `{"instruction": "apologize for the delay"}`,
+			setupMock: func(m *mocks.Client) {
+				handle := &mocks.WorkflowUpdateHandle{}
+				handle.On("Get", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+					output := args.Get(1).(*ticket.DraftReplyOutput)
+					output.Draft = "Sorry for the delay..."
+				}).Return(nil)
+
+				m.On("UpdateWorkflow", mock.Anything, mock.MatchedBy(func(options client.UpdateWorkflowOptions) bool {
+					return options.WorkflowID == "ticket-workflow-12345" &&
+						options.UpdateName == ticket.DraftReplyUpdate &&
+						options.WaitForStage == client.WorkflowUpdateStageCompleted &&
+						options.Args[0] == ticket.DraftReplyInput{Instruction: "apologize for the delay"}
+				})).Return(handle, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedDraft:  "Sorry for the delay...",
+		},
+		{
+			name: "Draft Without Body",
+			body: "",
+			setupMock: func(m *mocks.Client) {
+				handle := &mocks.WorkflowUpdateHandle{}
+				handle.On("Get", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+					output := args.Get(1).(*ticket.DraftReplyOutput)
+					output.Draft = "Hi there..."
+				}).Return(nil)
+
+				m.On("UpdateWorkflow", mock.Anything, mock.MatchedBy(func(options client.UpdateWorkflowOptions) bool {
+					return options.Args[0] == ticket.DraftReplyInput{}
+				})).Return(handle, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedDraft:  "Hi there...",
+		},
+		{
+			name:           "Invalid JSON",
+			body:           "not-a-json",
+			setupMock:      func(m *mocks.Client) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "Invalid JSON payload",
+		},
+		{
+			name: "Workflow Not Found",
+			body: "{}",
+			setupMock: func(m *mocks.Client) {
+				m.On("UpdateWorkflow", mock.Anything, mock.Anything).
+					Return(nil, serviceerror.NewNotFound("workflow not found"))
+				m.On("DescribeWorkflowExecution", mock.Anything, "ticket-workflow-12345", "").
+					Return(nil, serviceerror.NewNotFound("workflow not found"))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "Ticket not found",
+		},
+		{
+			name: "Closed Ticket",
+			body: "{}",
+			setupMock: func(m *mocks.Client) {
+				m.On("UpdateWorkflow", mock.Anything, mock.Anything).
+					Return(nil, serviceerror.NewNotFound("workflow execution already completed"))
+				m.On("DescribeWorkflowExecution", mock.Anything, "ticket-workflow-12345", "").
+					Return(describeOutput(enumspb.WORKFLOW_EXECUTION_STATUS_COMPLETED), nil)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedError:  "Ticket is closed",
+		},
+		{
+			name: "Update Failed",
+			body: "{}",
+			setupMock: func(m *mocks.Client) {
+				handle := &mocks.WorkflowUpdateHandle{}
+				handle.On("Get", mock.Anything, mock.Anything).Return(errors.New("activity error"))
+
+				m.On("UpdateWorkflow", mock.Anything, mock.Anything).Return(handle, nil)
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedError:  "Failed to draft reply",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockClient := &mocks.Client{}
+			tc.setupMock(mockClient)
+
+			server := NewHTTPServer(config.ServerConfig{
+				APIToken: "test-api-key",
+			}, mockClient, log.NewTestLogger())
+
+			req := httptest.NewRequest("POST", "/api/v1/ticket/12345/draft", bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(APIKeyHeader, "test-api-key")
+
+			w := httptest.NewRecorder()
+
+			router := mux.NewRouter()
+			router.HandleFunc("/api/v1/ticket/{ticketId}/draft", server.handleDraftReply)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedError != "" {
+				assert.Contains(t, w.Body.String(), tc.expectedError)
+			} else {
+				var resp ticket.DraftReplyOutput
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, tc.expectedDraft, resp.Draft)
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
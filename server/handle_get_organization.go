@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/gorilla/mux"
+	"github.com/taonic/ticketfu/genai"
 	"github.com/taonic/ticketfu/worker/org"
 	"go.temporal.io/server/common/log/tag"
 )
@@ -31,6 +32,23 @@ func (h *HTTPServer) handleGetOrganization(w http.ResponseWriter, r *http.Reques
 
 	workflowID := fmt.Sprintf(org.OrganizationWorkflowIDTemplate, organizationId)
 
+	// Serve the summary in the requested locale, falling back to the
+	// canonical summary if it can't be translated
+	if locale := r.URL.Query().Get("locale"); locale != "" {
+		if _, err := genai.ParseLocale(locale); err != nil {
+			http.Error(w, "Invalid locale", http.StatusBadRequest)
+			return
+		}
+
+		var resp org.QueryOrganizationOutput
+		err := h.translateSummary(r.Context(), workflowID, org.QueryOrganizationTranslation, org.TranslateOrganizationUpdate, org.TranslateInput{Locale: locale}, &resp)
+		if err == nil {
+			h.writeOrganizationOutput(w, resp)
+			return
+		}
+		h.logger.Warn("Failed to translate summary", tag.Value(locale), tag.Error(err))
+	}
+
 	// Query the workflow
 	val, err := h.temporalClient.QueryWorkflow(r.Context(), workflowID, "", org.QueryOrganizationSummary, "")
 	if err != nil {
@@ -47,6 +65,11 @@ func (h *HTTPServer) handleGetOrganization(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	h.writeOrganizationOutput(w, resp)
+}
+
+// writeOrganizationOutput responds with the organization summary parsed as JSON
+func (h *HTTPServer) writeOrganizationOutput(w http.ResponseWriter, resp org.QueryOrganizationOutput) {
 	// Clean and parse the summary JSON
 	summary := strings.TrimSpace(resp.Summary)
 	summary = strings.TrimPrefix(summary, "```json")
@@ -55,7 +78,7 @@ func (h *HTTPServer) handleGetOrganization(w http.ResponseWriter, r *http.Reques
 
 	// Parse the summary into a generic JSON object
 	var summaryJSON map[string]interface{}
-	err = json.Unmarshal([]byte(summary), &summaryJSON)
+	err := json.Unmarshal([]byte(summary), &summaryJSON)
 	if err != nil {
 		h.logger.Debug("Failed to parse summary JSON", tag.Error(err))
 		http.Error(w, "Failed to parse summary JSON", http.StatusInternalServerError)
@@ -65,7 +88,12 @@ func (h *HTTPServer) handleGetOrganization(w http.ResponseWriter, r *http.Reques
 	// Send the response
 	w.Header().Set("Content-Type", "application/json")
 	response := map[string]interface{}{
-		"summary": summaryJSON,
+		"summary":     summaryJSON,
+		"escalations": resp.Escalations,
+		"metrics":     resp.Metrics,
+	}
+	if resp.Locale != "" {
+		response["locale"] = resp.Locale
 	}
 	json.NewEncoder(w).Encode(response)
 }
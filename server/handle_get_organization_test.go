@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/taonic/ticketfu/config"
 	"github.com/taonic/ticketfu/worker/org"
+	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/mocks"
 	"go.temporal.io/server/common/log"
 )
@@ -182,3 +183,44 @@ func TestHandleGetOrganization(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleGetOrganizationWithLocale(t *testing.T) {
+	mockClient := &mocks.Client{}
+
+	handle := &mocks.WorkflowUpdateHandle{}
+	handle.On("Get", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		resp := args.Get(1).(*org.QueryOrganizationOutput)
+		resp.Summary = `{"overview": "Abrechnungsprobleme"}`
+		resp.Locale = "de"
+	}).Return(nil)
+
+	// Not translated yet, so the workflow translates it with an update
+	mockClient.On("QueryWorkflow", mock.Anything, "organization-workflow-123", "", org.QueryOrganizationTranslation, org.TranslateInput{Locale: "de"}).
+		Return(nil, errors.New("summary has not been translated to the locale yet"))
+	mockClient.On("UpdateWorkflow", mock.Anything, mock.MatchedBy(func(options client.UpdateWorkflowOptions) bool {
+		return options.WorkflowID == "organization-workflow-123" &&
+			options.UpdateName == org.TranslateOrganizationUpdate &&
+			options.Args[0] == org.TranslateInput{Locale: "de"}
+	})).Return(handle, nil)
+
+	server := NewHTTPServer(config.ServerConfig{
+		APIToken: "test-api-key",
+	}, mockClient, log.NewTestLogger())
+
+	req := httptest.NewRequest("GET", "/api/v1/organization/123/summary?locale=de", nil)
+	req.Header.Set(APIKeyHeader, "test-api-key")
+
+	w := httptest.NewRecorder()
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/organization/{orgId}/summary", server.handleGetOrganization)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "de", resp["locale"])
+	assert.Equal(t, "Abrechnungsprobleme", resp["summary"].(map[string]interface{})["overview"])
+
+	mockClient.AssertExpectations(t)
+}
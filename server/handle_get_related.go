@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/taonic/ticketfu/worker"
+	"github.com/taonic/ticketfu/worker/related"
+	"github.com/taonic/ticketfu/worker/ticket"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/server/common/log/tag"
+)
+
+// RelatedTimeout bounds how long a request waits for a summary missing from
+// the index to be embedded
+const RelatedTimeout = 30 * time.Second
+
+func (h *HTTPServer) handleGetRelated(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	ticketID := vars["ticketId"]
+
+	var input ticket.RelatedTicketsInput
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > ticket.MaxRelatedTickets {
+			http.Error(w, fmt.Sprintf("Invalid limit, must be between 1 and %d", ticket.MaxRelatedTickets), http.StatusBadRequest)
+			return
+		}
+		input.Limit = limit
+	}
+
+	h.logger.Debug("Handling related tickets", tag.Value(ticketID))
+
+	workflowID := fmt.Sprintf(ticket.TicketWorkflowIDTemplate, ticketID)
+
+	ctx, cancel := context.WithTimeout(r.Context(), RelatedTimeout)
+	defer cancel()
+
+	// The ticket's workflow is only queried, the lookup runs in a workflow of
+	// its own so reads don't add to the ticket's history
+	var output ticket.RelatedTicketsOutput
+	lookup, err := h.relatedTicketsLookup(ctx, workflowID, input)
+	if err == nil {
+		var run client.WorkflowRun
+		run, err = h.temporalClient.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
+			TaskQueue:                worker.TaskQueue,
+			WorkflowExecutionTimeout: RelatedTimeout,
+		}, ticket.RelatedTicketsWorkflow, lookup)
+		if err == nil {
+			err = run.Get(ctx, &output)
+		}
+	}
+
+	if err != nil {
+		h.logger.Error("Failed to find related tickets", tag.Error(err))
+		var notFound *serviceerror.NotFound
+		if errors.As(err, &notFound) {
+			http.Error(w, "Ticket not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to find related tickets", http.StatusInternalServerError)
+		return
+	}
+
+	if output.Tickets == nil {
+		output.Tickets = []related.Match{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(output)
+}
+
+// relatedTicketsLookup queries the ticket's workflow for what its related
+// tickets are looked up by
+func (h *HTTPServer) relatedTicketsLookup(ctx context.Context, workflowID string, input ticket.RelatedTicketsInput) (ticket.FindRelatedTicketsInput, error) {
+	var lookup ticket.FindRelatedTicketsInput
+
+	future, err := h.temporalClient.QueryWorkflow(ctx, workflowID, "", ticket.QueryRelatedTicketsLookup, input)
+	if err != nil {
+		return lookup, err
+	}
+
+	err = future.Get(&lookup)
+	return lookup, err
+}
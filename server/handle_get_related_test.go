@@ -0,0 +1,144 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/taonic/ticketfu/config"
+	"github.com/taonic/ticketfu/worker"
+	"github.com/taonic/ticketfu/worker/related"
+	"github.com/taonic/ticketfu/worker/ticket"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/mocks"
+	"go.temporal.io/server/common/log"
+)
+
+func TestHandleGetRelated(t *testing.T) {
+	matches := []related.Match{
+		{TicketID: 678, OrganizationID: 202, Score: 0.93, Summary: `{"intent":"Log in"}`},
+		{TicketID: 910, Score: 0.71, Summary: `{"intent":"Reset password"}`},
+	}
+
+	testCases := []struct {
+		name            string
+		query           string
+		setupMock       func(*mocks.Client)
+		expectedStatus  int
+		expectedTickets []related.Match
+		expectedError   string
+	}{
+		{
+			name:  "Related Tickets",
+			query: "?limit=2",
+			setupMock: func(m *mocks.Client) {
+				lookup := ticket.FindRelatedTicketsInput{TicketID: 12345, Summary: ticket.TicketSummary{Intent: "Log in"}, Limit: 2}
+				mockLookup(m, ticket.RelatedTicketsInput{Limit: 2}, lookup)
+
+				run := &mocks.WorkflowRun{}
+				run.On("Get", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+					output := args.Get(1).(*ticket.RelatedTicketsOutput)
+					output.Tickets = matches
+				}).Return(nil)
+
+				m.On("ExecuteWorkflow", mock.Anything, mock.MatchedBy(func(options client.StartWorkflowOptions) bool {
+					return options.TaskQueue == worker.TaskQueue
+				}), mock.Anything, lookup).Return(run, nil)
+			},
+			expectedStatus:  http.StatusOK,
+			expectedTickets: matches,
+		},
+		{
+			name: "No Related Tickets",
+			setupMock: func(m *mocks.Client) {
+				lookup := ticket.FindRelatedTicketsInput{TicketID: 12345, Limit: ticket.DefaultRelatedTickets}
+				mockLookup(m, ticket.RelatedTicketsInput{}, lookup)
+
+				run := &mocks.WorkflowRun{}
+				run.On("Get", mock.Anything, mock.Anything).Return(nil)
+
+				m.On("ExecuteWorkflow", mock.Anything, mock.Anything, mock.Anything, lookup).Return(run, nil)
+			},
+			expectedStatus:  http.StatusOK,
+			expectedTickets: []related.Match{},
+		},
+		{
+			name:           "Invalid Limit",
+			query:          "?limit=0",
+			setupMock:      func(m *mocks.Client) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "Invalid limit",
+		},
+		{
+			name: "Workflow Not Found",
+			setupMock: func(m *mocks.Client) {
+				m.On("QueryWorkflow", mock.Anything, "ticket-workflow-12345", "", ticket.QueryRelatedTicketsLookup, mock.Anything).
+					Return(nil, serviceerror.NewNotFound("workflow not found"))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "Ticket not found",
+		},
+		{
+			name: "Lookup Failed",
+			setupMock: func(m *mocks.Client) {
+				mockLookup(m, ticket.RelatedTicketsInput{}, ticket.FindRelatedTicketsInput{TicketID: 12345})
+
+				run := &mocks.WorkflowRun{}
+				run.On("Get", mock.Anything, mock.Anything).Return(errors.New("activity error"))
+
+				m.On("ExecuteWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(run, nil)
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedError:  "Failed to find related tickets",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockClient := &mocks.Client{}
+			tc.setupMock(mockClient)
+
+			server := NewHTTPServer(config.ServerConfig{
+				APIToken: "test-api-key",
+			}, mockClient, log.NewTestLogger())
+
+			req := httptest.NewRequest("GET", "/api/v1/ticket/12345/related"+tc.query, nil)
+			req.Header.Set(APIKeyHeader, "test-api-key")
+
+			w := httptest.NewRecorder()
+
+			router := mux.NewRouter()
+			router.HandleFunc("/api/v1/ticket/{ticketId}/related", server.handleGetRelated)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedError != "" {
+				assert.Contains(t, w.Body.String(), tc.expectedError)
+			} else {
+				var response ticket.RelatedTicketsOutput
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tc.expectedTickets, response.Tickets)
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+// mockLookup answers the ticket workflow's related tickets lookup query
+func mockLookup(m *mocks.Client, input ticket.RelatedTicketsInput, lookup ticket.FindRelatedTicketsInput) {
+	future := &mocks.Value{}
+	future.On("Get", mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(0).(*ticket.FindRelatedTicketsInput) = lookup
+	}).Return(nil)
+
+	m.On("QueryWorkflow", mock.Anything, "ticket-workflow-12345", "", ticket.QueryRelatedTicketsLookup, input).
+		Return(future, nil)
+}
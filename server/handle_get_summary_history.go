@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/taonic/ticketfu/worker/history"
+	"github.com/taonic/ticketfu/worker/org"
+	"github.com/taonic/ticketfu/worker/ticket"
+	"go.temporal.io/server/common/log/tag"
+)
+
+type (
+	// summarySource locates the workflow holding an entity's summary history
+	summarySource struct {
+		idVar              string
+		workflowIDTemplate string
+		queryType          string
+	}
+
+	GetSummaryHistoryResponse struct {
+		Versions []history.Version `json:"versions"`
+	}
+)
+
+var (
+	ticketSummarySource = summarySource{
+		idVar:              "ticketId",
+		workflowIDTemplate: ticket.TicketWorkflowIDTemplate,
+		queryType:          ticket.QueryTicketSummaryHistory,
+	}
+
+	organizationSummarySource = summarySource{
+		idVar:              "orgId",
+		workflowIDTemplate: org.OrganizationWorkflowIDTemplate,
+		queryType:          org.QueryOrganizationSummaryHistory,
+	}
+)
+
+func (h *HTTPServer) handleGetSummaryHistory(source summarySource) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		versions, err := h.querySummaryHistory(r.Context(), source, mux.Vars(r)[source.idVar])
+		if err != nil {
+			h.logger.Error("Failed to query summary history", tag.Error(err))
+			http.Error(w, "Failed to query workflow", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(GetSummaryHistoryResponse{Versions: versions})
+	}
+}
+
+// handleGetSummaryDiff diffs the versions given by the from and to query
+// parameters, defaulting to the two latest versions.
+func (h *HTTPServer) handleGetSummaryDiff(source summarySource) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		versions, err := h.querySummaryHistory(r.Context(), source, mux.Vars(r)[source.idVar])
+		if err != nil {
+			h.logger.Error("Failed to query summary history", tag.Error(err))
+			http.Error(w, "Failed to query workflow", http.StatusNotFound)
+			return
+		}
+
+		if len(versions) < 2 {
+			http.Error(w, "At least two summary versions are needed for a diff", http.StatusNotFound)
+			return
+		}
+
+		fromNum, err := versionParam(r, "from", versions[len(versions)-2].Version)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		toNum, err := versionParam(r, "to", versions[len(versions)-1].Version)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		from, fromOK := history.Find(versions, fromNum)
+		to, toOK := history.Find(versions, toNum)
+		if !fromOK || !toOK {
+			http.Error(w, "Summary version not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(history.Compare(from, to))
+	}
+}
+
+func (h *HTTPServer) querySummaryHistory(ctx context.Context, source summarySource, id string) ([]history.Version, error) {
+	h.logger.Debug("Handling GET summary history", tag.Value(id))
+
+	workflowID := fmt.Sprintf(source.workflowIDTemplate, id)
+
+	val, err := h.temporalClient.QueryWorkflow(ctx, workflowID, "", source.queryType)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []history.Version
+	if err := val.Get(&versions); err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+func versionParam(r *http.Request, name string, fallback int) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return fallback, nil
+	}
+
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s version: %s", name, raw)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,190 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/taonic/ticketfu/config"
+	"github.com/taonic/ticketfu/worker/history"
+	"github.com/taonic/ticketfu/worker/org"
+	"github.com/taonic/ticketfu/worker/ticket"
+	"go.temporal.io/sdk/mocks"
+	"go.temporal.io/server/common/log"
+)
+
+func mockSummaryHistory(m *mocks.Client, workflowID, queryType string, versions []history.Version) {
+	mockFuture := &mocks.Value{}
+	mockFuture.On("Get", mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(0).(*[]history.Version) = versions
+	}).Return(nil)
+
+	m.On("QueryWorkflow", mock.Anything, workflowID, "", queryType).Return(mockFuture, nil)
+}
+
+func TestHandleGetSummaryHistory(t *testing.T) {
+	versions := []history.Version{
+		{Version: 1, Summary: `{"summary":"Login fails"}`, Trigger: "new_comments", Model: "gpt-4o", PromptHash: "abc123"},
+		{Version: 2, Summary: `{"summary":"Login fixed"}`, Trigger: "status_changed", Model: "gpt-4o", PromptHash: "abc123"},
+	}
+
+	testCases := []struct {
+		name           string
+		url            string
+		setupMock      func(*mocks.Client)
+		expectedStatus int
+		expectedResp   *GetSummaryHistoryResponse
+		expectedError  string
+	}{
+		{
+			name: "Ticket History",
+			url:  "/api/v1/ticket/12345/summary/history",
+			setupMock: func(m *mocks.Client) {
+				mockSummaryHistory(m, "ticket-workflow-12345", ticket.QueryTicketSummaryHistory, versions)
+			},
+			expectedStatus: http.StatusOK,
+			expectedResp:   &GetSummaryHistoryResponse{Versions: versions},
+		},
+		{
+			name: "Organization History",
+			url:  "/api/v1/organization/303/summary/history",
+			setupMock: func(m *mocks.Client) {
+				mockSummaryHistory(m, "organization-workflow-303", org.QueryOrganizationSummaryHistory, versions)
+			},
+			expectedStatus: http.StatusOK,
+			expectedResp:   &GetSummaryHistoryResponse{Versions: versions},
+		},
+		{
+			name: "Workflow Not Found",
+			url:  "/api/v1/ticket/99999/summary/history",
+			setupMock: func(m *mocks.Client) {
+				m.On("QueryWorkflow", mock.Anything, "ticket-workflow-99999", "", ticket.QueryTicketSummaryHistory).
+					Return(nil, errors.New("workflow not found"))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "Failed to query workflow",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockClient := &mocks.Client{}
+			tc.setupMock(mockClient)
+
+			server := NewHTTPServer(config.ServerConfig{
+				APIToken: "test-api-key",
+			}, mockClient, log.NewTestLogger())
+
+			req := httptest.NewRequest("GET", tc.url, nil)
+			req.Header.Set(APIKeyHeader, "test-api-key")
+			w := httptest.NewRecorder()
+
+			server.registerRoutes().ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedError != "" {
+				assert.Contains(t, w.Body.String(), tc.expectedError)
+			} else {
+				var resp GetSummaryHistoryResponse
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, *tc.expectedResp, resp)
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleGetSummaryDiff(t *testing.T) {
+	versions := []history.Version{
+		{Version: 3, Summary: "a\nb"},
+		{Version: 4, Summary: "a\nc"},
+		{Version: 5, Summary: "a\nd"},
+	}
+
+	testCases := []struct {
+		name           string
+		query          string
+		versions       []history.Version
+		expectedStatus int
+		expectedDiff   *history.Diff
+		expectedError  string
+	}{
+		{
+			name:           "Latest Two By Default",
+			versions:       versions,
+			expectedStatus: http.StatusOK,
+			expectedDiff: &history.Diff{From: 4, To: 5, Lines: []history.DiffLine{
+				{Op: history.OpUnchanged, Text: "a"},
+				{Op: history.OpRemoved, Text: "c"},
+				{Op: history.OpAdded, Text: "d"},
+			}},
+		},
+		{
+			name:           "Explicit Versions",
+			query:          "?from=3&to=4",
+			versions:       versions,
+			expectedStatus: http.StatusOK,
+			expectedDiff: &history.Diff{From: 3, To: 4, Lines: []history.DiffLine{
+				{Op: history.OpUnchanged, Text: "a"},
+				{Op: history.OpRemoved, Text: "b"},
+				{Op: history.OpAdded, Text: "c"},
+			}},
+		},
+		{
+			name:           "Invalid Version",
+			query:          "?from=latest",
+			versions:       versions,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid from version: latest",
+		},
+		{
+			name:           "Unknown Version",
+			query:          "?from=1",
+			versions:       versions,
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "Summary version not found",
+		},
+		{
+			name:           "Single Version",
+			versions:       versions[:1],
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "At least two summary versions are needed",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockClient := &mocks.Client{}
+			mockSummaryHistory(mockClient, "ticket-workflow-12345", ticket.QueryTicketSummaryHistory, tc.versions)
+
+			server := NewHTTPServer(config.ServerConfig{
+				APIToken: "test-api-key",
+			}, mockClient, log.NewTestLogger())
+
+			req := httptest.NewRequest("GET", "/api/v1/ticket/12345/summary/diff"+tc.query, nil)
+			req.Header.Set(APIKeyHeader, "test-api-key")
+			w := httptest.NewRecorder()
+
+			router := mux.NewRouter()
+			router.HandleFunc("/api/v1/ticket/{ticketId}/summary/diff", server.handleGetSummaryDiff(ticketSummarySource))
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedError != "" {
+				assert.Contains(t, w.Body.String(), tc.expectedError)
+			} else {
+				var diff history.Diff
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &diff))
+				assert.Equal(t, *tc.expectedDiff, diff)
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
@@ -1,13 +1,15 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/taonic/ticketfu/genai"
 	"github.com/taonic/ticketfu/worker/ticket"
+	enumspb "go.temporal.io/api/enums/v1"
 	"go.temporal.io/server/common/log/tag"
 )
 
@@ -27,21 +29,71 @@ func (h *HTTPServer) handleGetTicket(w http.ResponseWriter, r *http.Request) {
 
 	workflowID := fmt.Sprintf(ticket.TicketWorkflowIDTemplate, ticketID)
 
-	future, err := h.temporalClient.QueryWorkflow(r.Context(), workflowID, "", ticket.QueryTicketSummary, "")
-	if err != nil {
-		http.Error(w, "Failed to query workflow", http.StatusNotFound)
-		log.Printf("Failed to query workflow: %v", err)
-		return
+	// Serve the summary in the requested locale, falling back to the
+	// canonical summary if it can't be translated
+	if locale := r.URL.Query().Get("locale"); locale != "" {
+		if _, err := genai.ParseLocale(locale); err != nil {
+			http.Error(w, "Invalid locale", http.StatusBadRequest)
+			return
+		}
+
+		var output ticket.QueryTicketOutput
+		err := h.translateSummary(r.Context(), workflowID, ticket.QueryTicketTranslation, ticket.TranslateTicketUpdate, ticket.TranslateInput{Locale: locale}, &output)
+		if err == nil {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(output)
+			return
+		}
+		h.logger.Warn("Failed to translate summary", tag.Value(locale), tag.Error(err))
 	}
 
-	output := ticket.QueryTicketOutput{}
-	err = future.Get(&output)
+	output, err := h.getTicketOutput(r.Context(), workflowID)
 	if err != nil {
 		h.logger.Error("Failed to query workflow", tag.Error(err))
 		http.Error(w, "Failed to query workflow", http.StatusNotFound)
+		return
 	}
 
 	// Send the workflow ID in the response as confirmation
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(output)
 }
+
+// getTicketOutput queries the ticket workflow. Workflows of closed tickets
+// have completed, in which case the output is read from the workflow result.
+func (h *HTTPServer) getTicketOutput(ctx context.Context, workflowID string) (*ticket.QueryTicketOutput, error) {
+	output := ticket.QueryTicketOutput{}
+
+	future, err := h.temporalClient.QueryWorkflow(ctx, workflowID, "", ticket.QueryTicketSummary, "")
+	if err == nil {
+		if err = future.Get(&output); err == nil {
+			return &output, nil
+		}
+	}
+
+	completed, resultErr := h.completedTicketOutput(ctx, workflowID)
+	if resultErr != nil {
+		return nil, resultErr
+	}
+	if completed == nil {
+		return nil, err
+	}
+
+	return completed, nil
+}
+
+// completedTicketOutput returns the result of the ticket's workflow once it
+// has completed, or nil while it's running or doesn't exist
+func (h *HTTPServer) completedTicketOutput(ctx context.Context, workflowID string) (*ticket.QueryTicketOutput, error) {
+	desc, err := h.temporalClient.DescribeWorkflowExecution(ctx, workflowID, "")
+	if err != nil || desc.GetWorkflowExecutionInfo().GetStatus() != enumspb.WORKFLOW_EXECUTION_STATUS_COMPLETED {
+		return nil, nil
+	}
+
+	output := ticket.QueryTicketOutput{}
+	if err := h.temporalClient.GetWorkflow(ctx, workflowID, "").Get(ctx, &output); err != nil {
+		return nil, fmt.Errorf("failed to get workflow result: %w", err)
+	}
+
+	return &output, nil
+}
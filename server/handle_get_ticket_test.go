@@ -5,6 +5,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"github.com/gorilla/mux"
@@ -12,6 +13,10 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/taonic/ticketfu/config"
 	"github.com/taonic/ticketfu/worker/ticket"
+	enumspb "go.temporal.io/api/enums/v1"
+	workflowpb "go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/mocks"
 	"go.temporal.io/server/common/log"
 )
@@ -32,7 +37,7 @@ func TestHandleGetTicket(t *testing.T) {
 				mockFuture := &mocks.Value{}
 				mockFuture.On("Get", mock.Anything).Run(func(args mock.Arguments) {
 					output := args.Get(0).(*ticket.QueryTicketOutput)
-					output.Summary = "Test ticket summary for ID 12345"
+					output.Summary = &ticket.TicketSummary{Summary: "Test ticket summary for ID 12345"}
 				}).Return(nil)
 
 				m.On("QueryWorkflow", mock.Anything, "ticket-workflow-12345", "", ticket.QueryTicketSummary, "").
@@ -40,7 +45,7 @@ func TestHandleGetTicket(t *testing.T) {
 			},
 			expectedStatus: http.StatusOK,
 			expectedResp: &ticket.QueryTicketOutput{
-				Summary: "Test ticket summary for ID 12345",
+				Summary: &ticket.TicketSummary{Summary: "Test ticket summary for ID 12345"},
 			},
 		},
 		{
@@ -49,6 +54,8 @@ func TestHandleGetTicket(t *testing.T) {
 			setupMock: func(m *mocks.Client) {
 				m.On("QueryWorkflow", mock.Anything, "ticket-workflow-99999", "", ticket.QueryTicketSummary, "").
 					Return(nil, errors.New("workflow not found"))
+				m.On("DescribeWorkflowExecution", mock.Anything, "ticket-workflow-99999", "").
+					Return(nil, errors.New("workflow not found"))
 			},
 			expectedStatus: http.StatusNotFound,
 			expectedError:  "Failed to query workflow",
@@ -62,10 +69,35 @@ func TestHandleGetTicket(t *testing.T) {
 
 				m.On("QueryWorkflow", mock.Anything, "ticket-workflow-54321", "", ticket.QueryTicketSummary, "").
 					Return(mockFuture, nil)
+				m.On("DescribeWorkflowExecution", mock.Anything, "ticket-workflow-54321", "").
+					Return(describeOutput(enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING), nil)
 			},
 			expectedStatus: http.StatusNotFound,
 			expectedError:  "Failed to query workflow",
 		},
+		{
+			name:     "Closed Ticket",
+			ticketID: "67890",
+			setupMock: func(m *mocks.Client) {
+				m.On("QueryWorkflow", mock.Anything, "ticket-workflow-67890", "", ticket.QueryTicketSummary, "").
+					Return(nil, errors.New("no worker to replay the closed workflow"))
+				m.On("DescribeWorkflowExecution", mock.Anything, "ticket-workflow-67890", "").
+					Return(describeOutput(enumspb.WORKFLOW_EXECUTION_STATUS_COMPLETED), nil)
+
+				mockRun := &mocks.WorkflowRun{}
+				mockRun.On("Get", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+					output := args.Get(1).(*ticket.QueryTicketOutput)
+					output.Summary = &ticket.TicketSummary{Summary: "Final summary"}
+					output.Status = "closed"
+				}).Return(nil)
+				m.On("GetWorkflow", mock.Anything, "ticket-workflow-67890", "").Return(mockRun)
+			},
+			expectedStatus: http.StatusOK,
+			expectedResp: &ticket.QueryTicketOutput{
+				Summary: &ticket.TicketSummary{Summary: "Final summary"},
+				Status:  "closed",
+			},
+		},
 		{
 			name:     "Empty Ticket ID",
 			ticketID: "",
@@ -115,6 +147,7 @@ func TestHandleGetTicket(t *testing.T) {
 				err := json.Unmarshal(w.Body.Bytes(), &resp)
 				assert.NoError(t, err)
 				assert.Equal(t, tc.expectedResp.Summary, resp.Summary)
+				assert.Equal(t, tc.expectedResp.Status, resp.Status)
 			}
 
 			mockClient.AssertExpectations(t)
@@ -129,6 +162,8 @@ func TestHandleGetTicketWithMissingWorkflow(t *testing.T) {
 	// Set up mock to simulate a specific Temporal error
 	mockClient.On("QueryWorkflow", mock.Anything, "ticket-workflow-45678", "", ticket.QueryTicketSummary, "").
 		Return(nil, errors.New("workflow execution not found"))
+	mockClient.On("DescribeWorkflowExecution", mock.Anything, "ticket-workflow-45678", "").
+		Return(nil, errors.New("workflow execution not found"))
 
 	// Create server
 	server := NewHTTPServer(config.ServerConfig{
@@ -156,3 +191,127 @@ func TestHandleGetTicketWithMissingWorkflow(t *testing.T) {
 
 	mockClient.AssertExpectations(t)
 }
+
+func TestHandleGetTicketWithLocale(t *testing.T) {
+	testCases := []struct {
+		name           string
+		locale         string
+		setupMock      func(*mocks.Client)
+		expectedStatus int
+		expectedResp   *ticket.QueryTicketOutput
+		expectedError  string
+	}{
+		{
+			name:   "Cached Translation",
+			locale: "fr",
+			setupMock: func(m *mocks.Client) {
+				mockFuture := &mocks.Value{}
+				mockFuture.On("Get", mock.Anything).Run(func(args mock.Arguments) {
+					output := args.Get(0).(*ticket.QueryTicketOutput)
+					output.Summary = &ticket.TicketSummary{Summary: "La connexion échoue"}
+					output.Locale = "fr"
+				}).Return(nil)
+				m.On("QueryWorkflow", mock.Anything, "ticket-workflow-12345", "", ticket.QueryTicketTranslation, ticket.TranslateInput{Locale: "fr"}).
+					Return(mockFuture, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedResp: &ticket.QueryTicketOutput{
+				Summary: &ticket.TicketSummary{Summary: "La connexion échoue"},
+				Locale:  "fr",
+			},
+		},
+		{
+			name:   "Translated",
+			locale: "fr",
+			setupMock: func(m *mocks.Client) {
+				m.On("QueryWorkflow", mock.Anything, "ticket-workflow-12345", "", ticket.QueryTicketTranslation, ticket.TranslateInput{Locale: "fr"}).
+					Return(nil, errors.New("summary has not been translated to the locale yet"))
+
+				handle := &mocks.WorkflowUpdateHandle{}
+				handle.On("Get", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+					output := args.Get(1).(*ticket.QueryTicketOutput)
+					output.Summary = &ticket.TicketSummary{Summary: "La connexion échoue"}
+					output.Locale = "fr"
+				}).Return(nil)
+
+				m.On("UpdateWorkflow", mock.Anything, mock.MatchedBy(func(options client.UpdateWorkflowOptions) bool {
+					return options.WorkflowID == "ticket-workflow-12345" &&
+						options.UpdateName == ticket.TranslateTicketUpdate &&
+						options.Args[0] == ticket.TranslateInput{Locale: "fr"}
+				})).Return(handle, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedResp: &ticket.QueryTicketOutput{
+				Summary: &ticket.TicketSummary{Summary: "La connexion échoue"},
+				Locale:  "fr",
+			},
+		},
+		{
+			name:   "Falls Back To Canonical Summary",
+			locale: "fr",
+			setupMock: func(m *mocks.Client) {
+				m.On("QueryWorkflow", mock.Anything, "ticket-workflow-12345", "", ticket.QueryTicketTranslation, ticket.TranslateInput{Locale: "fr"}).
+					Return(nil, errors.New("ticket has not been summarized yet"))
+				m.On("UpdateWorkflow", mock.Anything, mock.Anything).
+					Return(nil, errors.New("ticket has not been summarized yet"))
+
+				mockFuture := &mocks.Value{}
+				mockFuture.On("Get", mock.Anything).Run(func(args mock.Arguments) {
+					output := args.Get(0).(*ticket.QueryTicketOutput)
+					output.Summary = &ticket.TicketSummary{Summary: "Login fails"}
+				}).Return(nil)
+				m.On("QueryWorkflow", mock.Anything, "ticket-workflow-12345", "", ticket.QueryTicketSummary, "").
+					Return(mockFuture, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedResp: &ticket.QueryTicketOutput{
+				Summary: &ticket.TicketSummary{Summary: "Login fails"},
+			},
+		},
+		{
+			name:           "Invalid Locale",
+			locale:         "not-a-locale!",
+			setupMock:      func(m *mocks.Client) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "Invalid locale",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockClient := &mocks.Client{}
+			tc.setupMock(mockClient)
+
+			server := NewHTTPServer(config.ServerConfig{
+				APIToken: "test-api-key",
+			}, mockClient, log.NewTestLogger())
+
+			req := httptest.NewRequest("GET", "/api/v1/ticket/12345/summary?locale="+url.QueryEscape(tc.locale), nil)
+			req.Header.Set(APIKeyHeader, "test-api-key")
+
+			w := httptest.NewRecorder()
+			router := mux.NewRouter()
+			router.HandleFunc("/api/v1/ticket/{ticketId}/summary", server.handleGetTicket)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+			if tc.expectedError != "" {
+				assert.Contains(t, w.Body.String(), tc.expectedError)
+			} else {
+				var resp ticket.QueryTicketOutput
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, tc.expectedResp.Summary, resp.Summary)
+				assert.Equal(t, tc.expectedResp.Locale, resp.Locale)
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func describeOutput(status enumspb.WorkflowExecutionStatus) *workflowservice.DescribeWorkflowExecutionResponse {
+	return &workflowservice.DescribeWorkflowExecutionResponse{
+		WorkflowExecutionInfo: &workflowpb.WorkflowExecutionInfo{Status: status},
+	}
+}
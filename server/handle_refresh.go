@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/taonic/ticketfu/worker"
+	"github.com/taonic/ticketfu/worker/org"
+	"github.com/taonic/ticketfu/worker/ticket"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/server/common/log/tag"
+)
+
+// RefreshTimeout bounds how long a refresh request waits for the fresh summary
+// before responding that the refresh is still in progress
+const RefreshTimeout = 30 * time.Second
+
+// handleRefreshTicket runs the ticket pipeline right away, starting the
+// workflow if needed, and responds with the fresh summary. Closed tickets
+// respond with the final summary their workflow completed with, rather than
+// starting it over.
+func (h *HTTPServer) handleRefreshTicket(w http.ResponseWriter, r *http.Request) {
+	ticketID := mux.Vars(r)["ticketId"]
+	h.logger.Debug("Handling ticket refresh", tag.Value(ticketID))
+
+	workflowID := fmt.Sprintf(ticket.TicketWorkflowIDTemplate, ticketID)
+
+	ctx, cancel := context.WithTimeout(r.Context(), RefreshTimeout)
+	defer cancel()
+
+	completed, err := h.completedTicketOutput(ctx, workflowID)
+	if err != nil {
+		h.writeRefreshError(ctx, w, workflowID, err)
+		return
+	}
+	if completed != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(completed)
+		return
+	}
+
+	startOperation := h.temporalClient.NewWithStartWorkflowOperation(client.StartWorkflowOptions{
+		ID:                       workflowID,
+		TaskQueue:                worker.TaskQueue,
+		WorkflowIDConflictPolicy: enumspb.WORKFLOW_ID_CONFLICT_POLICY_USE_EXISTING,
+	}, ticket.TicketWorkflow, nil)
+
+	handle, err := h.temporalClient.UpdateWithStartWorkflow(ctx, client.UpdateWithStartWorkflowOptions{
+		StartWorkflowOperation: startOperation,
+		UpdateOptions: client.UpdateWorkflowOptions{
+			WorkflowID:   workflowID,
+			UpdateName:   ticket.RefreshTicketUpdate,
+			Args:         []interface{}{ticket.UpsertTicketInput{TicketID: ticketID}},
+			WaitForStage: client.WorkflowUpdateStageAccepted,
+		},
+	})
+
+	var output ticket.QueryTicketOutput
+	if err == nil {
+		err = handle.Get(ctx, &output)
+	}
+
+	if err != nil {
+		h.writeRefreshError(ctx, w, workflowID, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(output)
+}
+
+// handleRefreshOrganization regenerates the organization summary right away
+// and responds with it
+func (h *HTTPServer) handleRefreshOrganization(w http.ResponseWriter, r *http.Request) {
+	orgID, err := strconv.ParseInt(mux.Vars(r)["orgId"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid organization ID", http.StatusBadRequest)
+		return
+	}
+	h.logger.Debug("Handling organization refresh", tag.Value(orgID))
+
+	workflowID := fmt.Sprintf(org.OrganizationWorkflowIDTemplate, strconv.FormatInt(orgID, 10))
+
+	ctx, cancel := context.WithTimeout(r.Context(), RefreshTimeout)
+	defer cancel()
+
+	handle, err := h.temporalClient.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+		WorkflowID:   workflowID,
+		UpdateName:   org.RefreshOrganizationUpdate,
+		Args:         []interface{}{org.RefreshOrganizationInput{OrganizationID: orgID}},
+		WaitForStage: client.WorkflowUpdateStageAccepted,
+	})
+
+	var output org.QueryOrganizationOutput
+	if err == nil {
+		err = handle.Get(ctx, &output)
+	}
+
+	if err != nil {
+		h.writeRefreshError(ctx, w, workflowID, err)
+		return
+	}
+
+	h.writeOrganizationOutput(w, output)
+}
+
+// writeRefreshError responds with 202 when the refresh is still running once
+// the timeout elapses, so the caller can fall back to polling the summary
+func (h *HTTPServer) writeRefreshError(ctx context.Context, w http.ResponseWriter, workflowID string, err error) {
+	if ctx.Err() != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(response{
+			Message:    "Refresh in progress",
+			WorkflowID: workflowID,
+		})
+		return
+	}
+
+	h.logger.Error("Failed to refresh", tag.Error(err))
+
+	var notFound *serviceerror.NotFound
+	if errors.As(err, &notFound) {
+		http.Error(w, "Workflow not found", http.StatusNotFound)
+		return
+	}
+
+	http.Error(w, "Failed to refresh", http.StatusInternalServerError)
+}
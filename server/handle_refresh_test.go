@@ -0,0 +1,210 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/taonic/ticketfu/config"
+	"github.com/taonic/ticketfu/worker/org"
+	"github.com/taonic/ticketfu/worker/ticket"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/mocks"
+	"go.temporal.io/server/common/log"
+)
+
+func TestHandleRefreshTicket(t *testing.T) {
+	testCases := []struct {
+		name            string
+		cancelled       bool
+		setupMock       func(*mocks.Client)
+		expectedStatus  int
+		expectedSummary string
+		expectedError   string
+	}{
+		{
+			name: "Refreshed",
+			setupMock: func(m *mocks.Client) {
+				handle := &mocks.WorkflowUpdateHandle{}
+				handle.On("Get", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+					output := args.Get(1).(*ticket.QueryTicketOutput)
+					output.RawSummary = "fresh summary"
+				}).Return(nil)
+
+				m.On("DescribeWorkflowExecution", mock.Anything, "ticket-workflow-12345", "").
+					Return(describeOutput(enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING), nil)
+				m.On("NewWithStartWorkflowOperation", mock.MatchedBy(func(options client.StartWorkflowOptions) bool {
+					return options.ID == "ticket-workflow-12345"
+				}), mock.Anything, mock.Anything).Return(nil)
+				m.On("UpdateWithStartWorkflow", mock.Anything, mock.MatchedBy(func(options client.UpdateWithStartWorkflowOptions) bool {
+					return options.UpdateOptions.UpdateName == ticket.RefreshTicketUpdate &&
+						options.UpdateOptions.Args[0] == ticket.UpsertTicketInput{TicketID: "12345"}
+				})).Return(handle, nil)
+			},
+			expectedStatus:  http.StatusOK,
+			expectedSummary: "fresh summary",
+		},
+		{
+			name:      "Still In Progress",
+			cancelled: true,
+			setupMock: func(m *mocks.Client) {
+				handle := &mocks.WorkflowUpdateHandle{}
+				handle.On("Get", mock.Anything, mock.Anything).Return(context.DeadlineExceeded)
+
+				m.On("DescribeWorkflowExecution", mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, serviceerror.NewNotFound("workflow not found"))
+				m.On("NewWithStartWorkflowOperation", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+				m.On("UpdateWithStartWorkflow", mock.Anything, mock.Anything).Return(handle, nil)
+			},
+			expectedStatus: http.StatusAccepted,
+			expectedError:  "Refresh in progress",
+		},
+		{
+			name: "Closed Ticket Served From Result",
+			setupMock: func(m *mocks.Client) {
+				m.On("DescribeWorkflowExecution", mock.Anything, "ticket-workflow-12345", "").
+					Return(describeOutput(enumspb.WORKFLOW_EXECUTION_STATUS_COMPLETED), nil)
+
+				// Not started over
+				run := &mocks.WorkflowRun{}
+				run.On("Get", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+					output := args.Get(1).(*ticket.QueryTicketOutput)
+					output.RawSummary = "final summary"
+				}).Return(nil)
+				m.On("GetWorkflow", mock.Anything, "ticket-workflow-12345", "").Return(run)
+			},
+			expectedStatus:  http.StatusOK,
+			expectedSummary: "final summary",
+		},
+		{
+			name: "Update Rejected",
+			setupMock: func(m *mocks.Client) {
+				m.On("DescribeWorkflowExecution", mock.Anything, mock.Anything, mock.Anything).
+					Return(describeOutput(enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING), nil)
+				m.On("NewWithStartWorkflowOperation", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+				m.On("UpdateWithStartWorkflow", mock.Anything, mock.Anything).
+					Return(nil, errors.New("ticket is closed"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedError:  "Failed to refresh",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockClient := &mocks.Client{}
+			tc.setupMock(mockClient)
+
+			server := NewHTTPServer(config.ServerConfig{
+				APIToken: "test-api-key",
+			}, mockClient, log.NewTestLogger())
+
+			req := httptest.NewRequest("POST", "/api/v1/ticket/12345/refresh", nil)
+			if tc.cancelled {
+				ctx, cancel := context.WithCancel(req.Context())
+				cancel()
+				req = req.WithContext(ctx)
+			}
+
+			w := httptest.NewRecorder()
+
+			router := mux.NewRouter()
+			router.HandleFunc("/api/v1/ticket/{ticketId}/refresh", server.handleRefreshTicket)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedError != "" {
+				assert.Contains(t, w.Body.String(), tc.expectedError)
+			} else {
+				var resp ticket.QueryTicketOutput
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, tc.expectedSummary, resp.RawSummary)
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleRefreshOrganization(t *testing.T) {
+	testCases := []struct {
+		name           string
+		orgID          string
+		setupMock      func(*mocks.Client)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:  "Refreshed",
+			orgID: "67890",
+			setupMock: func(m *mocks.Client) {
+				handle := &mocks.WorkflowUpdateHandle{}
+				handle.On("Get", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+					output := args.Get(1).(*org.QueryOrganizationOutput)
+					output.Summary = `{"overview": "fresh"}`
+				}).Return(nil)
+
+				m.On("UpdateWorkflow", mock.Anything, mock.MatchedBy(func(options client.UpdateWorkflowOptions) bool {
+					return options.WorkflowID == "organization-workflow-67890" &&
+						options.UpdateName == org.RefreshOrganizationUpdate &&
+						options.Args[0] == org.RefreshOrganizationInput{OrganizationID: 67890}
+				})).Return(handle, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid Organization ID",
+			orgID:          "abc",
+			setupMock:      func(m *mocks.Client) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "Invalid organization ID",
+		},
+		{
+			name:  "Workflow Not Found",
+			orgID: "67890",
+			setupMock: func(m *mocks.Client) {
+				m.On("UpdateWorkflow", mock.Anything, mock.Anything).
+					Return(nil, serviceerror.NewNotFound("workflow not found"))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "Workflow not found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockClient := &mocks.Client{}
+			tc.setupMock(mockClient)
+
+			server := NewHTTPServer(config.ServerConfig{
+				APIToken: "test-api-key",
+			}, mockClient, log.NewTestLogger())
+
+			req := httptest.NewRequest("POST", "/api/v1/organization/"+tc.orgID+"/refresh", nil)
+			w := httptest.NewRecorder()
+
+			router := mux.NewRouter()
+			router.HandleFunc("/api/v1/organization/{orgId}/refresh", server.handleRefreshOrganization)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedError != "" {
+				assert.Contains(t, w.Body.String(), tc.expectedError)
+			} else {
+				var resp map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, map[string]interface{}{"overview": "fresh"}, resp["summary"])
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
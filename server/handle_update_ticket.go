@@ -17,6 +17,11 @@ import (
 type (
 	UpdateTicketRequest struct {
 		TicketURL string `json:"ticket_url"`
+
+		// When the update happened, so the workflow can skip updates it has
+		// caught up with, e.g. its own. Triggers created before it was sent
+		// leave it empty.
+		UpdatedAt string `json:"updated_at"`
 	}
 
 	response struct {
@@ -43,6 +48,9 @@ func (h *HTTPServer) handleUpdateTicket(w http.ResponseWriter, r *http.Request)
 	workflowID := fmt.Sprintf(ticket.TicketWorkflowIDTemplate, ticketID)
 
 	input := ticket.UpsertTicketInput{TicketID: ticketID}
+	if updatedAt, err := time.Parse(time.RFC3339, req.UpdatedAt); err == nil {
+		input.UpdatedAt = &updatedAt
+	}
 
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
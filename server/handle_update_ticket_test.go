@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -45,7 +46,7 @@ func TestHandleUpdateTicket(t *testing.T) {
 					mock.MatchedBy(func(options client.StartWorkflowOptions) bool {
 						return options.ID == workflowID && options.TaskQueue == worker.TaskQueue
 					}),
-					mock.AnythingOfType("func(internal.Context, ticket.Ticket) error"),
+					mock.AnythingOfType("func(internal.Context, ticket.Ticket) (*ticket.QueryTicketOutput, error)"),
 					nil,
 				).Return(mockRun, nil)
 			},
@@ -55,6 +56,31 @@ func TestHandleUpdateTicket(t *testing.T) {
 				WorkflowID: "",
 			},
 		},
+		{
+			name: "With Updated At",
+			requestBody: UpdateTicketRequest{
+				TicketURL: "company.zendesk.com/tickets/12345",
+				UpdatedAt: "2025-01-02T03:04:05Z",
+			},
+			setupMock: func(m *mocks.Client) {
+				updatedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+				m.On("SignalWithStartWorkflow",
+					mock.Anything,
+					"ticket-workflow-12345",
+					ticket.UpsertTicketSignal,
+					mock.MatchedBy(func(input ticket.UpsertTicketInput) bool {
+						return input.TicketID == "12345" && input.UpdatedAt != nil && input.UpdatedAt.Equal(updatedAt)
+					}),
+					mock.Anything,
+					mock.Anything,
+					nil,
+				).Return(mockRun, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedResp: &response{
+				Message: "Ticket update workflow started or signaled",
+			},
+		},
 		{
 			name: "Invalid URL",
 			requestBody: UpdateTicketRequest{
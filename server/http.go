@@ -31,7 +31,7 @@ func NewHTTPServer(config config.ServerConfig, temporalClient client.Client, log
 		Addr:         fmt.Sprintf("%s:%d", config.Host, config.Port),
 		Handler:      mux,
 		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
+		WriteTimeout: max(DraftReplyTimeout, RefreshTimeout, TranslateTimeout, RelatedTimeout) + 15*time.Second, // drafting, refreshing, translating and finding related tickets wait on the LLM
 		IdleTimeout:  60 * time.Second,
 	}
 
@@ -90,7 +90,15 @@ func (h *HTTPServer) registerRoutes() *mux.Router {
 	verifyAPIKey := APIKeyMiddleware(h.config.APIToken)
 	r.HandleFunc("/api/v1/ticket/{ticketId}/summary", verifyAPIKey(h.handleGetTicket)).Methods("GET")
 	r.HandleFunc("/api/v1/ticket", verifyAPIKey(h.handleUpdateTicket)).Methods("POST")
+	r.HandleFunc("/api/v1/ticket/{ticketId}/draft", verifyAPIKey(h.handleDraftReply)).Methods("POST")
+	r.HandleFunc("/api/v1/ticket/{ticketId}/refresh", verifyAPIKey(h.handleRefreshTicket)).Methods("POST")
+	r.HandleFunc("/api/v1/ticket/{ticketId}/related", verifyAPIKey(h.handleGetRelated)).Methods("GET")
+	r.HandleFunc("/api/v1/organization/{orgId}/refresh", verifyAPIKey(h.handleRefreshOrganization)).Methods("POST")
 	r.HandleFunc("/api/v1/organization/{orgId}/summary", verifyAPIKey(h.handleGetOrganization)).Methods("GET")
+	r.HandleFunc("/api/v1/ticket/{ticketId}/summary/history", verifyAPIKey(h.handleGetSummaryHistory(ticketSummarySource))).Methods("GET")
+	r.HandleFunc("/api/v1/ticket/{ticketId}/summary/diff", verifyAPIKey(h.handleGetSummaryDiff(ticketSummarySource))).Methods("GET")
+	r.HandleFunc("/api/v1/organization/{orgId}/summary/history", verifyAPIKey(h.handleGetSummaryHistory(organizationSummarySource))).Methods("GET")
+	r.HandleFunc("/api/v1/organization/{orgId}/summary/diff", verifyAPIKey(h.handleGetSummaryDiff(organizationSummarySource))).Methods("GET")
 
 	return r
 }
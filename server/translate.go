@@ -0,0 +1,38 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"go.temporal.io/sdk/client"
+)
+
+// TranslateTimeout bounds how long a summary request waits for a translation
+// before falling back to the canonical summary
+const TranslateTimeout = 30 * time.Second
+
+// translateSummary asks the workflow for its summary in the locale. Cached
+// translations are queried, so only a cache miss makes the workflow
+// translate, with an update.
+func (h *HTTPServer) translateSummary(ctx context.Context, workflowID, queryName, updateName string, input interface{}, output interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, TranslateTimeout)
+	defer cancel()
+
+	if future, err := h.temporalClient.QueryWorkflow(ctx, workflowID, "", queryName, input); err == nil {
+		if err := future.Get(output); err == nil {
+			return nil
+		}
+	}
+
+	handle, err := h.temporalClient.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+		WorkflowID:   workflowID,
+		UpdateName:   updateName,
+		Args:         []interface{}{input},
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+	})
+	if err != nil {
+		return err
+	}
+
+	return handle.Get(ctx, output)
+}
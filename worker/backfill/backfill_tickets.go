@@ -0,0 +1,144 @@
+package backfill
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/nukosuke/go-zendesk/zendesk"
+	"github.com/taonic/ticketfu/worker/reconcile"
+	"github.com/taonic/ticketfu/worker/ticket"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	"golang.org/x/time/rate"
+)
+
+type (
+	BackfillTicketsInput struct {
+		StartTime int64 // Unix timestamp to export tickets updated since
+		Filter    Filter
+		Rate      float64
+	}
+
+	BackfillTicketsOutput struct {
+		EndTime     int64 // Start time of the next page
+		EndOfStream bool
+		Started     int
+		Skipped     int
+	}
+
+	// Checkpoint is the tickets of the page started so far, recorded as a
+	// heartbeat. The export isn't ordered by update time, so tickets are
+	// recorded by ID along with the update time they were started at.
+	Checkpoint struct {
+		StartTime int64           // Start time of the page the tickets are from
+		Tickets   map[int64]int64 // Unix time each ticket started was updated at, keyed by ID
+	}
+)
+
+// BackfillTickets starts the workflows of a page of tickets matching the
+// filter, no faster than the rate. A retried page is exported again, and
+// skips the tickets the last attempt started unless updated since. Closed
+// tickets whose workflow has completed are skipped.
+//
+// Ticket workflows are started with SignalWithStart rather than as children
+// of the backfill, since they're long-lived and shared with webhooks and
+// reconciliation, which may already be running them.
+func (a *Activity) BackfillTickets(ctx context.Context, input BackfillTicketsInput) (*BackfillTicketsOutput, error) {
+	page, err := a.zClient.GetIncrementalTickets(ctx, input.StartTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export tickets: %w", err)
+	}
+
+	var checkpoint Checkpoint
+	if activity.HasHeartbeatDetails(ctx) {
+		if err := activity.GetHeartbeatDetails(ctx, &checkpoint); err != nil {
+			return nil, fmt.Errorf("failed to get heartbeat details: %w", err)
+		}
+	}
+	// Only tickets started from the same page are resumed
+	if checkpoint.StartTime != input.StartTime {
+		checkpoint = Checkpoint{StartTime: input.StartTime}
+	}
+
+	limiter := rate.NewLimiter(rate.Inf, 1)
+	if input.Rate > 0 {
+		limiter = rate.NewLimiter(rate.Limit(input.Rate), 1)
+	}
+	taskQueue := activity.GetInfo(ctx).TaskQueue
+
+	output := &BackfillTicketsOutput{EndTime: page.EndTime, EndOfStream: page.EndOfStream}
+	for _, t := range page.Tickets {
+		if !input.Filter.Matches(t) {
+			output.Skipped++
+			continue
+		}
+		if checkpoint.started(t) {
+			output.Started++
+			continue
+		}
+
+		ticketID := strconv.FormatInt(t.ID, 10)
+		workflowID := fmt.Sprintf(ticket.TicketWorkflowIDTemplate, ticketID)
+
+		if t.Status == ticket.StatusClosed {
+			completed, err := reconcile.WorkflowCompleted(ctx, a.tClient, workflowID)
+			if err != nil {
+				return nil, err
+			}
+			if completed {
+				output.Skipped++
+				continue
+			}
+		}
+
+		if err := limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		workflowOptions := client.StartWorkflowOptions{
+			ID:        workflowID,
+			TaskQueue: taskQueue,
+		}
+		// Tickets whose workflow is already up to date aren't summarized again
+		upsert := ticket.UpsertTicketInput{TicketID: ticketID, UpdatedAt: t.UpdatedAt}
+		if _, err := a.tClient.SignalWithStartWorkflow(ctx,
+			workflowID,
+			ticket.UpsertTicketSignal,
+			upsert,
+			workflowOptions,
+			ticket.TicketWorkflow,
+			nil,
+		); err != nil {
+			return nil, fmt.Errorf("failed to signal ticket workflow %s: %w", workflowID, err)
+		}
+		output.Started++
+
+		checkpoint.add(t)
+		activity.RecordHeartbeat(ctx, checkpoint)
+	}
+
+	return output, nil
+}
+
+// started reports whether the ticket was started from the page as of its
+// latest update
+func (c Checkpoint) started(t zendesk.Ticket) bool {
+	startedAt, ok := c.Tickets[t.ID]
+	return ok && updatedAt(t) <= startedAt
+}
+
+// add records the ticket as started
+func (c *Checkpoint) add(t zendesk.Ticket) {
+	if c.Tickets == nil {
+		c.Tickets = make(map[int64]int64)
+	}
+	c.Tickets[t.ID] = updatedAt(t)
+}
+
+func updatedAt(t zendesk.Ticket) int64 {
+	if t.UpdatedAt == nil {
+		return 0
+	}
+	return t.UpdatedAt.Unix()
+}
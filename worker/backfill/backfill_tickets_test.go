@@ -0,0 +1,186 @@
+package backfill
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nukosuke/go-zendesk/zendesk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/taonic/ticketfu/worker/ticket"
+	zd "github.com/taonic/ticketfu/zendesk"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/mocks"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestBackfillTickets(t *testing.T) {
+	updatedAt := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+	page := zd.IncrementalTicketsPage{
+		Tickets: []zendesk.Ticket{
+			{ID: 1, OrganizationID: 42, Status: "solved", UpdatedAt: &updatedAt},
+			{ID: 2, OrganizationID: 7, Status: "solved", UpdatedAt: &updatedAt},
+			{ID: 3, OrganizationID: 42, Status: "deleted", UpdatedAt: &updatedAt},
+			{ID: 4, OrganizationID: 42, Status: "open", UpdatedAt: &updatedAt},
+			{ID: 5, OrganizationID: 42, Status: "closed", UpdatedAt: &updatedAt},
+		},
+		EndTime: 1737000000,
+	}
+
+	// Ticket 1 was updated again after the last attempt started it
+	updatedAgain := updatedAt.Add(time.Minute)
+	movedPage := page
+	movedPage.Tickets = append(slices.Clone(page.Tickets[1:]), zendesk.Ticket{ID: 1, OrganizationID: 42, Status: "solved", UpdatedAt: &updatedAgain})
+
+	testCases := []struct {
+		name           string
+		heartbeat      any
+		setupMock      func(*zd.MockZendeskClient, *mocks.Client)
+		expectedOutput *BackfillTicketsOutput
+		expectedError  string
+	}{
+		{
+			name: "Starts Matching Tickets",
+			setupMock: func(z *zd.MockZendeskClient, c *mocks.Client) {
+				z.On("GetIncrementalTickets", mock.Anything, int64(1736000000)).Return(page, nil).Once()
+				c.On("DescribeWorkflowExecution", mock.Anything, "ticket-workflow-5", "").
+					Return(nil, serviceerror.NewNotFound("workflow not found")).Once()
+				for _, id := range []string{"1", "4", "5"} {
+					c.On("SignalWithStartWorkflow", mock.Anything, "ticket-workflow-"+id, ticket.UpsertTicketSignal,
+						ticket.UpsertTicketInput{TicketID: id, UpdatedAt: &updatedAt},
+						mock.MatchedBy(func(options client.StartWorkflowOptions) bool { return options.ID == "ticket-workflow-"+id }),
+						mock.Anything, mock.Anything).Return(&mocks.WorkflowRun{}, nil).Once()
+				}
+			},
+			expectedOutput: &BackfillTicketsOutput{EndTime: 1737000000, Started: 3, Skipped: 2},
+		},
+		{
+			name: "Skips Completed Closed Tickets",
+			setupMock: func(z *zd.MockZendeskClient, c *mocks.Client) {
+				z.On("GetIncrementalTickets", mock.Anything, int64(1736000000)).Return(page, nil).Once()
+				c.On("DescribeWorkflowExecution", mock.Anything, "ticket-workflow-5", "").
+					Return(describeResponse(enums.WORKFLOW_EXECUTION_STATUS_COMPLETED), nil).Once()
+				for _, id := range []string{"1", "4"} {
+					c.On("SignalWithStartWorkflow", mock.Anything, "ticket-workflow-"+id, mock.Anything, mock.Anything,
+						mock.Anything, mock.Anything, mock.Anything).Return(&mocks.WorkflowRun{}, nil).Once()
+				}
+			},
+			expectedOutput: &BackfillTicketsOutput{EndTime: 1737000000, Started: 2, Skipped: 3},
+		},
+		{
+			name:      "Retry Resumes After Started Tickets",
+			heartbeat: Checkpoint{StartTime: 1736000000, Tickets: map[int64]int64{1: updatedAt.Unix(), 4: updatedAt.Unix()}},
+			setupMock: func(z *zd.MockZendeskClient, c *mocks.Client) {
+				z.On("GetIncrementalTickets", mock.Anything, int64(1736000000)).Return(page, nil).Once()
+				c.On("DescribeWorkflowExecution", mock.Anything, "ticket-workflow-5", "").
+					Return(nil, serviceerror.NewNotFound("workflow not found")).Once()
+				c.On("SignalWithStartWorkflow", mock.Anything, "ticket-workflow-5", mock.Anything, mock.Anything,
+					mock.Anything, mock.Anything, mock.Anything).Return(&mocks.WorkflowRun{}, nil).Once()
+			},
+			expectedOutput: &BackfillTicketsOutput{EndTime: 1737000000, Started: 3, Skipped: 2},
+		},
+		{
+			name:      "Retry Starts Tickets Updated Since",
+			heartbeat: Checkpoint{StartTime: 1736000000, Tickets: map[int64]int64{1: updatedAt.Unix(), 4: updatedAt.Unix(), 5: updatedAt.Unix()}},
+			setupMock: func(z *zd.MockZendeskClient, c *mocks.Client) {
+				z.On("GetIncrementalTickets", mock.Anything, int64(1736000000)).Return(movedPage, nil).Once()
+				c.On("SignalWithStartWorkflow", mock.Anything, "ticket-workflow-1", ticket.UpsertTicketSignal,
+					ticket.UpsertTicketInput{TicketID: "1", UpdatedAt: &updatedAgain},
+					mock.Anything, mock.Anything, mock.Anything).Return(&mocks.WorkflowRun{}, nil).Once()
+			},
+			expectedOutput: &BackfillTicketsOutput{EndTime: 1737000000, Started: 3, Skipped: 2},
+		},
+		{
+			name:      "Retry Starts Tickets Updated Earlier Than Those Started",
+			heartbeat: Checkpoint{StartTime: 1736000000, Tickets: map[int64]int64{1: updatedAgain.Unix()}},
+			setupMock: func(z *zd.MockZendeskClient, c *mocks.Client) {
+				z.On("GetIncrementalTickets", mock.Anything, int64(1736000000)).Return(movedPage, nil).Once()
+				c.On("DescribeWorkflowExecution", mock.Anything, "ticket-workflow-5", "").
+					Return(nil, serviceerror.NewNotFound("workflow not found")).Once()
+				for _, id := range []string{"4", "5"} {
+					c.On("SignalWithStartWorkflow", mock.Anything, "ticket-workflow-"+id, mock.Anything, mock.Anything,
+						mock.Anything, mock.Anything, mock.Anything).Return(&mocks.WorkflowRun{}, nil).Once()
+				}
+			},
+			expectedOutput: &BackfillTicketsOutput{EndTime: 1737000000, Started: 3, Skipped: 2},
+		},
+		{
+			name:      "Checkpoint Of Another Page Is Ignored",
+			heartbeat: Checkpoint{StartTime: 1735000000, Tickets: map[int64]int64{1: updatedAt.Unix(), 4: updatedAt.Unix()}},
+			setupMock: func(z *zd.MockZendeskClient, c *mocks.Client) {
+				z.On("GetIncrementalTickets", mock.Anything, int64(1736000000)).Return(page, nil).Once()
+				c.On("DescribeWorkflowExecution", mock.Anything, "ticket-workflow-5", "").
+					Return(nil, serviceerror.NewNotFound("workflow not found")).Once()
+				for _, id := range []string{"1", "4", "5"} {
+					c.On("SignalWithStartWorkflow", mock.Anything, "ticket-workflow-"+id, mock.Anything, mock.Anything,
+						mock.Anything, mock.Anything, mock.Anything).Return(&mocks.WorkflowRun{}, nil).Once()
+				}
+			},
+			expectedOutput: &BackfillTicketsOutput{EndTime: 1737000000, Started: 3, Skipped: 2},
+		},
+		{
+			name: "Export Error",
+			setupMock: func(z *zd.MockZendeskClient, c *mocks.Client) {
+				z.On("GetIncrementalTickets", mock.Anything, mock.Anything).
+					Return(zd.IncrementalTicketsPage{}, errors.New("429 Too Many Requests"))
+			},
+			expectedError: "failed to export tickets",
+		},
+		{
+			name: "Signal Error",
+			setupMock: func(z *zd.MockZendeskClient, c *mocks.Client) {
+				z.On("GetIncrementalTickets", mock.Anything, mock.Anything).Return(page, nil)
+				c.On("SignalWithStartWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, errors.New("unavailable"))
+			},
+			expectedError: "failed to signal ticket workflow ticket-workflow-1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testSuite := testsuite.WorkflowTestSuite{}
+			testEnv := testSuite.NewTestActivityEnvironment()
+			if tc.heartbeat != nil {
+				testEnv.SetHeartbeatDetails(tc.heartbeat)
+			}
+
+			zClient := &zd.MockZendeskClient{}
+			tClient := &mocks.Client{}
+			tc.setupMock(zClient, tClient)
+
+			activity := NewActivity(tClient, zClient)
+			testEnv.RegisterActivity(activity.BackfillTickets)
+
+			future, err := testEnv.ExecuteActivity(activity.BackfillTickets, BackfillTicketsInput{
+				StartTime: 1736000000,
+				Filter:    Filter{OrganizationIDs: []int64{42}},
+			})
+
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+			} else {
+				require.NoError(t, err)
+				var output BackfillTicketsOutput
+				require.NoError(t, future.Get(&output))
+				assert.Equal(t, tc.expectedOutput, &output)
+			}
+
+			zClient.AssertExpectations(t)
+			tClient.AssertExpectations(t)
+		})
+	}
+}
+
+func describeResponse(status enums.WorkflowExecutionStatus) *workflowservice.DescribeWorkflowExecutionResponse {
+	return &workflowservice.DescribeWorkflowExecutionResponse{
+		WorkflowExecutionInfo: &workflow.WorkflowExecutionInfo{Status: status},
+	}
+}
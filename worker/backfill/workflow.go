@@ -0,0 +1,178 @@
+// Package backfill seeds the workflows of historical tickets, so organization
+// insights are populated on day one instead of once tickets happen to be
+// updated again.
+package backfill
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nukosuke/go-zendesk/zendesk"
+	"github.com/taonic/ticketfu/worker/reconcile"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+const (
+	BackfillWorkflowID = "backfill-workflow"
+	ProgressQuery      = "backfill-progress"
+	ParamsQuery        = "backfill-params"
+
+	// DefaultRate is how many ticket workflows are started a second unless
+	// configured. Every started ticket is summarized by the LLM.
+	DefaultRate = 1.0
+
+	// maxPageSize is the most tickets a page of the incremental export holds
+	maxPageSize = 1000
+)
+
+var (
+	pagesBeforeContinueAsNew = 20
+)
+
+type (
+	// Filter narrows down the tickets backfilled. Empty criteria match any
+	// ticket.
+	Filter struct {
+		OrganizationIDs []int64
+		Statuses        []string
+	}
+
+	BackfillInput struct {
+		Cursor   int64 // Unix timestamp of the export to resume from
+		Since    int64 // Unix timestamp the backfill started from, the first run's cursor when unset
+		Filter   Filter
+		Rate     float64 // Ticket workflows started a second. Non-positive is unlimited
+		Progress Progress
+	}
+
+	// Progress is carried across continue-as-new and is the workflow's result
+	Progress struct {
+		Cursor  int64 // Pass as the input's cursor to resume the backfill
+		Started int
+		Skipped int
+		Done    bool
+	}
+)
+
+// Matches reports whether the ticket should be backfilled. Deleted tickets
+// never are.
+func (f Filter) Matches(t zendesk.Ticket) bool {
+	if t.Status == "deleted" {
+		return false
+	}
+	if len(f.OrganizationIDs) > 0 && !slices.Contains(f.OrganizationIDs, t.OrganizationID) {
+		return false
+	}
+	if len(f.Statuses) > 0 && !slices.ContainsFunc(f.Statuses, func(s string) bool { return strings.EqualFold(s, t.Status) }) {
+		return false
+	}
+	return true
+}
+
+// Equal reports whether both filters match the same tickets
+func (f Filter) Equal(other Filter) bool {
+	statuses := func(f Filter) []string {
+		statuses := make([]string, len(f.Statuses))
+		for i, status := range f.Statuses {
+			statuses[i] = strings.ToLower(status)
+		}
+		slices.Sort(statuses)
+		return slices.Compact(statuses)
+	}
+	organizationIDs := func(f Filter) []int64 {
+		return slices.Compact(slices.Sorted(slices.Values(f.OrganizationIDs)))
+	}
+	return slices.Equal(organizationIDs(f), organizationIDs(other)) && slices.Equal(statuses(f), statuses(other))
+}
+
+// BackfillWorkflow pages through the tickets updated since the cursor,
+// starting the workflow of each one matching the filter
+func BackfillWorkflow(ctx workflow.Context, input BackfillInput) (*Progress, error) {
+	ctx = workflow.WithActivityOptions(ctx, activityOptions(input.Rate))
+	logger := workflow.GetLogger(ctx)
+
+	progress := input.Progress
+	progress.Cursor = max(progress.Cursor, input.Cursor)
+	if input.Since == 0 {
+		input.Since = input.Cursor
+	}
+
+	if err := workflow.SetQueryHandler(ctx, ProgressQuery, func() (Progress, error) {
+		return progress, nil
+	}); err != nil {
+		return nil, err
+	}
+
+	// Report what the backfill was started with, so it isn't mistaken for
+	// another one
+	if err := workflow.SetQueryHandler(ctx, ParamsQuery, func() (BackfillInput, error) {
+		return BackfillInput{Since: input.Since, Filter: input.Filter, Rate: input.Rate}, nil
+	}); err != nil {
+		return nil, err
+	}
+
+	var a *Activity
+	for page := 0; ; page++ {
+		// Zendesk rejects exports starting too recently. Webhooks and
+		// reconciliation cover those tickets.
+		if progress.Cursor > workflow.Now(ctx).Add(-reconcile.MinExportAge).Unix() {
+			break
+		}
+
+		if page == pagesBeforeContinueAsNew {
+			return nil, workflow.NewContinueAsNewError(ctx, BackfillWorkflow, BackfillInput{
+				Cursor:   progress.Cursor,
+				Since:    input.Since,
+				Filter:   input.Filter,
+				Rate:     input.Rate,
+				Progress: progress,
+			})
+		}
+
+		var output BackfillTicketsOutput
+		if err := workflow.ExecuteActivity(ctx, a.BackfillTickets, BackfillTicketsInput{
+			StartTime: progress.Cursor,
+			Filter:    input.Filter,
+			Rate:      input.Rate,
+		}).Get(ctx, &output); err != nil {
+			return nil, err
+		}
+
+		progress.Started += output.Started
+		progress.Skipped += output.Skipped
+		progress.Cursor = max(progress.Cursor, output.EndTime)
+
+		if output.EndOfStream {
+			break
+		}
+	}
+
+	progress.Done = true
+	logger.Info("Backfilled tickets", "started", progress.Started, "skipped", progress.Skipped)
+	return &progress, nil
+}
+
+// activityOptions gives a page long enough to start all its tickets at the
+// rate, heartbeating between tickets
+func activityOptions(rate float64) workflow.ActivityOptions {
+	timeout := 5 * time.Minute
+	heartbeat := time.Minute
+	if rate > 0 {
+		interval := time.Duration(float64(time.Second) / rate)
+		timeout += maxPageSize * interval
+		heartbeat += interval
+	}
+
+	return workflow.ActivityOptions{
+		StartToCloseTimeout: timeout,
+		HeartbeatTimeout:    heartbeat,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    10 * time.Second, // incremental exports are rate limited to 10 requests a minute
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    5 * time.Minute,
+			MaximumAttempts:    10,
+		},
+	}
+}
@@ -0,0 +1,181 @@
+package backfill
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nukosuke/go-zendesk/zendesk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+func TestBackfillWorkflowSuite(t *testing.T) {
+	suite.Run(t, new(BackfillWorkflowTestSuite))
+}
+
+type BackfillWorkflowTestSuite struct {
+	suite.Suite
+	testsuite.WorkflowTestSuite
+	env *testsuite.TestWorkflowEnvironment
+	now time.Time
+}
+
+func (s *BackfillWorkflowTestSuite) SetupTest() {
+	s.env = s.NewTestWorkflowEnvironment()
+	s.now = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	s.env.SetStartTime(s.now)
+}
+
+func (s *BackfillWorkflowTestSuite) TearDownTest() {
+	s.env.AssertExpectations(s.T())
+}
+
+func (s *BackfillWorkflowTestSuite) TestPagesUntilEndOfStream() {
+	since := s.now.Add(-30 * 24 * time.Hour).Unix()
+	filter := Filter{OrganizationIDs: []int64{42}}
+
+	s.env.OnActivity((*Activity)(nil).BackfillTickets, mock.Anything, BackfillTicketsInput{StartTime: since, Filter: filter, Rate: 2}).
+		Return(&BackfillTicketsOutput{EndTime: since + 3600, Started: 10, Skipped: 990}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).BackfillTickets, mock.Anything, BackfillTicketsInput{StartTime: since + 3600, Filter: filter, Rate: 2}).
+		Return(&BackfillTicketsOutput{EndTime: since + 7200, EndOfStream: true, Started: 5, Skipped: 20}, nil).Once()
+
+	s.env.ExecuteWorkflow(BackfillWorkflow, BackfillInput{Cursor: since, Filter: filter, Rate: 2})
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+
+	var progress Progress
+	s.NoError(s.env.GetWorkflowResult(&progress))
+	s.Equal(Progress{Cursor: since + 7200, Started: 15, Skipped: 1010, Done: true}, progress)
+}
+
+func (s *BackfillWorkflowTestSuite) TestStopsBeforeRecentTickets() {
+	since := s.now.Add(-time.Hour).Unix()
+
+	// Zendesk keeps returning the last page until the export catches up
+	s.env.OnActivity((*Activity)(nil).BackfillTickets, mock.Anything, mock.Anything).
+		Return(&BackfillTicketsOutput{EndTime: s.now.Unix(), Started: 3}, nil).Once()
+
+	s.env.ExecuteWorkflow(BackfillWorkflow, BackfillInput{Cursor: since})
+
+	var progress Progress
+	s.NoError(s.env.GetWorkflowResult(&progress))
+	s.Equal(Progress{Cursor: s.now.Unix(), Started: 3, Done: true}, progress)
+}
+
+func (s *BackfillWorkflowTestSuite) TestProgressQuery() {
+	since := s.now.Add(-time.Hour).Unix()
+
+	s.env.OnActivity((*Activity)(nil).BackfillTickets, mock.Anything, mock.Anything).
+		Return(&BackfillTicketsOutput{EndTime: since + 600, Started: 4, Skipped: 1}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).BackfillTickets, mock.Anything, mock.Anything).
+		After(time.Minute).Return(&BackfillTicketsOutput{EndTime: since + 1200, EndOfStream: true}, nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		value, err := s.env.QueryWorkflow(ProgressQuery)
+		s.NoError(err)
+
+		var progress Progress
+		s.NoError(value.Get(&progress))
+		s.Equal(Progress{Cursor: since + 600, Started: 4, Skipped: 1}, progress)
+	}, 30*time.Second)
+
+	s.env.ExecuteWorkflow(BackfillWorkflow, BackfillInput{Cursor: since})
+	s.NoError(s.env.GetWorkflowError())
+}
+
+func (s *BackfillWorkflowTestSuite) TestParamsQuery() {
+	since := s.now.Add(-time.Hour).Unix()
+	filter := Filter{OrganizationIDs: []int64{42}, Statuses: []string{"solved"}}
+
+	s.env.OnActivity((*Activity)(nil).BackfillTickets, mock.Anything, mock.Anything).
+		After(time.Minute).Return(&BackfillTicketsOutput{EndTime: since + 600, EndOfStream: true}, nil).Once()
+
+	// A continued run reports where the backfill started, not its cursor
+	s.env.RegisterDelayedCallback(func() {
+		value, err := s.env.QueryWorkflow(ParamsQuery)
+		s.NoError(err)
+
+		var params BackfillInput
+		s.NoError(value.Get(&params))
+		s.Equal(BackfillInput{Since: since - 600, Filter: filter, Rate: 2}, params)
+	}, 30*time.Second)
+
+	s.env.ExecuteWorkflow(BackfillWorkflow, BackfillInput{Cursor: since, Since: since - 600, Filter: filter, Rate: 2})
+	s.NoError(s.env.GetWorkflowError())
+}
+
+func (s *BackfillWorkflowTestSuite) TestContinueAsNewKeepsProgress() {
+	defer func(pages int) { pagesBeforeContinueAsNew = pages }(pagesBeforeContinueAsNew)
+	pagesBeforeContinueAsNew = 1
+
+	since := s.now.Add(-time.Hour).Unix()
+	filter := Filter{Statuses: []string{"solved"}}
+	s.env.OnActivity((*Activity)(nil).BackfillTickets, mock.Anything, mock.Anything).
+		Return(&BackfillTicketsOutput{EndTime: since + 300, Started: 7, Skipped: 993}, nil).Once()
+
+	s.env.ExecuteWorkflow(BackfillWorkflow, BackfillInput{
+		Cursor:   since,
+		Filter:   filter,
+		Rate:     1,
+		Progress: Progress{Started: 100},
+	})
+
+	s.True(s.env.IsWorkflowCompleted())
+	err := s.env.GetWorkflowError()
+	s.True(workflow.IsContinueAsNewError(err))
+
+	var continueAsNew *workflow.ContinueAsNewError
+	s.ErrorAs(err, &continueAsNew)
+
+	var input BackfillInput
+	s.NoError(converter.GetDefaultDataConverter().FromPayloads(continueAsNew.Input, &input))
+	s.Equal(BackfillInput{
+		Cursor:   since + 300,
+		Since:    since,
+		Filter:   filter,
+		Rate:     1,
+		Progress: Progress{Cursor: since + 300, Started: 107, Skipped: 993},
+	}, input)
+}
+
+func TestFilterEqual(t *testing.T) {
+	filter := Filter{OrganizationIDs: []int64{42, 7}, Statuses: []string{"open", "Solved"}}
+
+	assert.True(t, filter.Equal(Filter{OrganizationIDs: []int64{7, 42}, Statuses: []string{"solved", "open"}}))
+	assert.True(t, Filter{}.Equal(Filter{OrganizationIDs: []int64{}}))
+	assert.False(t, filter.Equal(Filter{OrganizationIDs: []int64{42}, Statuses: []string{"open", "solved"}}))
+	assert.False(t, filter.Equal(Filter{OrganizationIDs: []int64{42, 7}}))
+}
+
+func TestFilterMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   Filter
+		ticket   zendesk.Ticket
+		expected bool
+	}{
+		{name: "Empty Filter", ticket: zendesk.Ticket{Status: "open"}, expected: true},
+		{name: "Deleted Ticket", ticket: zendesk.Ticket{Status: "deleted"}},
+		{name: "Organization", filter: Filter{OrganizationIDs: []int64{1, 2}}, ticket: zendesk.Ticket{OrganizationID: 2}, expected: true},
+		{name: "Other Organization", filter: Filter{OrganizationIDs: []int64{1}}, ticket: zendesk.Ticket{OrganizationID: 2}},
+		{name: "Status Is Case Insensitive", filter: Filter{Statuses: []string{"Solved"}}, ticket: zendesk.Ticket{Status: "solved"}, expected: true},
+		{name: "Other Status", filter: Filter{Statuses: []string{"solved"}}, ticket: zendesk.Ticket{Status: "open"}},
+		{
+			name:     "All Criteria Must Match",
+			filter:   Filter{OrganizationIDs: []int64{1}, Statuses: []string{"solved"}},
+			ticket:   zendesk.Ticket{OrganizationID: 1, Status: "open"},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.filter.Matches(tt.ticket))
+		})
+	}
+}
@@ -0,0 +1,146 @@
+// Package history keeps a bounded, versioned history of the summaries
+// generated by the ticket and organization workflows.
+package history
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+const (
+	OpAdded     = "+"
+	OpRemoved   = "-"
+	OpUnchanged = " "
+)
+
+type (
+	// Version is a generated summary along with what produced it
+	Version struct {
+		Version    int       `json:"version"`
+		Summary    string    `json:"summary"`
+		At         time.Time `json:"at"`
+		Trigger    string    `json:"trigger"`
+		Model      string    `json:"model"`
+		PromptHash string    `json:"prompt_hash"`
+		Route      string    `json:"route,omitempty"` // Prompt route that matched, empty for the default prompt
+	}
+
+	DiffLine struct {
+		Op   string `json:"op"`
+		Text string `json:"text"`
+	}
+
+	Diff struct {
+		From  int        `json:"from"`
+		To    int        `json:"to"`
+		Lines []DiffLine `json:"lines"`
+	}
+)
+
+// Append numbers v after the latest version and appends it, dropping the
+// oldest versions beyond limit. Summaries identical to the latest version
+// aren't recorded again.
+func Append(versions []Version, v Version, limit int) []Version {
+	if len(versions) > 0 {
+		latest := versions[len(versions)-1]
+		if latest.Summary == v.Summary {
+			return versions
+		}
+		v.Version = latest.Version + 1
+	} else {
+		v.Version = 1
+	}
+
+	versions = append(versions, v)
+	if len(versions) > limit {
+		versions = versions[len(versions)-limit:]
+	}
+
+	return versions
+}
+
+// Find returns the version numbered n
+func Find(versions []Version, n int) (Version, bool) {
+	for _, v := range versions {
+		if v.Version == n {
+			return v, true
+		}
+	}
+	return Version{}, false
+}
+
+// PromptHash identifies a prompt without storing it in every version
+func PromptHash(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// Compare returns a line diff between two versions. JSON summaries are
+// indented first so each field lands on its own line.
+func Compare(from, to Version) Diff {
+	return Diff{
+		From:  from.Version,
+		To:    to.Version,
+		Lines: diffLines(summaryLines(from.Summary), summaryLines(to.Summary)),
+	}
+}
+
+func summaryLines(summary string) []string {
+	summary = strings.TrimSpace(summary)
+	summary = strings.TrimPrefix(summary, "```json")
+	summary = strings.TrimSuffix(summary, "```")
+	summary = strings.TrimSpace(summary)
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, []byte(summary), "", "  "); err == nil {
+		summary = indented.String()
+	}
+
+	return strings.Split(summary, "\n")
+}
+
+// diffLines computes a minimal line diff from the longest common subsequence
+func diffLines(a, b []string) []DiffLine {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []DiffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, DiffLine{Op: OpUnchanged, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, DiffLine{Op: OpRemoved, Text: a[i]})
+			i++
+		default:
+			lines = append(lines, DiffLine{Op: OpAdded, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, DiffLine{Op: OpRemoved, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, DiffLine{Op: OpAdded, Text: b[j]})
+	}
+
+	return lines
+}
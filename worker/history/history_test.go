@@ -0,0 +1,77 @@
+package history
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppend(t *testing.T) {
+	var versions []Version
+	versions = Append(versions, Version{Summary: "first"}, 2)
+	versions = Append(versions, Version{Summary: "first"}, 2)
+	require.Len(t, versions, 1, "identical summaries aren't recorded twice")
+	assert.Equal(t, 1, versions[0].Version)
+
+	versions = Append(versions, Version{Summary: "second"}, 2)
+	versions = Append(versions, Version{Summary: "third"}, 2)
+	require.Len(t, versions, 2)
+	assert.Equal(t, 2, versions[0].Version)
+	assert.Equal(t, 3, versions[1].Version)
+
+	v, ok := Find(versions, 3)
+	assert.True(t, ok)
+	assert.Equal(t, "third", v.Summary)
+
+	_, ok = Find(versions, 1)
+	assert.False(t, ok)
+}
+
+func TestPromptHash(t *testing.T) {
+	assert.Len(t, PromptHash("prompt"), 12)
+	assert.Equal(t, PromptHash("prompt"), PromptHash("prompt"))
+	assert.NotEqual(t, PromptHash("prompt"), PromptHash("other prompt"))
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		expected []DiffLine
+	}{
+		{
+			name: "JSON field changed",
+			from: `{"intent":"Restore access","next_step":"Wait for logs"}`,
+			to:   "```json\n{\"intent\":\"Restore access\",\"next_step\":\"Rotate certificate\"}\n```",
+			expected: []DiffLine{
+				{Op: OpUnchanged, Text: "{"},
+				{Op: OpUnchanged, Text: `  "intent": "Restore access",`},
+				{Op: OpRemoved, Text: `  "next_step": "Wait for logs"`},
+				{Op: OpAdded, Text: `  "next_step": "Rotate certificate"`},
+				{Op: OpUnchanged, Text: "}"},
+			},
+		},
+		{
+			name: "Plain text lines",
+			from: "a\nb\nc",
+			to:   "a\nc\nd",
+			expected: []DiffLine{
+				{Op: OpUnchanged, Text: "a"},
+				{Op: OpRemoved, Text: "b"},
+				{Op: OpUnchanged, Text: "c"},
+				{Op: OpAdded, Text: "d"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := Compare(Version{Version: 1, Summary: tt.from}, Version{Version: 2, Summary: tt.to})
+			assert.Equal(t, 1, diff.From)
+			assert.Equal(t, 2, diff.To)
+			assert.Equal(t, tt.expected, diff.Lines)
+		})
+	}
+}
@@ -0,0 +1,180 @@
+// Package metrics derives response-time and SLA metrics from a ticket's
+// timeline and aggregates them across an organization's tickets.
+package metrics
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"time"
+)
+
+// StatusSolved is the Zendesk status a reopened ticket moves back from
+const StatusSolved = "solved"
+
+type (
+	// Ticket holds the metrics of a single ticket, updated as its comments and
+	// status changes are seen
+	Ticket struct {
+		FirstResponseSeconds *int64           `json:"first_response_seconds,omitempty"` // Creation to the first public agent reply. Unset until then
+		CustomerWaitSeconds  int64            `json:"customer_wait_seconds"`            // Time the customer waited for public agent replies
+		AgentTouches         int              `json:"agent_touches"`                    // Public agent replies
+		Reopens              int              `json:"reopens"`                          // Moves out of solved other than to closed
+		TimeInStatusSeconds  map[string]int64 `json:"time_in_status_seconds,omitempty"` // Time in each status observed, since the ticket was first seen
+		SolvedAt             *time.Time       `json:"solved_at,omitempty"`              // Latest move to solved, unset once reopened
+
+		// Start of the periods still in progress
+		WaitingSince *time.Time `json:"waiting_since,omitempty"`
+		StatusSince  *time.Time `json:"status_since,omitempty"`
+	}
+
+	// Stat summarizes a metric across tickets
+	Stat struct {
+		Count  int     `json:"count"`
+		Median float64 `json:"median"`
+		P90    float64 `json:"p90"`
+	}
+
+	// Summary aggregates the metrics of an organization's tickets
+	Summary struct {
+		Tickets              int             `json:"tickets"`
+		FirstResponseSeconds Stat            `json:"first_response_seconds"`
+		CustomerWaitSeconds  Stat            `json:"customer_wait_seconds"`
+		AgentTouches         Stat            `json:"agent_touches"`
+		Reopens              Stat            `json:"reopens"`
+		TimeInStatusSeconds  map[string]Stat `json:"time_in_status_seconds,omitempty"`
+	}
+)
+
+// CustomerComment starts the customer's wait unless they are already waiting
+func (m *Ticket) CustomerComment(at time.Time) {
+	if m.WaitingSince == nil {
+		m.WaitingSince = &at
+	}
+}
+
+// AgentReply counts a public agent reply, ending the customer's wait and
+// setting the first response time from the ticket's creation
+func (m *Ticket) AgentReply(at, createdAt time.Time) {
+	m.AgentTouches++
+
+	if m.FirstResponseSeconds == nil && !createdAt.IsZero() {
+		seconds := seconds(at.Sub(createdAt))
+		m.FirstResponseSeconds = &seconds
+	}
+
+	if m.WaitingSince != nil {
+		m.CustomerWaitSeconds += seconds(at.Sub(*m.WaitingSince))
+		m.WaitingSince = nil
+	}
+}
+
+// StatusChange closes the period spent in the previous status as of when the
+// change was observed. The first call only marks when the current status was
+// first seen, so time spent before, e.g. by a backfilled ticket, isn't
+// credited to any status.
+func (m *Ticket) StatusChange(from, to string, at time.Time) {
+	if m.StatusSince == nil {
+		m.StatusSince = &at
+		return
+	}
+	if from == to {
+		return
+	}
+
+	if m.TimeInStatusSeconds == nil {
+		m.TimeInStatusSeconds = make(map[string]int64)
+	}
+	m.TimeInStatusSeconds[from] += seconds(at.Sub(*m.StatusSince))
+	m.StatusSince = &at
+
+	switch {
+	case to == StatusSolved:
+		m.SolvedAt = &at
+	case from == StatusSolved && to != "closed":
+		m.Reopens++
+		m.SolvedAt = nil
+	}
+}
+
+// At returns the metrics as of now, counting the periods still in progress
+// toward the current status and customer wait
+func (m Ticket) At(status string, now time.Time) Ticket {
+	m.TimeInStatusSeconds = maps.Clone(m.TimeInStatusSeconds)
+
+	if m.StatusSince != nil && status != "" {
+		if m.TimeInStatusSeconds == nil {
+			m.TimeInStatusSeconds = make(map[string]int64)
+		}
+		m.TimeInStatusSeconds[status] += seconds(now.Sub(*m.StatusSince))
+		m.StatusSince = &now
+	}
+
+	if m.WaitingSince != nil {
+		m.CustomerWaitSeconds += seconds(now.Sub(*m.WaitingSince))
+		m.WaitingSince = &now
+	}
+
+	return m
+}
+
+// Aggregate computes the median and p90 of each metric across tickets.
+// Tickets without a first response yet are left out of that metric.
+func Aggregate(tickets []Ticket) Summary {
+	var firstResponse, customerWait, agentTouches, reopens []float64
+	timeInStatus := make(map[string][]float64)
+
+	for _, t := range tickets {
+		if t.FirstResponseSeconds != nil {
+			firstResponse = append(firstResponse, float64(*t.FirstResponseSeconds))
+		}
+		customerWait = append(customerWait, float64(t.CustomerWaitSeconds))
+		agentTouches = append(agentTouches, float64(t.AgentTouches))
+		reopens = append(reopens, float64(t.Reopens))
+		for status, seconds := range t.TimeInStatusSeconds {
+			timeInStatus[status] = append(timeInStatus[status], float64(seconds))
+		}
+	}
+
+	summary := Summary{
+		Tickets:              len(tickets),
+		FirstResponseSeconds: stat(firstResponse),
+		CustomerWaitSeconds:  stat(customerWait),
+		AgentTouches:         stat(agentTouches),
+		Reopens:              stat(reopens),
+	}
+
+	if len(timeInStatus) > 0 {
+		summary.TimeInStatusSeconds = make(map[string]Stat, len(timeInStatus))
+		for status, values := range timeInStatus {
+			summary.TimeInStatusSeconds[status] = stat(values)
+		}
+	}
+
+	return summary
+}
+
+func stat(values []float64) Stat {
+	if len(values) == 0 {
+		return Stat{}
+	}
+
+	slices.Sort(values)
+	return Stat{
+		Count:  len(values),
+		Median: percentile(values, 0.5),
+		P90:    percentile(values, 0.9),
+	}
+}
+
+// percentile interpolates linearly between the closest ranks of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	pos := p * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(pos-float64(lower))
+}
+
+func seconds(d time.Duration) int64 {
+	return max(0, int64(d/time.Second))
+}
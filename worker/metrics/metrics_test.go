@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTicket(t *testing.T) {
+	created := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return created.Add(time.Duration(minutes) * time.Minute) }
+
+	var m Ticket
+	m.StatusChange("", "new", created)
+	m.CustomerComment(created)
+
+	// A follow-up while waiting doesn't restart the wait
+	m.CustomerComment(at(10))
+	m.StatusChange("new", "open", at(30))
+	m.AgentReply(at(30), created)
+
+	m.CustomerComment(at(60))
+	m.AgentReply(at(90), created)
+	m.StatusChange("open", StatusSolved, at(90))
+	require.NotNil(t, m.SolvedAt)
+	assert.Equal(t, at(90), *m.SolvedAt)
+
+	// Reopened by the customer, then solved and closed
+	m.StatusChange(StatusSolved, "open", at(120))
+	assert.Nil(t, m.SolvedAt)
+	m.CustomerComment(at(120))
+	m.AgentReply(at(150), created)
+	m.StatusChange("open", StatusSolved, at(150))
+	m.StatusChange(StatusSolved, "closed", at(180))
+
+	require.NotNil(t, m.FirstResponseSeconds)
+	assert.Equal(t, int64(30*60), *m.FirstResponseSeconds)
+	assert.Equal(t, int64((30+30+30)*60), m.CustomerWaitSeconds)
+	assert.Equal(t, 3, m.AgentTouches)
+	assert.Equal(t, 1, m.Reopens)
+	require.NotNil(t, m.SolvedAt)
+	assert.Equal(t, at(150), *m.SolvedAt)
+	assert.Equal(t, map[string]int64{
+		"new":        30 * 60,
+		"open":       (60 + 30) * 60,
+		StatusSolved: (30 + 30) * 60,
+	}, m.TimeInStatusSeconds)
+}
+
+func TestTicketAt(t *testing.T) {
+	created := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	var m Ticket
+	m.StatusChange("", "open", created)
+	m.CustomerComment(created)
+
+	// The periods in progress are counted without changing the metrics
+	snapshot := m.At("open", created.Add(time.Hour))
+	assert.Equal(t, int64(3600), snapshot.CustomerWaitSeconds)
+	assert.Equal(t, map[string]int64{"open": 3600}, snapshot.TimeInStatusSeconds)
+	assert.Nil(t, snapshot.FirstResponseSeconds)
+
+	assert.Zero(t, m.CustomerWaitSeconds)
+	assert.Nil(t, m.TimeInStatusSeconds)
+}
+
+func TestAggregate(t *testing.T) {
+	responded := func(seconds int64) *int64 { return &seconds }
+
+	summary := Aggregate([]Ticket{
+		{FirstResponseSeconds: responded(60), AgentTouches: 1, TimeInStatusSeconds: map[string]int64{"open": 100}},
+		{FirstResponseSeconds: responded(120), AgentTouches: 2, Reopens: 1},
+		{FirstResponseSeconds: responded(600), AgentTouches: 5, TimeInStatusSeconds: map[string]int64{"open": 300}},
+		{AgentTouches: 0}, // Not responded to yet
+	})
+
+	assert.Equal(t, 4, summary.Tickets)
+	assert.Equal(t, Stat{Count: 3, Median: 120, P90: 504}, summary.FirstResponseSeconds)
+	assert.Equal(t, Stat{Count: 4, Median: 1.5, P90: 4.1}, roundStat(summary.AgentTouches))
+	assert.Equal(t, Stat{Count: 4, Median: 0, P90: 0.7}, roundStat(summary.Reopens))
+	assert.Equal(t, map[string]Stat{"open": {Count: 2, Median: 200, P90: 280}}, summary.TimeInStatusSeconds)
+
+	assert.Equal(t, Summary{}, Aggregate(nil))
+}
+
+func roundStat(s Stat) Stat {
+	round := func(f float64) float64 { return float64(int(f*10+0.5)) / 10 }
+	return Stat{Count: s.Count, Median: round(s.Median), P90: round(s.P90)}
+}
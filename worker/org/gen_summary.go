@@ -4,6 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+
+	"github.com/taonic/ticketfu/genai"
+	"github.com/taonic/ticketfu/worker/history"
+	"github.com/taonic/ticketfu/worker/route"
+	"go.temporal.io/sdk/activity"
 )
 
 type (
@@ -12,21 +17,53 @@ type (
 	}
 
 	GenSummaryOutput struct {
-		Summary string
+		Summary    string
+		Model      string
+		PromptHash string
+		Route      string // Prompt route that matched, empty for the default prompt
+		Dropped    int    // Summaries of older tickets left out to fit the context budget
 	}
 )
 
 func (a *Activity) GenOrgSummary(ctx context.Context, input GenSummaryInput) (*GenSummaryOutput, error) {
-	organizationJSON, err := json.Marshal(input.Organization)
+	// Past summaries and their translations would only anchor the new one,
+	// and raw metrics are reported separately
+	organization := input.Organization
+	organization.SummaryHistory = nil
+	organization.TicketMetrics = nil
+	organization.Translations = nil
+
+	cfg := a.genAPI.GetConfig()
+	prompt := cfg.OrgSummaryPrompt
+	r, routed := route.ForOrganization(cfg.PromptRoutes, input.Organization.ID)
+	if routed {
+		prompt = r.OrgSummaryPrompt
+	}
+
+	organization, dropped, err := fitTicketSummaries(genai.NewBudget(cfg), prompt, organization)
+	if err != nil {
+		return nil, err
+	}
+	if dropped > 0 {
+		activity.GetLogger(ctx).Debug("Dropped older ticket summaries over the context budget", "org-id", organization.ID, "dropped", dropped)
+	}
+
+	organizationJSON, err := json.Marshal(organization)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal organization to JSON: %w", err)
 	}
 
-	result, err := a.genAPI.GenerateContent(ctx, a.genAPI.GetConfig().OrgSummaryPrompt, string(organizationJSON))
+	result, err := a.genAPI.GenerateContent(ctx, prompt, string(organizationJSON))
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate %w", err)
 	}
-	output := GenSummaryOutput{Summary: result}
+	output := GenSummaryOutput{
+		Summary:    result,
+		Model:      cfg.LLMModel,
+		PromptHash: history.PromptHash(prompt),
+		Route:      r.Name,
+		Dropped:    dropped,
+	}
 
 	return &output, nil
 }
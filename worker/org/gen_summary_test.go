@@ -3,12 +3,14 @@ package org
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/taonic/ticketfu/config"
+	"github.com/taonic/ticketfu/genai"
 	"go.temporal.io/sdk/testsuite"
 )
 
@@ -22,6 +24,12 @@ func (m *MockGeminiAPI) GenerateContent(ctx context.Context, instruction, conten
 	return args.Get(0).(string), args.Error(1)
 }
 
+func (m *MockGeminiAPI) Embed(ctx context.Context, content string) ([]float32, error) {
+	args := m.Called(ctx, content)
+	vector, _ := args.Get(0).([]float32)
+	return vector, args.Error(1)
+}
+
 func (m *MockGeminiAPI) GetConfig() config.AIConfig {
 	args := m.Called()
 	return args.Get(0).(config.AIConfig)
@@ -48,11 +56,13 @@ func TestActivity_GenOrgSummary(t *testing.T) {
 
 	// Define test cases
 	testCases := []struct {
-		name           string
-		organization   Organization
-		setupMock      func(*MockGeminiAPI)
-		expectedOutput string
-		expectedError  string
+		name            string
+		organization    Organization
+		setupMock       func(*MockGeminiAPI)
+		expectedOutput  string
+		expectedRoute   string
+		expectedDropped int
+		expectedError   string
 	}{
 		{
 			name:         "Successful Summary Generation",
@@ -72,6 +82,54 @@ func TestActivity_GenOrgSummary(t *testing.T) {
 			},
 			expectedOutput: `{"overview": "Test Organization has multiple support issues"}`,
 		},
+		{
+			name:         "Routed Summary",
+			organization: createTestOrganization(),
+			setupMock: func(m *MockGeminiAPI) {
+				m.On("GetConfig").Return(config.AIConfig{
+					OrgSummaryPrompt: "Analyze organization tickets",
+					PromptRoutes: []config.PromptRoute{
+						{Name: "other", Match: config.RouteMatch{OrganizationIDs: []int64{1}}, OrgSummaryPrompt: "Other"},
+						{Name: "enterprise", Match: config.RouteMatch{OrganizationIDs: []int64{123}}, OrgSummaryPrompt: "Analyze account health"},
+					},
+				})
+
+				m.On("GenerateContent",
+					mock.Anything,
+					"Analyze account health",
+					mock.Anything).Return(`{"overview": "Healthy"}`, nil)
+			},
+			expectedOutput: `{"overview": "Healthy"}`,
+			expectedRoute:  "enterprise",
+		},
+		{
+			name: "Over Budget",
+			organization: Organization{
+				ID:   123,
+				Name: "Test Organization",
+				TicketSummaries: map[int64]string{
+					1001: strings.Repeat("a", 300),
+					1002: strings.Repeat("b", 300),
+					1003: strings.Repeat("c", 300),
+				},
+			},
+			setupMock: func(m *MockGeminiAPI) {
+				m.On("GetConfig").Return(config.AIConfig{
+					LLMProvider:      genai.GoogleAI,
+					OrgSummaryPrompt: "prompt",
+					ContextBudgets:   map[string]config.ContextBudget{genai.GoogleAI: {ContextWindow: 300, OutputReserve: 100}},
+				})
+
+				m.On("GenerateContent",
+					mock.Anything,
+					mock.Anything,
+					mock.MatchedBy(func(content string) bool {
+						return strings.Contains(content, "ccc") && !strings.Contains(content, "aaa")
+					})).Return(`{"overview": "Recent tickets only"}`, nil)
+			},
+			expectedOutput:  `{"overview": "Recent tickets only"}`,
+			expectedDropped: 2,
+		},
 		{
 			name:         "Generation API Error",
 			organization: createTestOrganization(),
@@ -154,6 +212,8 @@ func TestActivity_GenOrgSummary(t *testing.T) {
 				require.NoError(t, err)
 
 				assert.Equal(t, tc.expectedOutput, output.Summary)
+				assert.Equal(t, tc.expectedRoute, output.Route)
+				assert.Equal(t, tc.expectedDropped, output.Dropped)
 			}
 
 			mockAPI.AssertExpectations(t)
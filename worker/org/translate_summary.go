@@ -0,0 +1,91 @@
+package org
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MaxSummaryTranslations bounds the translations cached per summary
+const MaxSummaryTranslations = 10
+
+type (
+	TranslateSummaryInput struct {
+		Summary string
+		Locale  string
+	}
+
+	TranslateSummaryOutput struct {
+		Summary string
+	}
+)
+
+// TranslateSummary translates the canonical organization summary to the
+// locale rather than summarizing the tickets again. A summary that isn't JSON,
+// e.g. from a custom summary prompt, is translated as plain text.
+func (a *Activity) TranslateSummary(ctx context.Context, input TranslateSummaryInput) (*TranslateSummaryOutput, error) {
+	summary := trimCodeFence(input.Summary)
+	plain := !json.Valid([]byte(summary))
+	if plain {
+		quoted, err := json.Marshal(strings.TrimSpace(input.Summary))
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal summary to JSON: %w", err)
+		}
+		summary = string(quoted)
+	}
+
+	content, err := json.Marshal(struct {
+		Locale  string          `json:"locale"`
+		Summary json.RawMessage `json:"summary"`
+	}{input.Locale, json.RawMessage(summary)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal summary to JSON: %w", err)
+	}
+
+	result, err := a.genAPI.GenerateContent(ctx, a.genAPI.GetConfig().TranslatePrompt, string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate %w", err)
+	}
+
+	translation := trimCodeFence(result)
+	if plain {
+		translation = plainTranslation(translation)
+		if translation == "" {
+			return nil, errors.New("failed to parse translated summary: empty translation")
+		}
+		return &TranslateSummaryOutput{Summary: translation}, nil
+	}
+	if !json.Valid([]byte(translation)) {
+		return nil, errors.New("failed to parse translated summary: invalid JSON")
+	}
+
+	return &TranslateSummaryOutput{Summary: translation}, nil
+}
+
+// plainTranslation returns the translated text of a plain text summary, which
+// the model may answer as is, as a JSON string or in the input's JSON object
+func plainTranslation(result string) string {
+	var text string
+	if err := json.Unmarshal([]byte(result), &text); err == nil {
+		return strings.TrimSpace(text)
+	}
+
+	var object struct {
+		Summary *string `json:"summary"`
+	}
+	if err := json.Unmarshal([]byte(result), &object); err == nil && object.Summary != nil {
+		return strings.TrimSpace(*object.Summary)
+	}
+
+	return strings.TrimSpace(result)
+}
+
+func trimCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "```json")
+	s = strings.TrimPrefix(s, "```")
+	s = strings.TrimSuffix(s, "```")
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,102 @@
+package org
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/taonic/ticketfu/config"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestActivity_TranslateSummary(t *testing.T) {
+	testSuite := testsuite.WorkflowTestSuite{}
+	testEnv := testSuite.NewTestActivityEnvironment()
+
+	testCases := []struct {
+		name           string
+		summary        string
+		setupMock      func(*MockGeminiAPI)
+		expectedOutput string
+		expectedError  string
+	}{
+		{
+			name:    "Successful Translation",
+			summary: "```json\n{\"overview\": \"Billing issues\"}\n```",
+			setupMock: func(m *MockGeminiAPI) {
+				m.On("GetConfig").Return(config.AIConfig{TranslatePrompt: "translate"})
+				m.On("GenerateContent", mock.Anything, "translate", mock.MatchedBy(func(content string) bool {
+					return strings.Contains(content, `"locale":"de"`) && strings.Contains(content, `"summary":{"overview":"Billing issues"}`)
+				})).Return(`{"overview": "Abrechnungsprobleme"}`, nil)
+			},
+			expectedOutput: `{"overview": "Abrechnungsprobleme"}`,
+		},
+		{
+			name:    "Invalid Translation",
+			summary: `{"overview": "Billing issues"}`,
+			setupMock: func(m *MockGeminiAPI) {
+				m.On("GetConfig").Return(config.AIConfig{TranslatePrompt: "translate"})
+				m.On("GenerateContent", mock.Anything, "translate", mock.Anything).Return("Abrechnungsprobleme", nil)
+			},
+			expectedError: "failed to parse translated summary",
+		},
+		{
+			name:    "Plain Text Summary",
+			summary: "Billing issues",
+			setupMock: func(m *MockGeminiAPI) {
+				m.On("GetConfig").Return(config.AIConfig{TranslatePrompt: "translate"})
+				m.On("GenerateContent", mock.Anything, "translate", mock.MatchedBy(func(content string) bool {
+					return strings.Contains(content, `"summary":"Billing issues"`)
+				})).Return("Abrechnungsprobleme", nil)
+			},
+			expectedOutput: "Abrechnungsprobleme",
+		},
+		{
+			name:    "Plain Text Summary Answered As JSON",
+			summary: "Billing issues",
+			setupMock: func(m *MockGeminiAPI) {
+				m.On("GetConfig").Return(config.AIConfig{TranslatePrompt: "translate"})
+				m.On("GenerateContent", mock.Anything, "translate", mock.Anything).
+					Return("```json\n{\"locale\": \"de\", \"summary\": \"Abrechnungsprobleme\"}\n```", nil)
+			},
+			expectedOutput: "Abrechnungsprobleme",
+		},
+		{
+			name:    "Empty Plain Text Translation",
+			summary: "Billing issues",
+			setupMock: func(m *MockGeminiAPI) {
+				m.On("GetConfig").Return(config.AIConfig{TranslatePrompt: "translate"})
+				m.On("GenerateContent", mock.Anything, "translate", mock.Anything).Return(`""`, nil)
+			},
+			expectedError: "empty translation",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockAPI := new(MockGeminiAPI)
+			tc.setupMock(mockAPI)
+
+			activity := &Activity{genAPI: mockAPI}
+			testEnv.RegisterActivity(activity.TranslateSummary)
+
+			input := TranslateSummaryInput{Summary: tc.summary, Locale: "de"}
+			future, err := testEnv.ExecuteActivity(activity.TranslateSummary, input)
+
+			if tc.expectedError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedError)
+			} else {
+				require.NoError(t, err)
+
+				var output TranslateSummaryOutput
+				require.NoError(t, future.Get(&output))
+				assert.Equal(t, tc.expectedOutput, output.Summary)
+			}
+
+			mockAPI.AssertExpectations(t)
+		})
+	}
+}
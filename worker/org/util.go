@@ -1,6 +1,15 @@
 package org
 
-import "sort"
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/taonic/ticketfu/genai"
+)
 
 func truncateStringMap(m map[int64]string, limit int) (map[int64]string, bool) {
 	if len(m) <= limit {
@@ -25,3 +34,74 @@ func truncateStringMap(m map[int64]string, limit int) (map[int64]string, bool) {
 
 	return newMap, true
 }
+
+// truncateStringMapBytes keeps the entries with the highest keys whose values
+// together fit within limit bytes
+func truncateStringMapBytes(m map[int64]string, limit int) (map[int64]string, bool) {
+	size := 0
+	for _, v := range m {
+		size += len(v)
+	}
+	if size <= limit {
+		return m, false
+	}
+
+	keys := make([]int64, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i] > keys[j]
+	})
+
+	newMap := make(map[int64]string)
+	size = 0
+	for _, k := range keys {
+		size += len(m[k])
+		if size > limit {
+			break
+		}
+		newMap[k] = m[k]
+	}
+
+	return newMap, true
+}
+
+// fitTicketSummaries keeps the summaries of the most recent tickets fitting
+// the tokens the budget leaves after the prompt and the rest of the
+// organization. Ticket IDs grow over time, so the highest are the most
+// recent. It returns how many summaries were dropped.
+func fitTicketSummaries(budget genai.Budget, prompt string, organization Organization) (Organization, int, error) {
+	summaries := organization.TicketSummaries
+	if len(summaries) == 0 {
+		return organization, 0, nil
+	}
+	organization.TicketSummaries = nil
+
+	metadata, err := json.Marshal(organization)
+	if err != nil {
+		return organization, 0, fmt.Errorf("failed to marshal organization to JSON: %w", err)
+	}
+	available := budget.Allocate(prompt, string(metadata)).Content
+
+	ids := slices.Sorted(maps.Keys(summaries))
+	slices.Reverse(ids)
+
+	fitted := make(map[int64]string, len(summaries))
+	used := 0
+	for _, id := range ids {
+		summaryJSON, err := json.Marshal(summaries[id])
+		if err != nil {
+			return organization, 0, fmt.Errorf("failed to marshal ticket summary to JSON: %w", err)
+		}
+		used += budget.CountTokens(strconv.FormatInt(id, 10) + string(summaryJSON))
+		if used > available {
+			break
+		}
+		fitted[id] = summaries[id]
+	}
+
+	organization.TicketSummaries = fitted
+	return organization, len(summaries) - len(fitted), nil
+}
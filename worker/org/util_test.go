@@ -1,9 +1,13 @@
 package org
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taonic/ticketfu/config"
+	"github.com/taonic/ticketfu/genai"
 )
 
 func TestTruncateStringMap(t *testing.T) {
@@ -52,3 +56,81 @@ func TestTruncateStringMap(t *testing.T) {
 		})
 	}
 }
+
+func TestTruncateStringMapBytes(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     map[int64]string
+		limit     int
+		expected  map[int64]string
+		truncated bool
+	}{
+		{
+			name:      "No truncation needed",
+			input:     map[int64]string{1: "a", 2: "bb"},
+			limit:     3,
+			expected:  map[int64]string{1: "a", 2: "bb"},
+			truncated: false,
+		},
+		{
+			name:      "Oldest dropped",
+			input:     map[int64]string{1: "a", 2: "bb", 3: "ccc", 4: "dddd"},
+			limit:     8,
+			expected:  map[int64]string{3: "ccc", 4: "dddd"},
+			truncated: true,
+		},
+		{
+			name:      "Latest over the limit",
+			input:     map[int64]string{1: "a", 2: "bb"},
+			limit:     1,
+			expected:  map[int64]string{},
+			truncated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, truncated := truncateStringMapBytes(tt.input, tt.limit)
+			assert.Equal(t, tt.expected, result)
+			assert.Equal(t, tt.truncated, truncated)
+		})
+	}
+}
+
+func TestFitTicketSummaries(t *testing.T) {
+	budgetFor := func(contextWindow int) genai.Budget {
+		return genai.NewBudget(config.AIConfig{
+			LLMProvider:    genai.GoogleAI,
+			ContextBudgets: map[string]config.ContextBudget{genai.GoogleAI: {ContextWindow: contextWindow, OutputReserve: 100}},
+		})
+	}
+
+	organization := Organization{
+		ID:   101,
+		Name: "Acme",
+		TicketSummaries: map[int64]string{
+			1: strings.Repeat("a", 300),
+			2: strings.Repeat("b", 300),
+			3: strings.Repeat("c", 300),
+		},
+	}
+
+	fitted, dropped, err := fitTicketSummaries(budgetFor(100_000), "prompt", organization)
+	require.NoError(t, err)
+	assert.Equal(t, 0, dropped)
+	assert.Equal(t, organization.TicketSummaries, fitted.TicketSummaries)
+
+	// Each summary takes about 100 tokens, so the rest of the window only
+	// holds the most recent ticket's
+	fitted, dropped, err = fitTicketSummaries(budgetFor(300), "prompt", organization)
+	require.NoError(t, err)
+	assert.Equal(t, 2, dropped)
+	assert.Equal(t, map[int64]string{3: strings.Repeat("c", 300)}, fitted.TicketSummaries)
+	assert.Equal(t, "Acme", fitted.Name)
+
+	// Organizations without tickets are left as is
+	fitted, dropped, err = fitTicketSummaries(budgetFor(300), "prompt", Organization{ID: 101})
+	require.NoError(t, err)
+	assert.Equal(t, 0, dropped)
+	assert.Nil(t, fitted.TicketSummaries)
+}
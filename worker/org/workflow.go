@@ -1,18 +1,34 @@
 package org
 
 import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
 	"time"
 
+	"github.com/taonic/ticketfu/genai"
+	"github.com/taonic/ticketfu/worker/history"
+	"github.com/taonic/ticketfu/worker/metrics"
 	sdklog "go.temporal.io/sdk/log"
 	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 )
 
 const (
-	UpsertOrganizationSignal       = "upsert-organization-signal"
-	QueryOrganizationSummary       = "query-organization-summary"
-	OrganizationWorkflowIDTemplate = "organization-workflow-%s" // e.g. organization-workflow-123
-	MaxTicketSummaries             = 500
+	UpsertOrganizationSignal        = "upsert-organization-signal"
+	EscalateTicketSignal            = "escalate-ticket-signal"
+	RemoveTicketSignal              = "remove-ticket-signal"
+	QueryOrganizationSummary        = "query-organization-summary"
+	QueryOrganizationSummaryHistory = "query-organization-summary-history"
+	QueryOrganizationTranslation    = "query-organization-translation"
+	RefreshOrganizationUpdate       = "refresh-organization-update"
+	TranslateOrganizationUpdate     = "translate-organization-update"
+	OrganizationWorkflowIDTemplate  = "organization-workflow-%s" // e.g. organization-workflow-123
+	MaxTicketSummaries              = 500
+	MaxTicketSummaryBytes           = 512 * 1024
+	MaxEscalations                  = 100
+	MaxSummaryVersions              = 10
 )
 
 var (
@@ -28,24 +44,79 @@ type (
 
 		TicketSummaries map[int64]string
 
+		// Latest metrics reported by each ticket in TicketSummaries
+		TicketMetrics map[int64]metrics.Ticket
+
+		// Most recent ticket escalations
+		Escalations []Escalation
+
 		// LLM generated summary
 		Summary string
+
+		// Translations of Summary keyed by locale, dropped whenever it changes
+		Translations map[string]string
+
+		// Previous summaries, oldest first
+		SummaryHistory []history.Version
 	}
 
 	UpsertOrganizationInput struct {
 		OrganizationID int64
 		TicketID       int64
 		TicketSummary  string
+		Metrics        *metrics.Ticket
+	}
+
+	// Escalation is raised by a ticket workflow when its scores cross the
+	// configured thresholds
+	Escalation struct {
+		TicketID  int64     `json:"ticket_id"`
+		Reason    string    `json:"reason"`
+		Sentiment float64   `json:"sentiment"`
+		Urgency   float64   `json:"urgency"`
+		At        time.Time `json:"at"`
+	}
+
+	RefreshOrganizationInput struct {
+		OrganizationID int64
+	}
+
+	TranslateInput struct {
+		Locale string `json:"locale"`
+	}
+
+	EscalateTicketInput struct {
+		OrganizationID int64
+		Escalation     Escalation
+	}
+
+	// RemoveTicketInput drops a ticket that no longer belongs to the
+	// organization because it was deleted or moved to another organization.
+	// A ticket merged into another is redirected to it.
+	RemoveTicketInput struct {
+		OrganizationID int64
+		TicketID       int64
+		MergedInto     int64
 	}
 
 	QueryOrganizationOutput struct {
-		Summary string `json:"summary"`
+		Summary     string          `json:"summary"`
+		Locale      string          `json:"locale,omitempty"` // Set when the summary is translated
+		Escalations []Escalation    `json:"escalations"`
+		Metrics     metrics.Summary `json:"metrics"`
 	}
 
 	organizationWorkflow struct {
 		workflow.Context
 		logger                     sdklog.Logger
 		signalCh                   workflow.ReceiveChannel
+		escalateCh                 workflow.ReceiveChannel
+		removeCh                   workflow.ReceiveChannel
+		refreshCh                  workflow.Channel
+		refreshesStarted           int
+		refreshesCompleted         int
+		translationsRun            int // Translations run by updates, counted toward continuing as new
+		translatedCh               workflow.Channel
 		updatesBeforeContinueAsNew int
 		activity                   Activity
 
@@ -67,6 +138,10 @@ func newOrganizationWorkflow(ctx workflow.Context, organization Organization) *o
 		}),
 		logger:                     sdklog.With(workflow.GetLogger(ctx)),
 		signalCh:                   workflow.GetSignalChannel(ctx, UpsertOrganizationSignal),
+		escalateCh:                 workflow.GetSignalChannel(ctx, EscalateTicketSignal),
+		removeCh:                   workflow.GetSignalChannel(ctx, RemoveTicketSignal),
+		refreshCh:                  workflow.NewBufferedChannel(ctx, 1),
+		translatedCh:               workflow.NewBufferedChannel(ctx, 1),
 		updatesBeforeContinueAsNew: updatesBeforeContinueAsNew,
 		organization:               organization,
 	}
@@ -94,11 +169,53 @@ func (s *organizationWorkflow) run() error {
 		ch.Receive(s.Context, &pendingUpsert)
 	})
 
+	// Listen for ticket escalations
+	var pendingEscalation *EscalateTicketInput
+	selector.AddReceive(s.escalateCh, func(ch workflow.ReceiveChannel, _ bool) {
+		ch.Receive(s.Context, &pendingEscalation)
+	})
+
+	// Listen for ticket removals
+	var pendingRemoval *RemoveTicketInput
+	selector.AddReceive(s.removeCh, func(ch workflow.ReceiveChannel, _ bool) {
+		ch.Receive(s.Context, &pendingRemoval)
+	})
+
+	// Listen for refresh updates
+	var pendingRefresh *RefreshOrganizationInput
+	selector.AddReceive(s.refreshCh, func(ch workflow.ReceiveChannel, _ bool) {
+		ch.Receive(s.Context, &pendingRefresh)
+	})
+
 	// Set query summary handler
 	if err := workflow.SetQueryHandler(s.Context, QueryOrganizationSummary, s.handleQuerySummary); err != nil {
 		return err
 	}
 
+	// Set summary history query handler
+	if err := workflow.SetQueryHandler(s.Context, QueryOrganizationSummaryHistory, s.handleQuerySummaryHistory); err != nil {
+		return err
+	}
+
+	// Set cached translation query handler
+	if err := workflow.SetQueryHandler(s.Context, QueryOrganizationTranslation, s.handleQueryTranslation); err != nil {
+		return err
+	}
+
+	// Set refresh update handler
+	if err := workflow.SetUpdateHandlerWithOptions(s.Context, RefreshOrganizationUpdate, s.handleRefresh, workflow.UpdateHandlerOptions{
+		Validator: s.validateRefresh,
+	}); err != nil {
+		return err
+	}
+
+	// Set translate update handler
+	if err := workflow.SetUpdateHandlerWithOptions(s.Context, TranslateOrganizationUpdate, s.handleTranslate, workflow.UpdateHandlerOptions{
+		Validator: s.validateTranslate,
+	}); err != nil {
+		return err
+	}
+
 	// Continually select until there are too many requests and no pending
 	// selects.
 	//
@@ -108,7 +225,12 @@ func (s *organizationWorkflow) run() error {
 	// example, we did not check this and there was an unhandled signal buffered
 	// locally, continue-as-new would be returned without it being handled and the
 	// new workflow wouldn't get the signal either. So it'd be lost.
-	for updateCount < s.updatesBeforeContinueAsNew || selector.HasPending() {
+	//
+	// Translations count toward continuing as new, so wake up to count them.
+	selector.AddReceive(s.translatedCh, func(ch workflow.ReceiveChannel, _ bool) {
+		ch.Receive(s.Context, nil)
+	})
+	for updateCount+s.translationsRun < s.updatesBeforeContinueAsNew || selector.HasPending() {
 		selector.Select(s)
 
 		if pendingUpsert != nil {
@@ -119,14 +241,57 @@ func (s *organizationWorkflow) run() error {
 			updateCount++
 		}
 
+		if pendingRefresh != nil {
+			if err := s.processRefresh(pendingRefresh); err != nil {
+				return err
+			}
+			pendingRefresh = nil
+			updateCount++
+		}
+
+		if pendingRemoval != nil {
+			if err := s.processRemoval(pendingRemoval); err != nil {
+				return err
+			}
+			pendingRemoval = nil
+			updateCount++
+		}
+
+		if pendingEscalation != nil {
+			s.processEscalation(pendingEscalation)
+			pendingEscalation = nil
+			updateCount++
+		}
+
 		if cancelled {
 			return temporal.NewCanceledError()
 		}
 	}
 
+	if err := s.awaitHandlers(); err != nil {
+		return err
+	}
+
 	return workflow.NewContinueAsNewError(s, OrganizationWorkflow, s.organization)
 }
 
+// awaitHandlers lets in-flight refresh updates finish before continuing as
+// new, serving the ones queued after the main loop exited so they don't wait
+// forever
+func (s *organizationWorkflow) awaitHandlers() error {
+	for {
+		_ = workflow.Await(s, func() bool { return workflow.AllHandlersFinished(s) || s.refreshCh.Len() > 0 })
+
+		var refresh *RefreshOrganizationInput
+		if !s.refreshCh.ReceiveAsync(&refresh) {
+			return nil
+		}
+		if err := s.processRefresh(refresh); err != nil {
+			return err
+		}
+	}
+}
+
 func (s *organizationWorkflow) processPendingUpsert(pendingUpsert *UpsertOrganizationInput) error {
 	// fetch organization if it hasn't been fetched
 	if s.organization.Name == "" {
@@ -147,38 +312,252 @@ func (s *organizationWorkflow) processPendingUpsert(pendingUpsert *UpsertOrganiz
 		s.organization.TicketSummaries = make(map[int64]string)
 	}
 
+	// Keep the ticket's latest metrics
+	if pendingUpsert.Metrics != nil {
+		if s.organization.TicketMetrics == nil {
+			s.organization.TicketMetrics = make(map[int64]metrics.Ticket)
+		}
+		s.organization.TicketMetrics[pendingUpsert.TicketID] = *pendingUpsert.Metrics
+	}
+
 	// Generate summary if needed
 	targetSummary, exist := s.organization.TicketSummaries[pendingUpsert.TicketID]
 	if !exist || targetSummary != pendingUpsert.TicketSummary {
 		s.logger.Debug("Updating org summary", "org-id", s.organization.ID, "ticket-id", pendingUpsert.TicketID)
 		s.organization.TicketSummaries[pendingUpsert.TicketID] = pendingUpsert.TicketSummary
 
-		// Truncate by keeping up to 500 most recent tickets
-		// todo: make it configurable
-		var truncated bool
-		s.organization.TicketSummaries, truncated = truncateStringMap(s.organization.TicketSummaries, MaxTicketSummaries)
-		if truncated {
-			s.logger.Debug("Truncated ticket summaries to the limit: ", MaxTicketSummaries)
+		// Bound the workflow's state to the most recent tickets, well under
+		// the payload limit. What reaches the LLM is budgeted in tokens
+		// when summarizing.
+		summaries, truncated := truncateStringMap(s.organization.TicketSummaries, MaxTicketSummaries)
+		summaries, trimmed := truncateStringMapBytes(summaries, MaxTicketSummaryBytes)
+		if truncated || trimmed {
+			s.logger.Debug("Truncated ticket summaries to the limits", "org-id", s.organization.ID, "summaries", len(summaries))
+			s.setTicketSummaries(summaries)
 		}
 
 		// Generate org summary
-		genSummaryInput := GenSummaryInput{Organization: s.organization}
-		genSummaryOutput := GenSummaryOutput{}
-
-		err := workflow.ExecuteActivity(s.Context, s.activity.GenOrgSummary, genSummaryInput).
-			Get(s.Context, &genSummaryOutput)
-		if err != nil {
+		if err := s.genSummary(fmt.Sprintf("ticket %d updated", pendingUpsert.TicketID)); err != nil {
 			return err
 		}
+	}
+
+	return nil
+}
+
+// setTicketSummaries replaces the ticket summaries, dropping the metrics of
+// tickets no longer summarized
+func (s *organizationWorkflow) setTicketSummaries(summaries map[int64]string) {
+	s.organization.TicketSummaries = summaries
+	maps.DeleteFunc(s.organization.TicketMetrics, func(ticketID int64, _ metrics.Ticket) bool {
+		_, exist := s.organization.TicketSummaries[ticketID]
+		return !exist
+	})
+}
+
+func (s *organizationWorkflow) genSummary(trigger string) error {
+	genSummaryInput := GenSummaryInput{Organization: s.organization}
+	genSummaryOutput := GenSummaryOutput{}
+
+	err := workflow.ExecuteActivity(s.Context, s.activity.GenOrgSummary, genSummaryInput).
+		Get(s.Context, &genSummaryOutput)
+	if err != nil {
+		return err
+	}
+
+	// Summaries of older tickets that no longer fit the context budget can't
+	// reach the summary again, so they're dropped from the state too
+	if genSummaryOutput.Dropped > 0 {
+		summaries, _ := truncateStringMap(s.organization.TicketSummaries, len(s.organization.TicketSummaries)-genSummaryOutput.Dropped)
+		s.setTicketSummaries(summaries)
+		s.logger.Debug("Dropped ticket summaries over the context budget", "org-id", s.organization.ID, "dropped", genSummaryOutput.Dropped)
+	}
+
+	if genSummaryOutput.Summary != "" {
+		if genSummaryOutput.Summary != s.organization.Summary {
+			s.organization.Translations = nil
+		}
+		s.organization.Summary = genSummaryOutput.Summary
+		s.organization.SummaryHistory = history.Append(s.organization.SummaryHistory, history.Version{
+			Summary:    genSummaryOutput.Summary,
+			At:         workflow.Now(s),
+			Trigger:    trigger,
+			Model:      genSummaryOutput.Model,
+			PromptHash: genSummaryOutput.PromptHash,
+			Route:      genSummaryOutput.Route,
+		}, MaxSummaryVersions)
+	}
 
-		if genSummaryOutput.Summary != "" {
-			s.organization.Summary = genSummaryOutput.Summary
+	return nil
+}
+
+func (s *organizationWorkflow) validateRefresh(input RefreshOrganizationInput) error {
+	if len(s.organization.TicketSummaries) == 0 {
+		return errors.New("organization has no ticket summaries yet")
+	}
+	return nil
+}
+
+// handleRefresh asks the main loop to refresh the organization right away and
+// returns the fresh summary. A refresh already queued serves concurrent
+// requests too.
+func (s *organizationWorkflow) handleRefresh(ctx workflow.Context, input RefreshOrganizationInput) (QueryOrganizationOutput, error) {
+	requestedAfter := s.refreshesStarted
+	s.refreshCh.SendAsync(&input)
+
+	if err := workflow.Await(ctx, func() bool { return s.refreshesCompleted > requestedAfter }); err != nil {
+		return QueryOrganizationOutput{}, err
+	}
+
+	return s.handleQuerySummary()
+}
+
+func (s *organizationWorkflow) validateTranslate(input TranslateInput) error {
+	if _, err := genai.ParseLocale(input.Locale); err != nil {
+		return err
+	}
+	if s.organization.Summary == "" {
+		return errors.New("organization has not been summarized yet")
+	}
+	return nil
+}
+
+// handleTranslate returns the summary translated to the locale, translating
+// the canonical summary on first request and caching it until the summary
+// changes. Cached translations are served by QueryOrganizationTranslation
+// instead, so this only runs on a cache miss.
+func (s *organizationWorkflow) handleTranslate(ctx workflow.Context, input TranslateInput) (QueryOrganizationOutput, error) {
+	locale, _ := genai.ParseLocale(input.Locale)
+
+	translation, ok := s.organization.Translations[locale]
+	if !ok {
+		ctx = workflow.WithActivityOptions(ctx, workflow.GetActivityOptions(s))
+		s.translationsRun++
+		s.translatedCh.SendAsync(struct{}{})
+
+		summary := s.organization.Summary
+		translateSummaryInput := TranslateSummaryInput{Summary: summary, Locale: locale}
+		translateSummaryOutput := TranslateSummaryOutput{}
+
+		if err := workflow.ExecuteActivity(ctx, s.activity.TranslateSummary, translateSummaryInput).
+			Get(ctx, &translateSummaryOutput); err != nil {
+			return QueryOrganizationOutput{}, err
+		}
+		translation = translateSummaryOutput.Summary
+
+		// Only cache if the summary wasn't regenerated while translating
+		if s.organization.Summary == summary {
+			if s.organization.Translations == nil || len(s.organization.Translations) >= MaxSummaryTranslations {
+				s.organization.Translations = make(map[string]string)
+			}
+			s.organization.Translations[locale] = translation
 		}
 	}
 
+	return s.translatedOutput(locale, translation), nil
+}
+
+// handleQueryTranslation returns the cached translation of the summary to
+// the locale, failing when it hasn't been translated yet
+func (s *organizationWorkflow) handleQueryTranslation(input TranslateInput) (QueryOrganizationOutput, error) {
+	locale, err := genai.ParseLocale(input.Locale)
+	if err != nil {
+		return QueryOrganizationOutput{}, err
+	}
+
+	translation, ok := s.organization.Translations[locale]
+	if !ok {
+		return QueryOrganizationOutput{}, errors.New("summary has not been translated to the locale yet")
+	}
+
+	return s.translatedOutput(locale, translation), nil
+}
+
+func (s *organizationWorkflow) translatedOutput(locale, translation string) QueryOrganizationOutput {
+	output, _ := s.handleQuerySummary()
+	output.Summary = translation
+	output.Locale = locale
+	return output
+}
+
+// processRefresh refetches the organization and regenerates its summary
+func (s *organizationWorkflow) processRefresh(pendingRefresh *RefreshOrganizationInput) error {
+	s.refreshesStarted++
+
+	fetchOrganizationInput := FetchOrganizationInput{ID: pendingRefresh.OrganizationID}
+	fetchOrganizationOutput := FetchOrganizationOutput{}
+
+	err := workflow.ExecuteActivity(s.Context, s.activity.FetchOrganization, fetchOrganizationInput).
+		Get(s.Context, &fetchOrganizationOutput)
+	if err != nil {
+		return err
+	}
+
+	s.organization.ID = fetchOrganizationOutput.Organization.ID
+	s.organization.Name = fetchOrganizationOutput.Organization.Name
+	s.organization.Details = fetchOrganizationOutput.Organization.Details
+	s.organization.Notes = fetchOrganizationOutput.Organization.Notes
+
+	if err := s.genSummary("refresh"); err != nil {
+		return err
+	}
+
+	s.refreshesCompleted = s.refreshesStarted
 	return nil
 }
 
+// processRemoval drops the ticket's summary and regenerates the org summary
+// without it. A merged ticket's summary is carried over to the ticket it was
+// merged into until that ticket reports its own. Removing the last ticket
+// clears the summary, recorded as an empty version.
+func (s *organizationWorkflow) processRemoval(pendingRemoval *RemoveTicketInput) error {
+	summary, ok := s.organization.TicketSummaries[pendingRemoval.TicketID]
+	if !ok {
+		return nil
+	}
+
+	s.logger.Debug("Removing ticket from org", "org-id", s.organization.ID, "ticket-id", pendingRemoval.TicketID, "merged-into", pendingRemoval.MergedInto)
+	delete(s.organization.TicketSummaries, pendingRemoval.TicketID)
+	delete(s.organization.TicketMetrics, pendingRemoval.TicketID)
+
+	trigger := fmt.Sprintf("ticket %d removed", pendingRemoval.TicketID)
+	if pendingRemoval.MergedInto != 0 {
+		if _, exist := s.organization.TicketSummaries[pendingRemoval.MergedInto]; !exist {
+			s.organization.TicketSummaries[pendingRemoval.MergedInto] = summary
+			trigger = fmt.Sprintf("ticket %d merged into %d", pendingRemoval.TicketID, pendingRemoval.MergedInto)
+		}
+	}
+
+	if len(s.organization.TicketSummaries) == 0 {
+		s.organization.Summary = ""
+		s.organization.Translations = nil
+		s.organization.SummaryHistory = history.Append(s.organization.SummaryHistory, history.Version{
+			At:      workflow.Now(s),
+			Trigger: trigger,
+		}, MaxSummaryVersions)
+		return nil
+	}
+
+	return s.genSummary(trigger)
+}
+
+func (s *organizationWorkflow) processEscalation(pendingEscalation *EscalateTicketInput) {
+	s.logger.Debug("Recording ticket escalation", "org-id", pendingEscalation.OrganizationID, "ticket-id", pendingEscalation.Escalation.TicketID)
+
+	s.organization.Escalations = append(s.organization.Escalations, pendingEscalation.Escalation)
+	if len(s.organization.Escalations) > MaxEscalations {
+		s.organization.Escalations = s.organization.Escalations[len(s.organization.Escalations)-MaxEscalations:]
+	}
+}
+
+func (s *organizationWorkflow) handleQuerySummaryHistory() ([]history.Version, error) {
+	return s.organization.SummaryHistory, nil
+}
+
 func (s *organizationWorkflow) handleQuerySummary() (QueryOrganizationOutput, error) {
-	return QueryOrganizationOutput{Summary: s.organization.Summary}, nil
+	return QueryOrganizationOutput{
+		Summary:     s.organization.Summary,
+		Escalations: s.organization.Escalations,
+		Metrics:     metrics.Aggregate(slices.Collect(maps.Values(s.organization.TicketMetrics))),
+	}, nil
 }
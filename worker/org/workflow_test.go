@@ -1,13 +1,18 @@
 package org
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
+	"github.com/taonic/ticketfu/worker/history"
+	"github.com/taonic/ticketfu/worker/metrics"
 	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
 )
 
 type OrgWorkflowTestSuite struct {
@@ -134,44 +139,117 @@ func (s *OrgWorkflowTestSuite) TestDuplicateTicketSummaries() {
 func (s *OrgWorkflowTestSuite) TestTicketTruncation() {
 	// Create a map with test ticket summaries
 	ticketMap := make(map[int64]string)
-	for i := int64(1); i <= MaxTicketSummaries+10; i++ {
-		ticketMap[i] = "Summary for ticket " + string(rune(i))
+	for i := int64(1); i <= 20; i++ {
+		ticketMap[i] = fmt.Sprintf("Summary for ticket %d", i)
 	}
 
-	// Create organization with more than the maximum allowed tickets
 	org := Organization{
 		ID:              505,
 		Name:            "Truncation Test Org",
 		TicketSummaries: ticketMap,
 	}
 
-	// Add a new ticket that should trigger truncation
+	// Add a new ticket that no longer leaves room for the oldest ones
 	s.env.RegisterDelayedCallback(func() {
 		s.env.SignalWorkflow(UpsertOrganizationSignal, UpsertOrganizationInput{
 			OrganizationID: 505,
-			TicketID:       MaxTicketSummaries + 11,
+			TicketID:       21,
 			TicketSummary:  "New ticket that triggers truncation",
 		})
 	}, time.Millisecond*100)
 
-	// Mock GenOrgSummary - verify input contains truncated map
 	s.env.OnActivity((*Activity)(nil).GenOrgSummary, mock.Anything, mock.MatchedBy(func(input GenSummaryInput) bool {
-		// Verify ticket map was truncated to MaxTicketSummaries
-		return len(input.Organization.TicketSummaries) == MaxTicketSummaries
+		return len(input.Organization.TicketSummaries) == 21
 	})).Return(&GenSummaryOutput{
 		Summary: "Summary after truncation",
+		Dropped: 6,
+	}, nil).Once()
+
+	// The summaries left out of the budget are dropped from the state
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertOrganizationSignal, UpsertOrganizationInput{
+			OrganizationID: 505,
+			TicketID:       22,
+			TicketSummary:  "Another new ticket",
+		})
+	}, time.Millisecond*200)
+
+	s.env.OnActivity((*Activity)(nil).GenOrgSummary, mock.Anything, mock.MatchedBy(func(input GenSummaryInput) bool {
+		_, oldest := input.Organization.TicketSummaries[6]
+		_, kept := input.Organization.TicketSummaries[7]
+		return len(input.Organization.TicketSummaries) == 16 && !oldest && kept
+	})).Return(&GenSummaryOutput{
+		Summary: "Summary after another ticket",
 	}, nil).Once()
 
 	// Add cancellation to complete the test
 	s.env.RegisterDelayedCallback(func() {
 		s.env.CancelWorkflow()
-	}, time.Millisecond*200)
+	}, time.Millisecond*300)
 
 	// Execute workflow
 	s.env.ExecuteWorkflow(OrganizationWorkflow, org)
 
 	// Verify workflow completed
 	s.True(s.env.IsWorkflowCompleted())
+	s.env.AssertExpectations(s.T())
+}
+
+func (s *OrgWorkflowTestSuite) TestTicketSummaryLimits() {
+	// More tickets than the state keeps, however large the context budget
+	ticketMap := make(map[int64]string)
+	for i := int64(1); i <= MaxTicketSummaries+10; i++ {
+		ticketMap[i] = fmt.Sprintf("Summary for ticket %d", i)
+	}
+
+	org := Organization{
+		ID:              505,
+		Name:            "Truncation Test Org",
+		TicketSummaries: ticketMap,
+		TicketMetrics:   map[int64]metrics.Ticket{1: {}, MaxTicketSummaries + 10: {}},
+	}
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertOrganizationSignal, UpsertOrganizationInput{
+			OrganizationID: 505,
+			TicketID:       MaxTicketSummaries + 11,
+			TicketSummary:  "New ticket that triggers truncation",
+		})
+	}, time.Millisecond*100)
+
+	s.env.OnActivity((*Activity)(nil).GenOrgSummary, mock.Anything, mock.MatchedBy(func(input GenSummaryInput) bool {
+		_, oldest := input.Organization.TicketSummaries[11]
+		_, oldestMetrics := input.Organization.TicketMetrics[1]
+		return len(input.Organization.TicketSummaries) == MaxTicketSummaries && !oldest && !oldestMetrics &&
+			len(input.Organization.TicketMetrics) == 1
+	})).Return(&GenSummaryOutput{Summary: "Summary after truncation"}, nil).Once()
+
+	// A large summary pushes out older ones past the byte limit
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertOrganizationSignal, UpsertOrganizationInput{
+			OrganizationID: 505,
+			TicketID:       MaxTicketSummaries + 12,
+			TicketSummary:  strings.Repeat("a", MaxTicketSummaryBytes-100),
+		})
+	}, time.Millisecond*200)
+
+	s.env.OnActivity((*Activity)(nil).GenOrgSummary, mock.Anything, mock.MatchedBy(func(input GenSummaryInput) bool {
+		size := 0
+		for _, summary := range input.Organization.TicketSummaries {
+			size += len(summary)
+		}
+		_, latest := input.Organization.TicketSummaries[MaxTicketSummaries+12]
+		return size <= MaxTicketSummaryBytes && latest && len(input.Organization.TicketSummaries) < 10
+	})).Return(&GenSummaryOutput{Summary: "Summary after a large ticket"}, nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, time.Millisecond*300)
+
+	s.env.ExecuteWorkflow(OrganizationWorkflow, org)
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.env.AssertExpectations(s.T())
 }
 
 func (s *OrgWorkflowTestSuite) TestConcurrentSignals() {
@@ -230,6 +308,417 @@ func (s *OrgWorkflowTestSuite) TestConcurrentSignals() {
 	s.Equal("Summary after concurrent signals", output.Summary)
 }
 
+func (s *OrgWorkflowTestSuite) TestTicketEscalation() {
+	org := Organization{
+		ID:              808,
+		Name:            "Escalation Test Org",
+		TicketSummaries: make(map[int64]string),
+	}
+
+	escalation := Escalation{TicketID: 8001, Reason: "urgency 0.90 is at or above 0.80", Sentiment: -0.2, Urgency: 0.9}
+
+	// Escalations are recorded without regenerating the org summary
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(EscalateTicketSignal, EscalateTicketInput{
+			OrganizationID: 808,
+			Escalation:     escalation,
+		})
+	}, time.Millisecond*100)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, time.Millisecond*200)
+
+	s.env.ExecuteWorkflow(OrganizationWorkflow, org)
+
+	s.True(s.env.IsWorkflowCompleted())
+
+	var output QueryOrganizationOutput
+	future, err := s.env.QueryWorkflow(QueryOrganizationSummary, nil)
+	s.NoError(err)
+	s.NoError(future.Get(&output))
+	s.Equal([]Escalation{escalation}, output.Escalations)
+}
+
+func (s *OrgWorkflowTestSuite) TestSummaryHistory() {
+	org := Organization{
+		ID:              909,
+		Name:            "History Test Org",
+		TicketSummaries: make(map[int64]string),
+	}
+
+	s.env.OnActivity((*Activity)(nil).GenOrgSummary, mock.Anything, mock.MatchedBy(func(input GenSummaryInput) bool {
+		return len(input.Organization.TicketSummaries) == 1
+	})).Return(&GenSummaryOutput{Summary: "First org summary", Model: "test-model", PromptHash: "abc123"}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).GenOrgSummary, mock.Anything, mock.MatchedBy(func(input GenSummaryInput) bool {
+		return len(input.Organization.TicketSummaries) == 2
+	})).Return(&GenSummaryOutput{Summary: "Second org summary", Model: "test-model", PromptHash: "abc123"}, nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertOrganizationSignal, UpsertOrganizationInput{OrganizationID: 909, TicketID: 9001, TicketSummary: "First"})
+	}, time.Millisecond*100)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertOrganizationSignal, UpsertOrganizationInput{OrganizationID: 909, TicketID: 9002, TicketSummary: "Second"})
+	}, time.Millisecond*200)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, time.Millisecond*300)
+
+	s.env.ExecuteWorkflow(OrganizationWorkflow, org)
+
+	s.True(s.env.IsWorkflowCompleted())
+
+	var versions []history.Version
+	future, err := s.env.QueryWorkflow(QueryOrganizationSummaryHistory)
+	s.NoError(err)
+	s.NoError(future.Get(&versions))
+	s.Require().Len(versions, 2)
+	s.Equal("First org summary", versions[0].Summary)
+	s.Equal("ticket 9001 updated", versions[0].Trigger)
+	s.Equal(2, versions[1].Version)
+	s.Equal("Second org summary", versions[1].Summary)
+	s.Equal("test-model", versions[1].Model)
+}
+
+func (s *OrgWorkflowTestSuite) TestRefresh() {
+	org := Organization{
+		ID:              808,
+		Name:            "Refresh Test Org",
+		TicketSummaries: make(map[int64]string),
+	}
+
+	s.env.OnActivity((*Activity)(nil).GenOrgSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: "Initial org summary"}, nil).Once()
+
+	// The refresh picks up the latest organization details before regenerating
+	s.env.OnActivity((*Activity)(nil).FetchOrganization, mock.Anything, FetchOrganizationInput{ID: 808}).
+		Return(&FetchOrganizationOutput{Organization: Organization{ID: 808, Name: "Renamed Org"}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).GenOrgSummary, mock.Anything, mock.MatchedBy(func(input GenSummaryInput) bool {
+		return input.Organization.Name == "Renamed Org" && len(input.Organization.TicketSummaries) == 1
+	})).Return(&GenSummaryOutput{Summary: "Refreshed org summary"}, nil).Once()
+
+	// Rejected until there's something to summarize
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(RefreshOrganizationUpdate, "early", &testsuite.TestUpdateCallback{
+			OnReject: func(err error) {
+				s.ErrorContains(err, "organization has no ticket summaries yet")
+			},
+			OnAccept:   func() { s.Fail("update should have been rejected") },
+			OnComplete: func(interface{}, error) {},
+		}, RefreshOrganizationInput{OrganizationID: 808})
+	}, time.Millisecond*50)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertOrganizationSignal, UpsertOrganizationInput{OrganizationID: 808, TicketID: 8001, TicketSummary: "First"})
+	}, time.Millisecond*100)
+
+	var refreshed QueryOrganizationOutput
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(RefreshOrganizationUpdate, "refresh", &testsuite.TestUpdateCallback{
+			OnReject: func(err error) { s.Fail("update should have been accepted", err) },
+			OnAccept: func() {},
+			OnComplete: func(result interface{}, err error) {
+				s.NoError(err)
+				refreshed = result.(QueryOrganizationOutput)
+			},
+		}, RefreshOrganizationInput{OrganizationID: 808})
+	}, time.Millisecond*200)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, time.Millisecond*300)
+
+	s.env.ExecuteWorkflow(OrganizationWorkflow, org)
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.Equal("Refreshed org summary", refreshed.Summary)
+
+	var versions []history.Version
+	future, err := s.env.QueryWorkflow(QueryOrganizationSummaryHistory)
+	s.NoError(err)
+	s.NoError(future.Get(&versions))
+	s.Require().Len(versions, 2)
+	s.Equal("refresh", versions[1].Trigger)
+}
+
+func (s *OrgWorkflowTestSuite) TestTicketRemoval() {
+	org := Organization{
+		ID:   707,
+		Name: "Removal Test Org",
+		TicketSummaries: map[int64]string{
+			7001: "First",
+			7002: "Second",
+			7003: "Third",
+		},
+	}
+
+	// A deleted ticket is dropped and the summary regenerated without it
+	s.env.OnActivity((*Activity)(nil).GenOrgSummary, mock.Anything, mock.MatchedBy(func(input GenSummaryInput) bool {
+		_, exist := input.Organization.TicketSummaries[7001]
+		return !exist && len(input.Organization.TicketSummaries) == 2
+	})).Return(&GenSummaryOutput{Summary: "Without the deleted ticket"}, nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(RemoveTicketSignal, RemoveTicketInput{OrganizationID: 707, TicketID: 7001})
+	}, time.Millisecond*100)
+
+	// A merged ticket is carried over to the ticket it was merged into
+	s.env.OnActivity((*Activity)(nil).GenOrgSummary, mock.Anything, mock.MatchedBy(func(input GenSummaryInput) bool {
+		_, exist := input.Organization.TicketSummaries[7002]
+		return !exist && input.Organization.TicketSummaries[7004] == "Second"
+	})).Return(&GenSummaryOutput{Summary: "With the merged ticket"}, nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(RemoveTicketSignal, RemoveTicketInput{OrganizationID: 707, TicketID: 7002, MergedInto: 7004})
+	}, time.Millisecond*200)
+
+	// Unknown tickets are ignored
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(RemoveTicketSignal, RemoveTicketInput{OrganizationID: 707, TicketID: 9999})
+	}, time.Millisecond*300)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, time.Millisecond*400)
+
+	s.env.ExecuteWorkflow(OrganizationWorkflow, org)
+
+	s.True(s.env.IsWorkflowCompleted())
+
+	var output QueryOrganizationOutput
+	future, err := s.env.QueryWorkflow(QueryOrganizationSummary)
+	s.NoError(err)
+	s.NoError(future.Get(&output))
+	s.Equal("With the merged ticket", output.Summary)
+
+	var versions []history.Version
+	future, err = s.env.QueryWorkflow(QueryOrganizationSummaryHistory)
+	s.NoError(err)
+	s.NoError(future.Get(&versions))
+	s.Require().Len(versions, 2)
+	s.Equal("ticket 7001 removed", versions[0].Trigger)
+	s.Equal("ticket 7002 merged into 7004", versions[1].Trigger)
+}
+
+func (s *OrgWorkflowTestSuite) TestLastTicketRemoval() {
+	org := Organization{
+		ID:              808,
+		Name:            "Last Removal Test Org",
+		Summary:         "Only ticket",
+		TicketSummaries: map[int64]string{8001: "Only"},
+		SummaryHistory:  []history.Version{{Version: 1, Summary: "Only ticket", Trigger: "ticket 8001 updated"}},
+	}
+
+	// Removing the last ticket clears the summary without generating one
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(RemoveTicketSignal, RemoveTicketInput{OrganizationID: 808, TicketID: 8001})
+	}, time.Millisecond*100)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, time.Millisecond*200)
+
+	s.env.ExecuteWorkflow(OrganizationWorkflow, org)
+
+	s.True(s.env.IsWorkflowCompleted())
+
+	var output QueryOrganizationOutput
+	future, err := s.env.QueryWorkflow(QueryOrganizationSummary)
+	s.NoError(err)
+	s.NoError(future.Get(&output))
+	s.Empty(output.Summary)
+
+	var versions []history.Version
+	future, err = s.env.QueryWorkflow(QueryOrganizationSummaryHistory)
+	s.NoError(err)
+	s.NoError(future.Get(&versions))
+	s.Require().Len(versions, 2)
+	s.Equal(2, versions[1].Version)
+	s.Empty(versions[1].Summary)
+	s.Equal("ticket 8001 removed", versions[1].Trigger)
+}
+
+func (s *OrgWorkflowTestSuite) TestTicketMetrics() {
+	org := Organization{
+		ID:              606,
+		Name:            "Metrics Test Org",
+		TicketSummaries: make(map[int64]string),
+	}
+
+	s.env.OnActivity((*Activity)(nil).GenOrgSummary, mock.Anything, mock.MatchedBy(func(input GenSummaryInput) bool {
+		return input.Organization.TicketMetrics == nil || len(input.Organization.TicketMetrics) > 0
+	})).Return(&GenSummaryOutput{Summary: "Org summary"}, nil)
+
+	firstResponse := func(seconds int64) *metrics.Ticket {
+		return &metrics.Ticket{FirstResponseSeconds: &seconds, AgentTouches: 1}
+	}
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertOrganizationSignal, UpsertOrganizationInput{OrganizationID: 606, TicketID: 6001, TicketSummary: "First", Metrics: firstResponse(60)})
+	}, time.Millisecond*100)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertOrganizationSignal, UpsertOrganizationInput{OrganizationID: 606, TicketID: 6002, TicketSummary: "Second", Metrics: firstResponse(180)})
+	}, time.Millisecond*200)
+
+	// Updated metrics replace the ticket's previous ones even when the summary is unchanged
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertOrganizationSignal, UpsertOrganizationInput{OrganizationID: 606, TicketID: 6001, TicketSummary: "First", Metrics: firstResponse(120)})
+	}, time.Millisecond*300)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, time.Millisecond*400)
+
+	s.env.ExecuteWorkflow(OrganizationWorkflow, org)
+
+	s.True(s.env.IsWorkflowCompleted())
+
+	var output QueryOrganizationOutput
+	future, err := s.env.QueryWorkflow(QueryOrganizationSummary)
+	s.NoError(err)
+	s.NoError(future.Get(&output))
+	s.Equal(2, output.Metrics.Tickets)
+	s.Equal(metrics.Stat{Count: 2, Median: 150, P90: 174}, output.Metrics.FirstResponseSeconds)
+}
+
+func (s *OrgWorkflowTestSuite) TestTranslate() {
+	org := Organization{
+		ID:              909,
+		Name:            "Translate Test Org",
+		TicketSummaries: make(map[int64]string),
+	}
+
+	s.env.OnActivity((*Activity)(nil).GenOrgSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: `{"overview": "first"}`}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).GenOrgSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: `{"overview": "second"}`}, nil).Once()
+
+	// Each summary is translated once per locale
+	s.env.OnActivity((*Activity)(nil).TranslateSummary, mock.Anything, TranslateSummaryInput{Summary: `{"overview": "first"}`, Locale: "de"}).
+		Return(&TranslateSummaryOutput{Summary: `{"overview": "erste"}`}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).TranslateSummary, mock.Anything, TranslateSummaryInput{Summary: `{"overview": "second"}`, Locale: "de"}).
+		Return(&TranslateSummaryOutput{Summary: `{"overview": "zweite"}`}, nil).Once()
+
+	translate := func(id string, onComplete func(QueryOrganizationOutput)) func() {
+		return func() {
+			s.env.UpdateWorkflow(TranslateOrganizationUpdate, id, &testsuite.TestUpdateCallback{
+				OnReject: func(err error) { s.Fail("update should have been accepted", err) },
+				OnAccept: func() {},
+				OnComplete: func(result interface{}, err error) {
+					s.NoError(err)
+					onComplete(result.(QueryOrganizationOutput))
+				},
+			}, TranslateInput{Locale: "de"})
+		}
+	}
+
+	// Rejected until there's something to translate
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(TranslateOrganizationUpdate, "early", &testsuite.TestUpdateCallback{
+			OnReject: func(err error) {
+				s.ErrorContains(err, "organization has not been summarized yet")
+			},
+			OnAccept:   func() { s.Fail("update should have been rejected") },
+			OnComplete: func(interface{}, error) {},
+		}, TranslateInput{Locale: "de"})
+	}, time.Millisecond*50)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertOrganizationSignal, UpsertOrganizationInput{OrganizationID: 909, TicketID: 9001, TicketSummary: "First"})
+	}, time.Millisecond*100)
+
+	var summaries []string
+	record := func(output QueryOrganizationOutput) {
+		s.Equal("de", output.Locale)
+		summaries = append(summaries, output.Summary)
+	}
+	s.env.RegisterDelayedCallback(translate("first", record), time.Millisecond*200)
+	s.env.RegisterDelayedCallback(translate("cached", record), time.Millisecond*300)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertOrganizationSignal, UpsertOrganizationInput{OrganizationID: 909, TicketID: 9002, TicketSummary: "Second"})
+	}, time.Millisecond*400)
+	s.env.RegisterDelayedCallback(translate("stale", record), time.Millisecond*500)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, time.Millisecond*600)
+
+	s.env.ExecuteWorkflow(OrganizationWorkflow, org)
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.Equal([]string{`{"overview": "erste"}`, `{"overview": "erste"}`, `{"overview": "zweite"}`}, summaries)
+
+	// The cached translation is served by a query
+	var output QueryOrganizationOutput
+	future, err := s.env.QueryWorkflow(QueryOrganizationTranslation, TranslateInput{Locale: "de"})
+	s.NoError(err)
+	s.NoError(future.Get(&output))
+	s.Equal(`{"overview": "zweite"}`, output.Summary)
+	s.Equal("de", output.Locale)
+
+	_, err = s.env.QueryWorkflow(QueryOrganizationTranslation, TranslateInput{Locale: "fr"})
+	s.ErrorContains(err, "summary has not been translated to the locale yet")
+}
+
+func (s *OrgWorkflowTestSuite) TestRefreshWhileContinuingAsNew() {
+	defer func(n int) { updatesBeforeContinueAsNew = n }(updatesBeforeContinueAsNew)
+	// Two upserts and a translation
+	updatesBeforeContinueAsNew = 3
+
+	org := Organization{
+		ID:              606,
+		Name:            "Drain Test Org",
+		TicketSummaries: make(map[int64]string),
+	}
+
+	s.env.OnActivity((*Activity)(nil).GenOrgSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: "Org summary"}, nil).Twice()
+
+	// Keeps the run draining after the second signal
+	s.env.OnActivity((*Activity)(nil).TranslateSummary, mock.Anything, mock.Anything).
+		After(time.Minute).Return(&TranslateSummaryOutput{Summary: "Zusammenfassung"}, nil).Once()
+
+	// The refresh accepted while draining is still served
+	s.env.OnActivity((*Activity)(nil).FetchOrganization, mock.Anything, FetchOrganizationInput{ID: 606}).
+		Return(&FetchOrganizationOutput{Organization: Organization{ID: 606, Name: "Drain Test Org"}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).GenOrgSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: "Refreshed org summary"}, nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertOrganizationSignal, UpsertOrganizationInput{OrganizationID: 606, TicketID: 6001, TicketSummary: "First"})
+	}, time.Millisecond*100)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(TranslateOrganizationUpdate, "translate", &testsuite.TestUpdateCallback{
+			OnReject:   func(err error) { s.Fail("update should have been accepted", err) },
+			OnAccept:   func() {},
+			OnComplete: func(interface{}, error) {},
+		}, TranslateInput{Locale: "de"})
+	}, time.Millisecond*200)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertOrganizationSignal, UpsertOrganizationInput{OrganizationID: 606, TicketID: 6002, TicketSummary: "Second"})
+	}, time.Millisecond*300)
+
+	var refreshed QueryOrganizationOutput
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(RefreshOrganizationUpdate, "refresh", &testsuite.TestUpdateCallback{
+			OnReject: func(err error) { s.Fail("update should have been accepted", err) },
+			OnAccept: func() {},
+			OnComplete: func(result interface{}, err error) {
+				s.NoError(err)
+				refreshed = result.(QueryOrganizationOutput)
+			},
+		}, RefreshOrganizationInput{OrganizationID: 606})
+	}, time.Millisecond*400)
+
+	s.env.ExecuteWorkflow(OrganizationWorkflow, org)
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.True(workflow.IsContinueAsNewError(s.env.GetWorkflowError()))
+	s.Equal("Refreshed org summary", refreshed.Summary)
+}
+
 func TestOrgWorkflowSuite(t *testing.T) {
 	suite.Run(t, new(OrgWorkflowTestSuite))
 }
@@ -0,0 +1,18 @@
+package reconcile
+
+import (
+	"github.com/taonic/ticketfu/zendesk"
+	"go.temporal.io/sdk/client"
+)
+
+type Activity struct {
+	tClient client.Client
+	zClient zendesk.Client
+}
+
+func NewActivity(tClient client.Client, zClient zendesk.Client) *Activity {
+	return &Activity{
+		tClient: tClient,
+		zClient: zClient,
+	}
+}
@@ -0,0 +1,120 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/taonic/ticketfu/worker/ticket"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	"golang.org/x/sync/errgroup"
+)
+
+// MaxConcurrentSignals bounds the ticket workflows signaled at once
+const MaxConcurrentSignals = 10
+
+type (
+	ReconcileTicketsInput struct {
+		StartTime int64 // Unix timestamp to export tickets updated since
+	}
+
+	ReconcileTicketsOutput struct {
+		EndTime     int64 // Start time of the next page
+		EndOfStream bool
+		Signaled    int
+	}
+)
+
+// ReconcileTickets signals the ticket workflows of a page of tickets updated
+// since the start time. Deleted tickets only signal a running workflow so it
+// can record the tombstone. Closed tickets whose workflow has completed are
+// skipped, since their workflow would otherwise start over and summarize them
+// again.
+func (a *Activity) ReconcileTickets(ctx context.Context, input ReconcileTicketsInput) (*ReconcileTicketsOutput, error) {
+	page, err := a.zClient.GetIncrementalTickets(ctx, input.StartTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export tickets: %w", err)
+	}
+
+	taskQueue := activity.GetInfo(ctx).TaskQueue
+
+	var signaled atomic.Int64
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(MaxConcurrentSignals)
+	for _, t := range page.Tickets {
+		group.Go(func() error {
+			ticketID := strconv.FormatInt(t.ID, 10)
+			workflowID := fmt.Sprintf(ticket.TicketWorkflowIDTemplate, ticketID)
+			upsert := ticket.UpsertTicketInput{TicketID: ticketID, UpdatedAt: t.UpdatedAt}
+
+			if t.Status == "deleted" {
+				err := a.tClient.SignalWorkflow(groupCtx, workflowID, "", ticket.UpsertTicketSignal, upsert)
+				var notFound *serviceerror.NotFound
+				if errors.As(err, &notFound) {
+					return nil
+				}
+				if err != nil {
+					return fmt.Errorf("failed to signal ticket workflow %s: %w", workflowID, err)
+				}
+				signaled.Add(1)
+				return nil
+			}
+
+			if t.Status == ticket.StatusClosed {
+				completed, err := WorkflowCompleted(groupCtx, a.tClient, workflowID)
+				if err != nil {
+					return err
+				}
+				if completed {
+					return nil
+				}
+			}
+
+			workflowOptions := client.StartWorkflowOptions{
+				ID:        workflowID,
+				TaskQueue: taskQueue,
+			}
+			if _, err := a.tClient.SignalWithStartWorkflow(groupCtx,
+				workflowID,
+				ticket.UpsertTicketSignal,
+				upsert,
+				workflowOptions,
+				ticket.TicketWorkflow,
+				nil,
+			); err != nil {
+				return fmt.Errorf("failed to signal ticket workflow %s: %w", workflowID, err)
+			}
+			signaled.Add(1)
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	return &ReconcileTicketsOutput{
+		EndTime:     page.EndTime,
+		EndOfStream: page.EndOfStream,
+		Signaled:    int(signaled.Load()),
+	}, nil
+}
+
+// WorkflowCompleted reports whether the ticket's latest workflow completed.
+// Tickets never seen before have no workflow yet, and failed or terminated
+// workflows are restarted.
+func WorkflowCompleted(ctx context.Context, tClient client.Client, workflowID string) (bool, error) {
+	resp, err := tClient.DescribeWorkflowExecution(ctx, workflowID, "")
+	var notFound *serviceerror.NotFound
+	if errors.As(err, &notFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to describe ticket workflow %s: %w", workflowID, err)
+	}
+	return resp.GetWorkflowExecutionInfo().GetStatus() == enums.WORKFLOW_EXECUTION_STATUS_COMPLETED, nil
+}
@@ -0,0 +1,126 @@
+package reconcile
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nukosuke/go-zendesk/zendesk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/taonic/ticketfu/worker/ticket"
+	zd "github.com/taonic/ticketfu/zendesk"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/mocks"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestReconcileTickets(t *testing.T) {
+	testSuite := testsuite.WorkflowTestSuite{}
+	testEnv := testSuite.NewTestActivityEnvironment()
+
+	updatedAt := time.Date(2025, 3, 1, 11, 30, 0, 0, time.UTC)
+	page := zd.IncrementalTicketsPage{
+		Tickets: []zendesk.Ticket{
+			{ID: 1, Status: "open", UpdatedAt: &updatedAt},
+			{ID: 2, Status: "deleted", UpdatedAt: &updatedAt},
+			{ID: 3, Status: "deleted", UpdatedAt: &updatedAt},
+			{ID: 4, Status: "closed", UpdatedAt: &updatedAt},
+			{ID: 5, Status: "closed", UpdatedAt: &updatedAt},
+		},
+		EndTime:     1740828600,
+		EndOfStream: true,
+	}
+
+	testCases := []struct {
+		name           string
+		setupMock      func(*zd.MockZendeskClient, *mocks.Client)
+		expectedOutput *ReconcileTicketsOutput
+		expectedError  string
+	}{
+		{
+			name: "Signals Updated And Deleted Tickets",
+			setupMock: func(z *zd.MockZendeskClient, c *mocks.Client) {
+				z.On("GetIncrementalTickets", mock.Anything, int64(1740826800)).Return(page, nil).Once()
+
+				// Updated tickets start their workflow if needed
+				c.On("SignalWithStartWorkflow", mock.Anything, "ticket-workflow-1", ticket.UpsertTicketSignal,
+					ticket.UpsertTicketInput{TicketID: "1", UpdatedAt: &updatedAt},
+					mock.MatchedBy(func(options client.StartWorkflowOptions) bool { return options.ID == "ticket-workflow-1" }),
+					mock.Anything, mock.Anything).Return(&mocks.WorkflowRun{}, nil).Once()
+
+				// Deleted tickets only signal workflows that are running
+				c.On("SignalWorkflow", mock.Anything, "ticket-workflow-2", "", ticket.UpsertTicketSignal,
+					ticket.UpsertTicketInput{TicketID: "2", UpdatedAt: &updatedAt}).Return(nil).Once()
+				c.On("SignalWorkflow", mock.Anything, "ticket-workflow-3", "", ticket.UpsertTicketSignal, mock.Anything).
+					Return(serviceerror.NewNotFound("workflow not found")).Once()
+
+				// Closed tickets whose workflow completed aren't summarized again
+				c.On("DescribeWorkflowExecution", mock.Anything, "ticket-workflow-4", "").
+					Return(describeResponse(enums.WORKFLOW_EXECUTION_STATUS_COMPLETED), nil).Once()
+
+				// Closed tickets missed entirely are still summarized
+				c.On("DescribeWorkflowExecution", mock.Anything, "ticket-workflow-5", "").
+					Return(nil, serviceerror.NewNotFound("workflow not found")).Once()
+				c.On("SignalWithStartWorkflow", mock.Anything, "ticket-workflow-5", ticket.UpsertTicketSignal,
+					ticket.UpsertTicketInput{TicketID: "5", UpdatedAt: &updatedAt},
+					mock.Anything, mock.Anything, mock.Anything).Return(&mocks.WorkflowRun{}, nil).Once()
+			},
+			expectedOutput: &ReconcileTicketsOutput{EndTime: 1740828600, EndOfStream: true, Signaled: 3},
+		},
+		{
+			name: "Export Error",
+			setupMock: func(z *zd.MockZendeskClient, c *mocks.Client) {
+				z.On("GetIncrementalTickets", mock.Anything, mock.Anything).
+					Return(zd.IncrementalTicketsPage{}, errors.New("429 Too Many Requests"))
+			},
+			expectedError: "failed to export tickets",
+		},
+		{
+			name: "Signal Error",
+			setupMock: func(z *zd.MockZendeskClient, c *mocks.Client) {
+				z.On("GetIncrementalTickets", mock.Anything, mock.Anything).
+					Return(zd.IncrementalTicketsPage{Tickets: page.Tickets[:1]}, nil)
+				c.On("SignalWithStartWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, errors.New("unavailable"))
+			},
+			expectedError: "failed to signal ticket workflow ticket-workflow-1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			zClient := &zd.MockZendeskClient{}
+			tClient := &mocks.Client{}
+			tc.setupMock(zClient, tClient)
+
+			activity := NewActivity(tClient, zClient)
+			testEnv.RegisterActivity(activity.ReconcileTickets)
+
+			future, err := testEnv.ExecuteActivity(activity.ReconcileTickets, ReconcileTicketsInput{StartTime: 1740826800})
+
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+			} else {
+				require.NoError(t, err)
+				var output ReconcileTicketsOutput
+				require.NoError(t, future.Get(&output))
+				assert.Equal(t, tc.expectedOutput, &output)
+			}
+
+			zClient.AssertExpectations(t)
+			tClient.AssertExpectations(t)
+		})
+	}
+}
+
+func describeResponse(status enums.WorkflowExecutionStatus) *workflowservice.DescribeWorkflowExecutionResponse {
+	return &workflowservice.DescribeWorkflowExecutionResponse{
+		WorkflowExecutionInfo: &workflow.WorkflowExecutionInfo{Status: status},
+	}
+}
@@ -0,0 +1,67 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+)
+
+const (
+	ScheduleID = "reconcile-schedule"
+
+	// DefaultInterval is how often tickets are reconciled unless configured
+	DefaultInterval = 15 * time.Minute
+)
+
+// EnsureSchedule creates the schedule running ReconcileWorkflow every
+// interval, or updates the interval of the existing one. A non-positive
+// interval deletes the schedule.
+func EnsureSchedule(ctx context.Context, tClient client.Client, taskQueue string, interval time.Duration) error {
+	schedules := tClient.ScheduleClient()
+
+	if interval <= 0 {
+		err := schedules.GetHandle(ctx, ScheduleID).Delete(ctx)
+		var notFound *serviceerror.NotFound
+		if err != nil && !errors.As(err, &notFound) {
+			return fmt.Errorf("failed to delete reconcile schedule: %w", err)
+		}
+		return nil
+	}
+
+	spec := client.ScheduleSpec{
+		Intervals: []client.ScheduleIntervalSpec{{Every: interval}},
+	}
+
+	_, err := schedules.Create(ctx, client.ScheduleOptions{
+		ID:   ScheduleID,
+		Spec: spec,
+		Action: &client.ScheduleWorkflowAction{
+			ID:        ReconcileWorkflowID,
+			Workflow:  ReconcileWorkflow,
+			Args:      []interface{}{ReconcileInput{}},
+			TaskQueue: taskQueue,
+		},
+		// A slow run already covers the tickets the skipped one would
+		Overlap: enums.SCHEDULE_OVERLAP_POLICY_SKIP,
+	})
+	if errors.Is(err, temporal.ErrScheduleAlreadyRunning) {
+		err = schedules.GetHandle(ctx, ScheduleID).Update(ctx, client.ScheduleUpdateOptions{
+			DoUpdate: func(input client.ScheduleUpdateInput) (*client.ScheduleUpdate, error) {
+				schedule := input.Description.Schedule
+				schedule.Spec = &spec
+				return &client.ScheduleUpdate{Schedule: &schedule}, nil
+			},
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to schedule reconciliation: %w", err)
+	}
+
+	return nil
+}
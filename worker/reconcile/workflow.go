@@ -0,0 +1,97 @@
+// Package reconcile catches up on ticket updates whose Zendesk webhook was
+// missed, e.g. while the server was down, by periodically exporting the
+// tickets updated since a watermark and signaling their workflows.
+package reconcile
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+const (
+	ReconcileWorkflowID = "reconcile-workflow"
+
+	// InitialLookback is how far back the first reconciliation looks
+	InitialLookback = time.Hour
+
+	// MinExportAge is how old the start of an export must be. Zendesk
+	// rejects more recent start times.
+	MinExportAge = time.Minute
+)
+
+var (
+	pagesBeforeContinueAsNew = 20
+)
+
+type (
+	ReconcileInput struct {
+		// Unix timestamp tickets updated since are reconciled. Defaults to the
+		// watermark the previous scheduled run completed with.
+		Watermark int64
+		Signaled  int // Tickets signaled before continuing as new
+	}
+
+	ReconcileOutput struct {
+		Watermark int64
+		Signaled  int
+	}
+)
+
+// ReconcileWorkflow pages through the tickets updated since the watermark,
+// signaling each ticket's workflow, and completes with the new watermark for
+// the next scheduled run to resume from
+func ReconcileWorkflow(ctx workflow.Context, input ReconcileInput) (*ReconcileOutput, error) {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 5 * time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    10 * time.Second, // incremental exports are rate limited to 10 requests a minute
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    5 * time.Minute,
+			MaximumAttempts:    10,
+		},
+	})
+	logger := workflow.GetLogger(ctx)
+
+	watermark := input.Watermark
+	if watermark == 0 && workflow.HasLastCompletionResult(ctx) {
+		var last ReconcileOutput
+		if err := workflow.GetLastCompletionResult(ctx, &last); err != nil {
+			return nil, err
+		}
+		watermark = last.Watermark
+	}
+	if watermark == 0 {
+		watermark = workflow.Now(ctx).Add(-InitialLookback).Unix()
+	}
+
+	var a *Activity
+	signaled := input.Signaled
+	for page := 0; ; page++ {
+		// The rest is picked up by the next scheduled run
+		if watermark > workflow.Now(ctx).Add(-MinExportAge).Unix() {
+			break
+		}
+
+		if page == pagesBeforeContinueAsNew {
+			return nil, workflow.NewContinueAsNewError(ctx, ReconcileWorkflow, ReconcileInput{Watermark: watermark, Signaled: signaled})
+		}
+
+		var output ReconcileTicketsOutput
+		if err := workflow.ExecuteActivity(ctx, a.ReconcileTickets, ReconcileTicketsInput{StartTime: watermark}).
+			Get(ctx, &output); err != nil {
+			return nil, err
+		}
+
+		signaled += output.Signaled
+		watermark = max(watermark, output.EndTime)
+
+		if output.EndOfStream {
+			break
+		}
+	}
+
+	logger.Info("Reconciled tickets", "signaled", signaled, "watermark", time.Unix(watermark, 0).UTC())
+	return &ReconcileOutput{Watermark: watermark, Signaled: signaled}, nil
+}
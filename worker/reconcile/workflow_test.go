@@ -0,0 +1,98 @@
+package reconcile
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+func TestReconcileWorkflowSuite(t *testing.T) {
+	suite.Run(t, new(ReconcileWorkflowTestSuite))
+}
+
+type ReconcileWorkflowTestSuite struct {
+	suite.Suite
+	testsuite.WorkflowTestSuite
+	env *testsuite.TestWorkflowEnvironment
+	now time.Time
+}
+
+func (s *ReconcileWorkflowTestSuite) SetupTest() {
+	s.env = s.NewTestWorkflowEnvironment()
+	s.now = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	s.env.SetStartTime(s.now)
+}
+
+func (s *ReconcileWorkflowTestSuite) TearDownTest() {
+	s.env.AssertExpectations(s.T())
+}
+
+func (s *ReconcileWorkflowTestSuite) TestFirstRunLooksBack() {
+	start := s.now.Add(-InitialLookback).Unix()
+
+	s.env.OnActivity((*Activity)(nil).ReconcileTickets, mock.Anything, ReconcileTicketsInput{StartTime: start}).
+		Return(&ReconcileTicketsOutput{EndTime: start + 600, EndOfStream: true, Signaled: 3}, nil).Once()
+
+	s.env.ExecuteWorkflow(ReconcileWorkflow, ReconcileInput{})
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+
+	var output ReconcileOutput
+	s.NoError(s.env.GetWorkflowResult(&output))
+	s.Equal(ReconcileOutput{Watermark: start + 600, Signaled: 3}, output)
+}
+
+func (s *ReconcileWorkflowTestSuite) TestResumesFromPreviousRun() {
+	watermark := s.now.Add(-20 * time.Minute).Unix()
+	s.env.SetLastCompletionResult(&ReconcileOutput{Watermark: watermark})
+
+	// Pages until the end of the stream
+	s.env.OnActivity((*Activity)(nil).ReconcileTickets, mock.Anything, ReconcileTicketsInput{StartTime: watermark}).
+		Return(&ReconcileTicketsOutput{EndTime: watermark + 300, Signaled: 1000}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).ReconcileTickets, mock.Anything, ReconcileTicketsInput{StartTime: watermark + 300}).
+		Return(&ReconcileTicketsOutput{EndTime: watermark + 600, EndOfStream: true, Signaled: 10}, nil).Once()
+
+	s.env.ExecuteWorkflow(ReconcileWorkflow, ReconcileInput{})
+
+	var output ReconcileOutput
+	s.NoError(s.env.GetWorkflowResult(&output))
+	s.Equal(ReconcileOutput{Watermark: watermark + 600, Signaled: 1010}, output)
+}
+
+func (s *ReconcileWorkflowTestSuite) TestRecentWatermarkIsLeftForTheNextRun() {
+	watermark := s.now.Add(-MinExportAge / 2).Unix()
+
+	s.env.ExecuteWorkflow(ReconcileWorkflow, ReconcileInput{Watermark: watermark})
+
+	var output ReconcileOutput
+	s.NoError(s.env.GetWorkflowResult(&output))
+	s.Equal(ReconcileOutput{Watermark: watermark}, output)
+}
+
+func (s *ReconcileWorkflowTestSuite) TestContinueAsNewKeepsWatermark() {
+	defer func(pages int) { pagesBeforeContinueAsNew = pages }(pagesBeforeContinueAsNew)
+	pagesBeforeContinueAsNew = 1
+
+	watermark := s.now.Add(-time.Hour).Unix()
+	s.env.OnActivity((*Activity)(nil).ReconcileTickets, mock.Anything, ReconcileTicketsInput{StartTime: watermark}).
+		Return(&ReconcileTicketsOutput{EndTime: watermark + 300, Signaled: 1000}, nil).Once()
+
+	s.env.ExecuteWorkflow(ReconcileWorkflow, ReconcileInput{Watermark: watermark})
+
+	s.True(s.env.IsWorkflowCompleted())
+	err := s.env.GetWorkflowError()
+	s.True(workflow.IsContinueAsNewError(err))
+
+	var continueAsNew *workflow.ContinueAsNewError
+	s.ErrorAs(err, &continueAsNew)
+
+	var input ReconcileInput
+	s.NoError(converter.GetDefaultDataConverter().FromPayloads(continueAsNew.Input, &input))
+	s.Equal(ReconcileInput{Watermark: watermark + 300, Signaled: 1000}, input)
+}
@@ -0,0 +1,268 @@
+// Package related keeps the embeddings of ticket summaries in a local index
+// persisted to a file, and finds the tickets most similar to a given one.
+package related
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/taonic/ticketfu/config"
+)
+
+const (
+	// DefaultIndexPath is where the index is persisted unless configured
+	DefaultIndexPath = "ticketfu-embeddings.json"
+
+	// TaskQueue is where the activities reading and writing the index run.
+	// The index is local to the worker serving it, so a single worker polls
+	// this queue.
+	TaskQueue = "ticketfu-index-queue"
+
+	// minCompactRecords is how many records the log holds before it's
+	// worth compacting
+	minCompactRecords = 1000
+)
+
+type (
+	// Entry is the embedding of a ticket's summary
+	Entry struct {
+		TicketID       int64     `json:"ticket_id"`
+		OrganizationID int64     `json:"organization_id,omitempty"`
+		Summary        string    `json:"summary"`
+		Vector         []float32 `json:"vector"`
+		UpdatedAt      time.Time `json:"updated_at"`
+	}
+
+	// Match is an indexed ticket similar to the one searched for
+	Match struct {
+		TicketID       int64   `json:"ticket_id"`
+		OrganizationID int64   `json:"organization_id,omitempty"`
+		Score          float64 `json:"score"` // Cosine similarity, 1 being identical
+		Summary        string  `json:"summary"`
+	}
+
+	// Index holds the entries in memory and appends every change to a log
+	// file, which is compacted once mostly made of stale records
+	Index struct {
+		path    string
+		mu      sync.RWMutex
+		entries map[int64]Entry
+		records int // Records in the log
+	}
+
+	// record is a change appended to the log
+	record struct {
+		Entry
+		Deleted bool `json:"deleted,omitempty"`
+	}
+)
+
+// NewIndex loads the index persisted at the configured path, starting empty
+// when the file doesn't exist yet. Indexes persisted as a single JSON array
+// are converted to a log.
+func NewIndex(cfg config.WorkerConfig) (*Index, error) {
+	path := cfg.EmbeddingIndexPath
+	if path == "" {
+		path = DefaultIndexPath
+	}
+	index := &Index{path: path, entries: make(map[int64]Entry)}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedding index: %w", err)
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("[")) {
+		var entries []Entry
+		if err := json.Unmarshal(content, &entries); err != nil {
+			return nil, fmt.Errorf("failed to parse embedding index %s: %w", path, err)
+		}
+		for _, entry := range entries {
+			index.entries[entry.TicketID] = entry
+		}
+		return index, index.compact()
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	for {
+		var r record
+		err := decoder.Decode(&r)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		// A crash can leave the last record half written
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return index, index.compact()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse embedding index %s: %w", path, err)
+		}
+		index.apply(r)
+	}
+
+	return index, nil
+}
+
+// Get returns the ticket's entry
+func (i *Index) Get(ticketID int64) (Entry, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	entry, ok := i.entries[ticketID]
+	return entry, ok
+}
+
+// Upsert adds or replaces the ticket's entry
+func (i *Index) Upsert(entry Entry) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return i.append(record{Entry: entry})
+}
+
+// Delete removes the ticket's entry, if any
+func (i *Index) Delete(ticketID int64) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if _, ok := i.entries[ticketID]; !ok {
+		return nil
+	}
+	return i.append(record{Entry: Entry{TicketID: ticketID}, Deleted: true})
+}
+
+// Search returns up to limit tickets most similar to the vector, most similar
+// first. The excluded ticket and entries embedded by a model with different
+// dimensions are skipped.
+func (i *Index) Search(vector []float32, limit int, exclude int64) []Match {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	var matches []Match
+	for _, entry := range i.entries {
+		if entry.TicketID == exclude || len(entry.Vector) != len(vector) {
+			continue
+		}
+		matches = append(matches, Match{
+			TicketID:       entry.TicketID,
+			OrganizationID: entry.OrganizationID,
+			Score:          cosine(vector, entry.Vector),
+			Summary:        entry.Summary,
+		})
+	}
+
+	sort.Slice(matches, func(a, b int) bool {
+		if matches[a].Score != matches[b].Score {
+			return matches[a].Score > matches[b].Score
+		}
+		return matches[a].TicketID < matches[b].TicketID
+	})
+
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// apply applies a record to the entries
+func (i *Index) apply(r record) {
+	if r.Deleted {
+		delete(i.entries, r.TicketID)
+	} else {
+		i.entries[r.TicketID] = r.Entry
+	}
+	i.records++
+}
+
+// append writes the record to the log and applies it, compacting the log
+// once most of its records are stale. Callers hold the write lock.
+func (i *Index) append(r record) error {
+	content, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal embedding index record: %w", err)
+	}
+
+	file, err := os.OpenFile(i.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to write embedding index: %w", err)
+	}
+	if _, err := file.Write(append(content, '\n')); err != nil {
+		file.Close()
+		// Rewrite the log rather than append after a partial record
+		return errors.Join(fmt.Errorf("failed to write embedding index: %w", err), i.compact())
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write embedding index: %w", err)
+	}
+
+	i.apply(r)
+	if i.records > minCompactRecords && i.records > 2*len(i.entries) {
+		return i.compact()
+	}
+	return nil
+}
+
+// compact rewrites the log with a record per entry, to a temporary file
+// renamed over the index so a crash never leaves it half written. Callers
+// hold the write lock.
+func (i *Index) compact() error {
+	ticketIDs := make([]int64, 0, len(i.entries))
+	for ticketID := range i.entries {
+		ticketIDs = append(ticketIDs, ticketID)
+	}
+	sort.Slice(ticketIDs, func(a, b int) bool { return ticketIDs[a] < ticketIDs[b] })
+
+	var content bytes.Buffer
+	encoder := json.NewEncoder(&content)
+	for _, ticketID := range ticketIDs {
+		if err := encoder.Encode(record{Entry: i.entries[ticketID]}); err != nil {
+			return fmt.Errorf("failed to marshal embedding index record: %w", err)
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(i.path), filepath.Base(i.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write embedding index: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content.Bytes()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write embedding index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write embedding index: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), i.path); err != nil {
+		return fmt.Errorf("failed to write embedding index: %w", err)
+	}
+
+	i.records = len(ticketIDs)
+	return nil
+}
+
+func cosine(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for n := range a {
+		dot += float64(a[n]) * float64(b[n])
+		normA += float64(a[n]) * float64(a[n])
+		normB += float64(b[n]) * float64(b[n])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,125 @@
+package related
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taonic/ticketfu/config"
+)
+
+func newTestIndex(t *testing.T) (*Index, string) {
+	path := filepath.Join(t.TempDir(), "embeddings.json")
+	index, err := NewIndex(config.WorkerConfig{EmbeddingIndexPath: path})
+	require.NoError(t, err)
+	return index, path
+}
+
+func TestSearch(t *testing.T) {
+	index, _ := newTestIndex(t)
+
+	require.NoError(t, index.Upsert(Entry{TicketID: 1, OrganizationID: 10, Summary: "login fails", Vector: []float32{1, 0, 0}}))
+	require.NoError(t, index.Upsert(Entry{TicketID: 2, OrganizationID: 20, Summary: "login slow", Vector: []float32{0.8, 0.6, 0}}))
+	require.NoError(t, index.Upsert(Entry{TicketID: 3, OrganizationID: 10, Summary: "billing", Vector: []float32{0, 0, 1}}))
+	// Embedded by a model with different dimensions
+	require.NoError(t, index.Upsert(Entry{TicketID: 4, Summary: "other model", Vector: []float32{1, 0}}))
+
+	matches := index.Search([]float32{1, 0, 0}, 2, 1)
+	require.Len(t, matches, 2)
+	assert.Equal(t, int64(2), matches[0].TicketID)
+	assert.Equal(t, int64(20), matches[0].OrganizationID)
+	assert.InDelta(t, 0.8, matches[0].Score, 1e-6)
+	assert.Equal(t, "login slow", matches[0].Summary)
+	assert.Equal(t, int64(3), matches[1].TicketID)
+	assert.InDelta(t, 0, matches[1].Score, 1e-6)
+}
+
+func TestPersistence(t *testing.T) {
+	index, path := newTestIndex(t)
+
+	require.NoError(t, index.Upsert(Entry{TicketID: 1, Summary: "first", Vector: []float32{1, 0}}))
+	require.NoError(t, index.Upsert(Entry{TicketID: 2, Summary: "second", Vector: []float32{0, 1}}))
+	require.NoError(t, index.Upsert(Entry{TicketID: 1, Summary: "first, updated", Vector: []float32{1, 1}}))
+	require.NoError(t, index.Delete(2))
+	require.NoError(t, index.Delete(3), "deleting a missing ticket is a no-op")
+
+	reloaded, err := NewIndex(config.WorkerConfig{EmbeddingIndexPath: path})
+	require.NoError(t, err)
+
+	entry, ok := reloaded.Get(1)
+	require.True(t, ok)
+	assert.Equal(t, "first, updated", entry.Summary)
+	assert.Equal(t, []float32{1, 1}, entry.Vector)
+
+	_, ok = reloaded.Get(2)
+	assert.False(t, ok)
+
+	// No temporary files are left behind
+	files, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func TestNewIndexCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "embeddings.json")
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o600))
+
+	_, err := NewIndex(config.WorkerConfig{EmbeddingIndexPath: path})
+	assert.ErrorContains(t, err, "failed to parse embedding index")
+}
+
+func TestLogCompaction(t *testing.T) {
+	index, path := newTestIndex(t)
+
+	for n := range minCompactRecords + 1 {
+		require.NoError(t, index.Upsert(Entry{TicketID: 1, Summary: "summary", Vector: []float32{float32(n), 1}}))
+	}
+	require.NoError(t, index.Upsert(Entry{TicketID: 2, Summary: "other", Vector: []float32{0, 1}}))
+
+	// Only the latest record of each ticket is kept once compacted
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, bytes.Count(content, []byte("\n")))
+
+	reloaded, err := NewIndex(config.WorkerConfig{EmbeddingIndexPath: path})
+	require.NoError(t, err)
+	entry, ok := reloaded.Get(1)
+	require.True(t, ok)
+	assert.Equal(t, []float32{minCompactRecords, 1}, entry.Vector)
+}
+
+func TestNewIndexFromArray(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "embeddings.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"ticket_id": 1, "summary": "first", "vector": [1, 0]}]`), 0o600))
+
+	index, err := NewIndex(config.WorkerConfig{EmbeddingIndexPath: path})
+	require.NoError(t, err)
+	require.NoError(t, index.Upsert(Entry{TicketID: 2, Summary: "second", Vector: []float32{0, 1}}))
+
+	reloaded, err := NewIndex(config.WorkerConfig{EmbeddingIndexPath: path})
+	require.NoError(t, err)
+	for _, ticketID := range []int64{1, 2} {
+		_, ok := reloaded.Get(ticketID)
+		assert.True(t, ok)
+	}
+}
+
+func TestNewIndexPartialRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "embeddings.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"ticket_id": 1, "summary": "first", "vector": [1, 0]}`+"\n"+`{"ticket_id": 2, "summ`), 0o600))
+
+	index, err := NewIndex(config.WorkerConfig{EmbeddingIndexPath: path})
+	require.NoError(t, err)
+	_, ok := index.Get(1)
+	assert.True(t, ok)
+
+	// The partial record is dropped before more are appended
+	require.NoError(t, index.Upsert(Entry{TicketID: 3, Summary: "third", Vector: []float32{0, 1}}))
+	reloaded, err := NewIndex(config.WorkerConfig{EmbeddingIndexPath: path})
+	require.NoError(t, err)
+	_, ok = reloaded.Get(3)
+	assert.True(t, ok)
+}
@@ -0,0 +1,158 @@
+// Package route picks the summary prompt for a ticket or organization from
+// the ordered prompt routes loaded from a YAML file.
+package route
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/taonic/ticketfu/config"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// FieldTypes are the JSON types a summary field can have
+	FieldTypes = []string{"string", "number", "boolean", "array", "object"}
+
+	// reservedFields are always part of the ticket summary
+	reservedFields = []string{"intent", "summary", "next_step"}
+)
+
+// Ticket is what ticket routes match on
+type Ticket struct {
+	BrandID        int64
+	GroupID        int64
+	Type           string
+	Tags           []string
+	CustomFields   map[string]string
+	OrganizationID int64
+}
+
+// file is the layout of the prompt routes YAML file
+type file struct {
+	Routes []config.PromptRoute `yaml:"routes"`
+}
+
+// Load reads and validates the prompt routes in the YAML file at path
+func Load(path string) ([]config.PromptRoute, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt routes: %w", err)
+	}
+
+	var f file
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&f); err != nil {
+		return nil, fmt.Errorf("failed to parse prompt routes: %w", err)
+	}
+
+	if err := Validate(f.Routes); err != nil {
+		return nil, err
+	}
+	return f.Routes, nil
+}
+
+// Validate checks that routes are named uniquely and each sets a prompt.
+// Organization routes may only match on organizations, as there's no single
+// ticket to match the other criteria against.
+func Validate(routes []config.PromptRoute) error {
+	names := make(map[string]bool)
+	for i, r := range routes {
+		if r.Name == "" {
+			return fmt.Errorf("prompt route %d: name is required", i+1)
+		}
+		if names[r.Name] {
+			return fmt.Errorf("prompt route %q: duplicate name", r.Name)
+		}
+		names[r.Name] = true
+
+		if r.TicketSummaryPrompt == "" && r.OrgSummaryPrompt == "" {
+			return fmt.Errorf("prompt route %q: ticket_summary_prompt or org_summary_prompt is required", r.Name)
+		}
+		if len(r.SummaryFields) > 0 && r.TicketSummaryPrompt == "" {
+			return fmt.Errorf("prompt route %q: summary_fields require a ticket_summary_prompt asking for them", r.Name)
+		}
+		if r.OrgSummaryPrompt != "" && hasTicketCriteria(r.Match) {
+			return fmt.Errorf("prompt route %q: org_summary_prompt can only be routed by organization_ids", r.Name)
+		}
+
+		for _, field := range r.SummaryFields {
+			if err := validateField(field); err != nil {
+				return fmt.Errorf("prompt route %q: %w", r.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateField(field config.SummaryField) error {
+	if field.Name == "" {
+		return errors.New("summary field name is required")
+	}
+	if slices.Contains(reservedFields, field.Name) {
+		return fmt.Errorf("summary field %q is always included", field.Name)
+	}
+	if !slices.Contains(FieldTypes, field.Type) {
+		return fmt.Errorf("summary field %q: type must be one of %s", field.Name, strings.Join(FieldTypes, ", "))
+	}
+	return nil
+}
+
+// ForTicket returns the first route with a ticket prompt matching the ticket
+func ForTicket(routes []config.PromptRoute, ticket Ticket) (config.PromptRoute, bool) {
+	for _, r := range routes {
+		if r.TicketSummaryPrompt != "" && matchTicket(r.Match, ticket) {
+			return r, true
+		}
+	}
+	return config.PromptRoute{}, false
+}
+
+// ForOrganization returns the first route with an organization prompt
+// matching the organization
+func ForOrganization(routes []config.PromptRoute, organizationID int64) (config.PromptRoute, bool) {
+	for _, r := range routes {
+		if r.OrgSummaryPrompt != "" && anyOf(r.Match.OrganizationIDs, organizationID) {
+			return r, true
+		}
+	}
+	return config.PromptRoute{}, false
+}
+
+func matchTicket(m config.RouteMatch, ticket Ticket) bool {
+	if !anyOf(m.BrandIDs, ticket.BrandID) ||
+		!anyOf(m.GroupIDs, ticket.GroupID) ||
+		!anyOf(m.OrganizationIDs, ticket.OrganizationID) {
+		return false
+	}
+
+	if len(m.Types) > 0 && !slices.ContainsFunc(m.Types, func(t string) bool { return strings.EqualFold(t, ticket.Type) }) {
+		return false
+	}
+
+	if len(m.Tags) > 0 && !slices.ContainsFunc(m.Tags, func(tag string) bool { return slices.Contains(ticket.Tags, tag) }) {
+		return false
+	}
+
+	for name, value := range m.CustomFields {
+		if ticket.CustomFields[name] != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// anyOf reports whether v is one of values, or values is unset
+func anyOf[T comparable](values []T, v T) bool {
+	return len(values) == 0 || slices.Contains(values, v)
+}
+
+func hasTicketCriteria(m config.RouteMatch) bool {
+	return len(m.BrandIDs) > 0 || len(m.GroupIDs) > 0 || len(m.Types) > 0 || len(m.Tags) > 0 || len(m.CustomFields) > 0
+}
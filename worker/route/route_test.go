@@ -0,0 +1,176 @@
+package route
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taonic/ticketfu/config"
+)
+
+func writeRoutes(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "routes.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	path := writeRoutes(t, `
+routes:
+  - name: billing
+    match:
+      brand_ids: [1]
+      tags: [billing, refund]
+    ticket_summary_prompt: summarize the billing issue
+    summary_fields:
+      - name: refund_requested
+        type: boolean
+        description: whether the customer asked for a refund
+  - name: enterprise
+    match:
+      organization_ids: [42]
+    org_summary_prompt: summarize the account health
+`)
+
+	routes, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []config.PromptRoute{
+		{
+			Name:                "billing",
+			Match:               config.RouteMatch{BrandIDs: []int64{1}, Tags: []string{"billing", "refund"}},
+			TicketSummaryPrompt: "summarize the billing issue",
+			SummaryFields: []config.SummaryField{
+				{Name: "refund_requested", Type: "boolean", Description: "whether the customer asked for a refund"},
+			},
+		},
+		{
+			Name:             "enterprise",
+			Match:            config.RouteMatch{OrganizationIDs: []int64{42}},
+			OrgSummaryPrompt: "summarize the account health",
+		},
+	}, routes)
+
+	_, err = Load(writeRoutes(t, "routes:\n  - name: billing\n    prompt: typo\n"))
+	assert.ErrorContains(t, err, "failed to parse prompt routes")
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorContains(t, err, "failed to read prompt routes")
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		routes   []config.PromptRoute
+		expected string
+	}{
+		{
+			name:     "Missing Name",
+			routes:   []config.PromptRoute{{TicketSummaryPrompt: "p"}},
+			expected: "prompt route 1: name is required",
+		},
+		{
+			name:     "Duplicate Name",
+			routes:   []config.PromptRoute{{Name: "a", TicketSummaryPrompt: "p"}, {Name: "a", TicketSummaryPrompt: "p"}},
+			expected: "duplicate name",
+		},
+		{
+			name:     "Missing Prompt",
+			routes:   []config.PromptRoute{{Name: "a"}},
+			expected: "ticket_summary_prompt or org_summary_prompt is required",
+		},
+		{
+			name: "Fields Without Ticket Prompt",
+			routes: []config.PromptRoute{{
+				Name:             "a",
+				Match:            config.RouteMatch{OrganizationIDs: []int64{1}},
+				OrgSummaryPrompt: "p",
+				SummaryFields:    []config.SummaryField{{Name: "f", Type: "string"}},
+			}},
+			expected: "summary_fields require a ticket_summary_prompt",
+		},
+		{
+			name:     "Organization Prompt Routed By Tags",
+			routes:   []config.PromptRoute{{Name: "a", Match: config.RouteMatch{Tags: []string{"vip"}}, OrgSummaryPrompt: "p"}},
+			expected: "org_summary_prompt can only be routed by organization_ids",
+		},
+		{
+			name:     "Reserved Field",
+			routes:   []config.PromptRoute{{Name: "a", TicketSummaryPrompt: "p", SummaryFields: []config.SummaryField{{Name: "summary", Type: "string"}}}},
+			expected: `summary field "summary" is always included`,
+		},
+		{
+			name:     "Unknown Field Type",
+			routes:   []config.PromptRoute{{Name: "a", TicketSummaryPrompt: "p", SummaryFields: []config.SummaryField{{Name: "f", Type: "date"}}}},
+			expected: `summary field "f": type must be one of`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorContains(t, Validate(tt.routes), tt.expected)
+		})
+	}
+}
+
+func TestForTicket(t *testing.T) {
+	routes := []config.PromptRoute{
+		{Name: "enterprise-org", Match: config.RouteMatch{OrganizationIDs: []int64{42}}, OrgSummaryPrompt: "org"},
+		{Name: "vip-billing", Match: config.RouteMatch{Tags: []string{"billing"}, CustomFields: map[string]string{"plan": "enterprise"}}, TicketSummaryPrompt: "vip"},
+		{Name: "billing", Match: config.RouteMatch{Tags: []string{"billing", "refund"}}, TicketSummaryPrompt: "billing"},
+		{Name: "incidents", Match: config.RouteMatch{BrandIDs: []int64{1}, Types: []string{"incident"}}, TicketSummaryPrompt: "incident"},
+	}
+
+	tests := []struct {
+		name     string
+		ticket   Ticket
+		expected string
+	}{
+		{
+			name:     "First Match Wins",
+			ticket:   Ticket{Tags: []string{"billing"}, CustomFields: map[string]string{"plan": "enterprise"}},
+			expected: "vip-billing",
+		},
+		{
+			name:     "Any Tag Matches",
+			ticket:   Ticket{Tags: []string{"refund"}, CustomFields: map[string]string{"plan": "free"}},
+			expected: "billing",
+		},
+		{
+			name:     "Type Is Case Insensitive",
+			ticket:   Ticket{BrandID: 1, Type: "Incident"},
+			expected: "incidents",
+		},
+		{
+			name:   "All Criteria Must Match",
+			ticket: Ticket{BrandID: 2, Type: "incident"},
+		},
+		{
+			name:   "Organization Routes Are Skipped",
+			ticket: Ticket{OrganizationID: 42},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, ok := ForTicket(routes, tt.ticket)
+			assert.Equal(t, tt.expected != "", ok)
+			assert.Equal(t, tt.expected, r.Name)
+		})
+	}
+}
+
+func TestForOrganization(t *testing.T) {
+	routes := []config.PromptRoute{
+		{Name: "billing", Match: config.RouteMatch{Tags: []string{"billing"}}, TicketSummaryPrompt: "billing"},
+		{Name: "enterprise", Match: config.RouteMatch{OrganizationIDs: []int64{42, 43}}, OrgSummaryPrompt: "org"},
+	}
+
+	r, ok := ForOrganization(routes, 43)
+	assert.True(t, ok)
+	assert.Equal(t, "enterprise", r.Name)
+
+	_, ok = ForOrganization(routes, 1)
+	assert.False(t, ok)
+}
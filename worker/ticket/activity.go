@@ -1,7 +1,10 @@
 package ticket
 
 import (
+	"sync"
+
 	"github.com/taonic/ticketfu/genai"
+	"github.com/taonic/ticketfu/worker/related"
 	"github.com/taonic/ticketfu/zendesk"
 	"go.temporal.io/sdk/client"
 )
@@ -10,12 +13,19 @@ type Activity struct {
 	tClient client.Client
 	zClient zendesk.Client
 	genAPI  genai.API
+	index   *related.Index
+
+	// Comment authors looked up so far, shared by the batches of a thread
+	// and the tickets the worker summarizes
+	authorsMu sync.Mutex
+	authors   map[int64]Author
 }
 
-func NewActivity(tClient client.Client, zClient zendesk.Client, genAPI genai.API) *Activity {
+func NewActivity(tClient client.Client, zClient zendesk.Client, genAPI genai.API, index *related.Index) *Activity {
 	return &Activity{
 		tClient: tClient,
 		zClient: zClient,
 		genAPI:  genAPI,
+		index:   index,
 	}
 }
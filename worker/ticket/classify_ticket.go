@@ -0,0 +1,81 @@
+package ticket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/taonic/ticketfu/config"
+	"github.com/taonic/ticketfu/genai"
+	"go.temporal.io/sdk/activity"
+)
+
+type (
+	ClassifyTicketInput struct {
+		Ticket   Ticket
+		Taxonomy []config.TaxonomyField
+	}
+
+	ClassifyTicketOutput struct {
+		// Taxonomy value keyed by taxonomy field name
+		Classification map[string]string
+	}
+)
+
+func (a *Activity) ClassifyTicket(ctx context.Context, input ClassifyTicketInput) (*ClassifyTicketOutput, error) {
+	cfg := a.genAPI.GetConfig()
+	taxonomyJSON, err := json.Marshal(input.Taxonomy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal taxonomy to JSON: %w", err)
+	}
+
+	// The taxonomy is budgeted along with the prompt
+	ticket, dropped, err := fitComments(genai.NewBudget(cfg), cfg.ClassifyPrompt+string(taxonomyJSON), cleanse(input.Ticket))
+	if err != nil {
+		return nil, err
+	}
+	if dropped > 0 {
+		activity.GetLogger(ctx).Debug("Dropped older comments over the context budget", "ticket-id", input.Ticket.ID, "dropped", dropped)
+	}
+
+	content, err := json.Marshal(struct {
+		Taxonomy []config.TaxonomyField `json:"taxonomy"`
+		Ticket   Ticket                 `json:"ticket"`
+	}{input.Taxonomy, ticket})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ticket to JSON: %w", err)
+	}
+
+	result, err := a.genAPI.GenerateContent(ctx, cfg.ClassifyPrompt, string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate %w", err)
+	}
+
+	var suggested map[string]string
+	if err := json.Unmarshal([]byte(trimCodeFence(result)), &suggested); err != nil {
+		return nil, fmt.Errorf("failed to parse classification: %w", err)
+	}
+
+	// Only keep values from the taxonomy, normalised to their configured spelling
+	classification := make(map[string]string)
+	for _, field := range input.Taxonomy {
+		value, ok := matchTaxonomyValue(field, suggested[field.Name])
+		if !ok {
+			activity.GetLogger(ctx).Debug("Dropping classification outside the taxonomy", "field", field.Name, "value", suggested[field.Name])
+			continue
+		}
+		classification[field.Name] = value
+	}
+
+	return &ClassifyTicketOutput{Classification: classification}, nil
+}
+
+func matchTaxonomyValue(field config.TaxonomyField, value string) (string, bool) {
+	for _, v := range field.Values {
+		if strings.EqualFold(v, strings.TrimSpace(value)) {
+			return v, true
+		}
+	}
+	return "", false
+}
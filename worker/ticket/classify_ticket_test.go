@@ -0,0 +1,91 @@
+package ticket
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/taonic/ticketfu/config"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestClassifyTicket(t *testing.T) {
+	testSuite := testsuite.WorkflowTestSuite{}
+	testEnv := testSuite.NewTestActivityEnvironment()
+
+	ticket := Ticket{ID: 12345, Subject: "Invoice charged twice"}
+	taxonomy := []config.TaxonomyField{
+		{Name: "product_area", Values: []string{"Billing", "API"}},
+		{Name: "severity", Values: []string{"low", "high"}, FieldID: 360001},
+	}
+
+	testCases := []struct {
+		name           string
+		setupMock      func(*MockGenAIAPI)
+		expectedOutput map[string]string
+		expectedError  string
+	}{
+		{
+			name: "Successful Classification",
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{ClassifyPrompt: "classify"})
+				m.On("GenerateContent", mock.Anything, "classify", mock.MatchedBy(func(content string) bool {
+					return strings.Contains(content, `"product_area"`) && strings.Contains(content, "Invoice charged twice")
+				})).Return("```json\n{\"product_area\": \"billing\", \"severity\": \"high\"}\n```", nil)
+			},
+			expectedOutput: map[string]string{"product_area": "Billing", "severity": "high"},
+		},
+		{
+			name: "Values Outside Taxonomy",
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{ClassifyPrompt: "classify"})
+				m.On("GenerateContent", mock.Anything, mock.Anything, mock.Anything).
+					Return(`{"product_area": "Hardware", "severity": "low", "team": "core"}`, nil)
+			},
+			expectedOutput: map[string]string{"severity": "low"},
+		},
+		{
+			name: "Invalid JSON",
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{ClassifyPrompt: "classify"})
+				m.On("GenerateContent", mock.Anything, mock.Anything, mock.Anything).Return("not json", nil)
+			},
+			expectedError: "failed to parse classification",
+		},
+		{
+			name: "Generation API Error",
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{ClassifyPrompt: "classify"})
+				m.On("GenerateContent", mock.Anything, mock.Anything, mock.Anything).Return("", errors.New("API failure"))
+			},
+			expectedError: "failed to generate",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockAPI := new(MockGenAIAPI)
+			tc.setupMock(mockAPI)
+
+			activity := &Activity{genAPI: mockAPI}
+			testEnv.RegisterActivity(activity.ClassifyTicket)
+
+			future, err := testEnv.ExecuteActivity(activity.ClassifyTicket, ClassifyTicketInput{Ticket: ticket, Taxonomy: taxonomy})
+
+			if tc.expectedError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedError)
+			} else {
+				require.NoError(t, err)
+				var output ClassifyTicketOutput
+				require.NoError(t, future.Get(&output))
+				assert.Equal(t, tc.expectedOutput, output.Classification)
+			}
+
+			mockAPI.AssertExpectations(t)
+		})
+	}
+}
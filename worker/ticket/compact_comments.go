@@ -0,0 +1,60 @@
+package ticket
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/taonic/ticketfu/genai"
+	"go.temporal.io/sdk/temporal"
+)
+
+type (
+	CompactCommentsInput struct {
+		// Digest produced by the previous compaction, if any
+		Digest   string
+		Comments []Comment
+	}
+
+	CompactCommentsOutput struct {
+		Digest string
+	}
+)
+
+// CompactComments folds older comments into a rolling history digest so the
+// thread stays within the context budget without losing the earlier conversation.
+// Comments too long to fold in at once are cut to fit the budget.
+func (a *Activity) CompactComments(ctx context.Context, input CompactCommentsInput) (*CompactCommentsOutput, error) {
+	cfg := a.genAPI.GetConfig()
+	inputJSON, err := fitCompaction(genai.NewBudget(cfg), cfg.CommentDigestPrompt, input)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := a.genAPI.GenerateContent(ctx, cfg.CommentDigestPrompt, inputJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate %w", err)
+	}
+
+	return &CompactCommentsOutput{Digest: result}, nil
+}
+
+// fitCompaction returns the compaction as JSON, halving its longest comment
+// or the digest until it fits the budget beside the prompt
+func fitCompaction(budget genai.Budget, prompt string, input CompactCommentsInput) (string, error) {
+	input.Comments = slices.Clone(input.Comments)
+
+	texts := []*string{&input.Digest}
+	for i := range input.Comments {
+		texts = append(texts, &input.Comments[i].Body)
+	}
+
+	inputJSON, err := fitHalving(budget, prompt, &input, texts)
+	if err != nil {
+		return "", err
+	}
+	if inputJSON == "" {
+		return "", temporal.NewNonRetryableApplicationError("comments don't fit the context budget", "OverContextBudget", nil)
+	}
+	return inputJSON, nil
+}
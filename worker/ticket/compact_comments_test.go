@@ -0,0 +1,107 @@
+package ticket
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/taonic/ticketfu/config"
+	"github.com/taonic/ticketfu/genai"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestCompactComments(t *testing.T) {
+	testSuite := testsuite.WorkflowTestSuite{}
+	testEnv := testSuite.NewTestActivityEnvironment()
+
+	testCases := []struct {
+		name           string
+		input          *CompactCommentsInput
+		setupMock      func(*MockGenAIAPI)
+		expectedDigest string
+		expectedError  string
+	}{
+		{
+			name: "Successful Compaction",
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{CommentDigestPrompt: "digest"})
+				m.On("GenerateContent", mock.Anything, "digest", mock.MatchedBy(func(content string) bool {
+					return strings.Contains(content, "Previous digest") && strings.Contains(content, "Comment 1")
+				})).Return("New digest", nil)
+			},
+			expectedDigest: "New digest",
+		},
+		{
+			name: "Long Comment Cut To The Budget",
+			input: &CompactCommentsInput{
+				Digest:   "Previous digest",
+				Comments: []Comment{{Body: strings.Repeat("a", 10_000)}, {Body: "Comment 2"}},
+			},
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{
+					CommentDigestPrompt: "digest",
+					LLMProvider:         genai.Anthropic,
+					ContextBudgets:      map[string]config.ContextBudget{genai.Anthropic: {ContextWindow: 1_000, OutputReserve: 100}},
+				})
+				m.On("GenerateContent", mock.Anything, "digest", mock.MatchedBy(func(content string) bool {
+					return genai.EstimateTokens(content) <= 900 &&
+						strings.Contains(content, "Previous digest") && strings.Contains(content, "Comment 2")
+				})).Return("New digest", nil)
+			},
+			expectedDigest: "New digest",
+		},
+		{
+			name: "Over The Budget",
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{
+					CommentDigestPrompt: "digest",
+					LLMProvider:         genai.Anthropic,
+					ContextBudgets:      map[string]config.ContextBudget{genai.Anthropic: {ContextWindow: 10, OutputReserve: 5}},
+				})
+			},
+			expectedError: "comments don't fit the context budget",
+		},
+		{
+			name: "Generation API Error",
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{CommentDigestPrompt: "digest"})
+				m.On("GenerateContent", mock.Anything, mock.Anything, mock.Anything).Return("", errors.New("API failure"))
+			},
+			expectedError: "failed to generate",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockAPI := new(MockGenAIAPI)
+			tc.setupMock(mockAPI)
+
+			activity := &Activity{genAPI: mockAPI}
+			testEnv.RegisterActivity(activity.CompactComments)
+
+			input := CompactCommentsInput{
+				Digest:   "Previous digest",
+				Comments: []Comment{{Body: "Comment 1"}, {Body: "Comment 2"}},
+			}
+			if tc.input != nil {
+				input = *tc.input
+			}
+			future, err := testEnv.ExecuteActivity(activity.CompactComments, input)
+
+			if tc.expectedError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedError)
+			} else {
+				require.NoError(t, err)
+				var output CompactCommentsOutput
+				require.NoError(t, future.Get(&output))
+				assert.Equal(t, tc.expectedDigest, output.Digest)
+			}
+
+			mockAPI.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package ticket
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/taonic/ticketfu/config"
+)
+
+const (
+	EventStatusChanged       = "status_changed"
+	EventPriorityChanged     = "priority_changed"
+	EventSubjectChanged      = "subject_changed"
+	EventAssigneeChanged     = "assignee_changed"
+	EventOrganizationChanged = "organization_changed"
+	EventEscalated           = "escalated"
+
+	// Summary triggers besides ticket events
+	TriggerNewComments = "new_comments"
+	TriggerRefresh     = "refresh"
+
+	// MaxTicketEvents is the number of most recent events kept on a ticket
+	MaxTicketEvents = 100
+)
+
+// TicketEvent records a metadata change detected between two fetches of a ticket
+type TicketEvent struct {
+	Type string    `json:"type"`
+	From string    `json:"from"`
+	To   string    `json:"to"`
+	At   time.Time `json:"at"`
+}
+
+// diffTicket compares the metadata of two versions of a ticket and returns
+// an event for each detected change.
+func diffTicket(prev, curr Ticket, at time.Time) []TicketEvent {
+	var events []TicketEvent
+
+	add := func(eventType, from, to string) {
+		events = append(events, TicketEvent{Type: eventType, From: from, To: to, At: at})
+	}
+
+	if prev.Status != curr.Status {
+		add(EventStatusChanged, prev.Status, curr.Status)
+	}
+	if prev.Priority != curr.Priority {
+		add(EventPriorityChanged, prev.Priority, curr.Priority)
+	}
+	if prev.Subject != curr.Subject {
+		add(EventSubjectChanged, prev.Subject, curr.Subject)
+	}
+	if prev.AssigneeID != curr.AssigneeID {
+		add(EventAssigneeChanged, prev.Assignee, curr.Assignee)
+	}
+	if prev.OrganizationID != curr.OrganizationID {
+		add(EventOrganizationChanged, orgLabel(prev), orgLabel(curr))
+	}
+
+	return events
+}
+
+func orgLabel(ticket Ticket) string {
+	if ticket.OrganizationID == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s (#%d)", ticket.OrganizationName, ticket.OrganizationID)
+}
+
+// appendEvents appends events while keeping up to MaxTicketEvents of the most recent ones
+func appendEvents(events []TicketEvent, newEvents ...TicketEvent) []TicketEvent {
+	events = append(events, newEvents...)
+	if len(events) > MaxTicketEvents {
+		events = events[len(events)-MaxTicketEvents:]
+	}
+	return events
+}
+
+// summaryTrigger describes what caused the summary to be regenerated, e.g.
+// "status_changed, new_comments"
+func summaryTrigger(events []TicketEvent, newComments int) string {
+	var triggers []string
+	for _, event := range events {
+		if !slices.Contains(triggers, event.Type) {
+			triggers = append(triggers, event.Type)
+		}
+	}
+	if newComments > 0 {
+		triggers = append(triggers, TriggerNewComments)
+	}
+	if len(triggers) == 0 {
+		return TriggerRefresh
+	}
+	return strings.Join(triggers, ", ")
+}
+
+// escalationReason describes the thresholds newly crossed by the current
+// scores, or returns an empty string if the previous scores had already
+// crossed them or none are crossed.
+func escalationReason(prev *Scores, curr Scores, cfg config.TicketWorkflowConfig) string {
+	negative := func(s Scores) bool { return s.Sentiment <= cfg.SentimentThreshold }
+	urgent := func(s Scores) bool { return s.Urgency >= cfg.UrgencyThreshold }
+
+	var reasons []string
+	if negative(curr) && (prev == nil || !negative(*prev)) {
+		reasons = append(reasons, fmt.Sprintf("sentiment %.2f is at or below %.2f", curr.Sentiment, cfg.SentimentThreshold))
+	}
+	if urgent(curr) && (prev == nil || !urgent(*prev)) {
+		reasons = append(reasons, fmt.Sprintf("urgency %.2f is at or above %.2f", curr.Urgency, cfg.UrgencyThreshold))
+	}
+
+	return strings.Join(reasons, "; ")
+}
@@ -0,0 +1,133 @@
+package ticket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/taonic/ticketfu/config"
+)
+
+func TestDiffTicket(t *testing.T) {
+	now := time.Now()
+	base := Ticket{
+		ID:               1,
+		Subject:          "Subject",
+		Status:           "open",
+		Priority:         "normal",
+		AssigneeID:       10,
+		Assignee:         "Agent A",
+		OrganizationID:   100,
+		OrganizationName: "Org A",
+	}
+
+	tests := []struct {
+		name     string
+		update   func(*Ticket)
+		expected []TicketEvent
+	}{
+		{
+			name:     "No changes",
+			update:   func(*Ticket) {},
+			expected: nil,
+		},
+		{
+			name: "Status transition",
+			update: func(t *Ticket) {
+				t.Status = "pending"
+			},
+			expected: []TicketEvent{{Type: EventStatusChanged, From: "open", To: "pending", At: now}},
+		},
+		{
+			name: "Reassignment",
+			update: func(t *Ticket) {
+				t.AssigneeID = 11
+				t.Assignee = "Agent B"
+			},
+			expected: []TicketEvent{{Type: EventAssigneeChanged, From: "Agent A", To: "Agent B", At: now}},
+		},
+		{
+			name: "Organization move",
+			update: func(t *Ticket) {
+				t.OrganizationID = 200
+				t.OrganizationName = "Org B"
+			},
+			expected: []TicketEvent{{Type: EventOrganizationChanged, From: "Org A (#100)", To: "Org B (#200)", At: now}},
+		},
+		{
+			name: "Multiple changes",
+			update: func(t *Ticket) {
+				t.Priority = "urgent"
+				t.Subject = "New subject"
+			},
+			expected: []TicketEvent{
+				{Type: EventPriorityChanged, From: "normal", To: "urgent", At: now},
+				{Type: EventSubjectChanged, From: "Subject", To: "New subject", At: now},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			curr := base
+			tt.update(&curr)
+			assert.Equal(t, tt.expected, diffTicket(base, curr, now))
+		})
+	}
+}
+
+func TestAppendEvents(t *testing.T) {
+	events := make([]TicketEvent, MaxTicketEvents)
+	events = appendEvents(events, TicketEvent{Type: EventStatusChanged})
+
+	assert.Len(t, events, MaxTicketEvents)
+	assert.Equal(t, EventStatusChanged, events[len(events)-1].Type)
+}
+
+func TestEscalationReason(t *testing.T) {
+	cfg := config.TicketWorkflowConfig{SentimentThreshold: -0.5, UrgencyThreshold: 0.8}
+	calm := Scores{Sentiment: 0.2, Urgency: 0.3}
+	angry := Scores{Sentiment: -0.7, Urgency: 0.3}
+
+	tests := []struct {
+		name     string
+		prev     *Scores
+		curr     Scores
+		expected string
+	}{
+		{
+			name:     "First score below thresholds",
+			curr:     calm,
+			expected: "",
+		},
+		{
+			name:     "First score crosses sentiment",
+			curr:     angry,
+			expected: "sentiment -0.70 is at or below -0.50",
+		},
+		{
+			name:     "Newly crosses both",
+			prev:     &calm,
+			curr:     Scores{Sentiment: -0.5, Urgency: 0.9},
+			expected: "sentiment -0.50 is at or below -0.50; urgency 0.90 is at or above 0.80",
+		},
+		{
+			name:     "Already escalated",
+			prev:     &angry,
+			curr:     Scores{Sentiment: -0.9, Urgency: 0.3},
+			expected: "",
+		},
+		{
+			name:     "Already negative, newly urgent",
+			prev:     &angry,
+			curr:     Scores{Sentiment: -0.9, Urgency: 0.8},
+			expected: "urgency 0.80 is at or above 0.80",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, escalationReason(tt.prev, tt.curr, cfg))
+		})
+	}
+}
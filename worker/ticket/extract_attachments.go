@@ -0,0 +1,298 @@
+package ticket
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/taonic/ticketfu/zendesk"
+	"go.temporal.io/sdk/activity"
+)
+
+const (
+	// MaxAttachmentBytes is the largest attachment downloaded, and the largest
+	// file read from a zip archive
+	MaxAttachmentBytes = 5 * 1024 * 1024
+	// MaxSnippetBytes bounds the text kept from each file
+	MaxSnippetBytes = 8 * 1024
+	// MaxAttachmentSnippets bounds the snippets kept on the ticket, dropping the oldest
+	MaxAttachmentSnippets = 20
+	// MaxArchiveFiles bounds the text files read from a zip archive
+	MaxArchiveFiles = 50
+	// MaxArchiveBytes bounds the bytes decompressed from a zip archive
+	MaxArchiveBytes = 4 * MaxAttachmentBytes
+
+	// snippetContextLines is how many lines are kept around each relevant line
+	snippetContextLines = 3
+)
+
+var (
+	textExtensions = map[string]bool{
+		".txt": true, ".log": true, ".out": true, ".json": true, ".ndjson": true,
+		".yaml": true, ".yml": true, ".csv": true, ".conf": true, ".cfg": true,
+		".ini": true, ".toml": true, ".properties": true, ".env": true, ".trace": true,
+	}
+
+	textContentTypes = map[string]bool{
+		"application/json":     true,
+		"application/x-ndjson": true,
+		"application/yaml":     true,
+		"application/x-yaml":   true,
+		"application/csv":      true,
+	}
+
+	// relevantLine matches lines worth keeping from long logs and traces
+	relevantLine = regexp.MustCompile(`(?i)\b(error|exception|fatal|panic|fail(ed|ure)?|traceback|caused by|timeout|timed out|denied|refused|warn(ing)?)\b`)
+)
+
+type (
+	// AttachmentSnippet is the relevant text extracted from a text-like attachment
+	AttachmentSnippet struct {
+		AttachmentID int64
+		FileName     string // Files inside a zip are named "<archive>/<file>"
+		Text         string
+	}
+
+	ExtractAttachmentsInput struct {
+		Attachments []Attachment
+	}
+
+	ExtractAttachmentsOutput struct {
+		Snippets []AttachmentSnippet
+	}
+)
+
+// ExtractAttachments downloads text-like attachments, including zipped logs,
+// and extracts the snippets relevant to summarizing the ticket. Attachments
+// that fail to download or don't hold text are skipped. Only the latest
+// MaxAttachmentSnippets snippets are returned.
+func (a *Activity) ExtractAttachments(ctx context.Context, input ExtractAttachmentsInput) (*ExtractAttachmentsOutput, error) {
+	logger := activity.GetLogger(ctx)
+
+	var snippets []AttachmentSnippet
+	for n, attachment := range input.Attachments {
+		activity.RecordHeartbeat(ctx, n)
+
+		if !isTextAttachment(attachment) && !isZipAttachment(attachment) {
+			continue
+		}
+		if attachment.Size > MaxAttachmentBytes {
+			logger.Debug("Skipping large attachment", "attachment-id", attachment.ID, "size", attachment.Size)
+			continue
+		}
+
+		content, err := a.zClient.DownloadAttachment(ctx, attachment.ContentURL, MaxAttachmentBytes)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if !errors.Is(err, zendesk.ErrAttachmentTooLarge) {
+				logger.Warn("Failed to download attachment", "attachment-id", attachment.ID, "error", err)
+			}
+			continue
+		}
+
+		if isZipAttachment(attachment) {
+			snippets = append(snippets, extractZip(attachment, content)...)
+			continue
+		}
+
+		if text, ok := extractSnippet(content); ok {
+			snippets = append(snippets, AttachmentSnippet{
+				AttachmentID: attachment.ID,
+				FileName:     attachment.FileName,
+				Text:         text,
+			})
+		}
+	}
+
+	if len(snippets) > MaxAttachmentSnippets {
+		snippets = snippets[len(snippets)-MaxAttachmentSnippets:]
+	}
+
+	return &ExtractAttachmentsOutput{Snippets: snippets}, nil
+}
+
+// textAttachments returns the attachments on comments that ExtractAttachments
+// can read
+func textAttachments(comments []Comment) []Attachment {
+	var attachments []Attachment
+	for _, comment := range comments {
+		for _, attachment := range comment.Attachments {
+			if isTextAttachment(attachment) || isZipAttachment(attachment) {
+				attachments = append(attachments, attachment)
+			}
+		}
+	}
+	return attachments
+}
+
+func isTextAttachment(attachment Attachment) bool {
+	contentType, _, _ := strings.Cut(attachment.ContentType, ";")
+	contentType = strings.TrimSpace(strings.ToLower(contentType))
+	if strings.HasPrefix(contentType, "text/") || textContentTypes[contentType] {
+		return true
+	}
+	return isTextFile(attachment.FileName)
+}
+
+func isZipAttachment(attachment Attachment) bool {
+	return strings.EqualFold(path.Ext(attachment.FileName), ".zip")
+}
+
+func isTextFile(name string) bool {
+	return textExtensions[strings.ToLower(path.Ext(name))]
+}
+
+// extractZip extracts snippets from the text files in a zip archive, reading
+// at most MaxArchiveFiles files and MaxArchiveBytes bytes
+func extractZip(attachment Attachment, content []byte) []AttachmentSnippet {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil
+	}
+
+	var snippets []AttachmentSnippet
+	files, remaining := 0, MaxArchiveBytes
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() || !isTextFile(file.Name) || file.UncompressedSize64 > MaxAttachmentBytes {
+			continue
+		}
+		if files == MaxArchiveFiles || remaining == 0 {
+			break
+		}
+		files++
+
+		text, err := readZipFile(file, min(remaining, MaxAttachmentBytes))
+		remaining = max(0, remaining-len(text))
+		if err != nil {
+			continue
+		}
+
+		if snippet, ok := extractSnippet(text); ok {
+			snippets = append(snippets, AttachmentSnippet{
+				AttachmentID: attachment.ID,
+				FileName:     attachment.FileName + "/" + file.Name,
+				Text:         snippet,
+			})
+		}
+	}
+
+	return snippets
+}
+
+// readZipFile decompresses a file of at most limit bytes. What was read is
+// returned along with the error when the file is larger.
+func readZipFile(file *zip.File, limit int) ([]byte, error) {
+	r, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	// The header's size can't be trusted, so cap the read as well
+	content, err := io.ReadAll(io.LimitReader(r, int64(limit)+1))
+	if err != nil {
+		return content, err
+	}
+	if len(content) > limit {
+		return content, fmt.Errorf("%s exceeds the size limit", file.Name)
+	}
+	return content, nil
+}
+
+// extractSnippet returns the text worth summarizing. Short files are kept
+// whole. Longer ones are cut down to the lines around errors and failures,
+// falling back to the start and end of the file when there are none.
+func extractSnippet(content []byte) (string, bool) {
+	if len(content) == 0 || bytes.IndexByte(content, 0) >= 0 || !utf8.Valid(content) {
+		return "", false
+	}
+
+	text := strings.TrimSpace(string(content))
+	if text == "" {
+		return "", false
+	}
+	if len(text) <= MaxSnippetBytes {
+		return text, true
+	}
+
+	lines := strings.Split(text, "\n")
+	keep := make([]bool, len(lines))
+	var matched bool
+	for i, line := range lines {
+		if relevantLine.MatchString(line) {
+			matched = true
+			for j := max(0, i-snippetContextLines); j <= min(len(lines)-1, i+snippetContextLines); j++ {
+				keep[j] = true
+			}
+		}
+	}
+
+	if !matched {
+		head := truncateUTF8(text, MaxSnippetBytes/2)
+		tail := truncateUTF8Tail(text, MaxSnippetBytes/2)
+		return head + "\n...\n" + tail, true
+	}
+
+	// Elided lines are marked with "...", leaving room for the last marker
+	var b strings.Builder
+	var skipped bool
+	for i, line := range lines {
+		if !keep[i] {
+			skipped = true
+			continue
+		}
+		if skipped {
+			b.WriteString("...\n")
+			skipped = false
+		}
+		// a line over the budget, e.g. minified JSON, is cut short rather than dropped
+		if room := MaxSnippetBytes - b.Len() - len("\n..."); len(line) > room {
+			if room > 0 {
+				b.WriteString(truncateUTF8(line, room))
+				b.WriteString("\n")
+			}
+			skipped = true
+			break
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	if skipped {
+		b.WriteString("...")
+	}
+
+	return strings.TrimSpace(b.String()), true
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a character
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// truncateUTF8Tail keeps at most the last n bytes of s without splitting a
+// character
+func truncateUTF8Tail(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	start := len(s) - n
+	for start < len(s) && !utf8.RuneStart(s[start]) {
+		start++
+	}
+	return s[start:]
+}
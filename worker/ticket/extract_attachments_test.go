@@ -0,0 +1,182 @@
+package ticket
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	zd "github.com/taonic/ticketfu/zendesk"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestExtractAttachments(t *testing.T) {
+	testSuite := testsuite.WorkflowTestSuite{}
+	testEnv := testSuite.NewTestActivityEnvironment()
+
+	// More snippets than the ticket keeps
+	var logs []Attachment
+	var latestSnippets []AttachmentSnippet
+	for id := int64(1); id <= MaxAttachmentSnippets+5; id++ {
+		logs = append(logs, Attachment{ID: id, FileName: "app.log", ContentType: "text/plain", ContentURL: fmt.Sprintf("https://example.zendesk.com/logs/%d", id)})
+		if id > 5 {
+			latestSnippets = append(latestSnippets, AttachmentSnippet{AttachmentID: id, FileName: "app.log", Text: "timeout"})
+		}
+	}
+
+	testCases := []struct {
+		name             string
+		attachments      []Attachment
+		setupMock        func(*zd.MockZendeskClient)
+		expectedSnippets []AttachmentSnippet
+	}{
+		{
+			name: "Text Attachments",
+			attachments: []Attachment{
+				{ID: 1, FileName: "config.yaml", ContentType: "application/x-yaml", ContentURL: "https://example.zendesk.com/1"},
+				{ID: 2, FileName: "output", ContentType: "text/plain; charset=utf-8", ContentURL: "https://example.zendesk.com/2"},
+			},
+			setupMock: func(m *zd.MockZendeskClient) {
+				m.On("DownloadAttachment", mock.Anything, "https://example.zendesk.com/1", int64(MaxAttachmentBytes)).
+					Return([]byte("retries: 3\ntimeout: 5s\n"), nil).Once()
+				m.On("DownloadAttachment", mock.Anything, "https://example.zendesk.com/2", int64(MaxAttachmentBytes)).
+					Return([]byte("connection refused"), nil).Once()
+			},
+			expectedSnippets: []AttachmentSnippet{
+				{AttachmentID: 1, FileName: "config.yaml", Text: "retries: 3\ntimeout: 5s"},
+				{AttachmentID: 2, FileName: "output", Text: "connection refused"},
+			},
+		},
+		{
+			name: "Zipped Logs",
+			attachments: []Attachment{
+				{ID: 3, FileName: "logs.zip", ContentType: "application/zip", ContentURL: "https://example.zendesk.com/3"},
+			},
+			setupMock: func(m *zd.MockZendeskClient) {
+				m.On("DownloadAttachment", mock.Anything, "https://example.zendesk.com/3", int64(MaxAttachmentBytes)).
+					Return(zipArchive(t, map[string]string{
+						"server.log": "panic: nil pointer dereference",
+						"image.png":  "not text",
+					}), nil).Once()
+			},
+			expectedSnippets: []AttachmentSnippet{
+				{AttachmentID: 3, FileName: "logs.zip/server.log", Text: "panic: nil pointer dereference"},
+			},
+		},
+		{
+			name: "Skipped Attachments",
+			attachments: []Attachment{
+				{ID: 4, FileName: "screenshot.png", ContentType: "image/png", ContentURL: "https://example.zendesk.com/4"},
+				{ID: 5, FileName: "huge.log", ContentType: "text/plain", ContentURL: "https://example.zendesk.com/5", Size: MaxAttachmentBytes + 1},
+				{ID: 6, FileName: "binary.log", ContentType: "text/plain", ContentURL: "https://example.zendesk.com/6"},
+				{ID: 7, FileName: "gone.log", ContentType: "text/plain", ContentURL: "https://example.zendesk.com/7"},
+			},
+			setupMock: func(m *zd.MockZendeskClient) {
+				m.On("DownloadAttachment", mock.Anything, "https://example.zendesk.com/6", int64(MaxAttachmentBytes)).
+					Return([]byte("\x00\x01\x02"), nil).Once()
+				m.On("DownloadAttachment", mock.Anything, "https://example.zendesk.com/7", int64(MaxAttachmentBytes)).
+					Return(nil, errors.New("failed to download attachment: 404 Not Found")).Once()
+			},
+		},
+		{
+			name:        "Latest Snippets",
+			attachments: logs,
+			setupMock: func(m *zd.MockZendeskClient) {
+				for _, attachment := range logs {
+					m.On("DownloadAttachment", mock.Anything, attachment.ContentURL, int64(MaxAttachmentBytes)).
+						Return([]byte("timeout"), nil).Once()
+				}
+			},
+			expectedSnippets: latestSnippets,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockClient := new(zd.MockZendeskClient)
+			tc.setupMock(mockClient)
+
+			activity := &Activity{zClient: mockClient}
+			testEnv.RegisterActivity(activity.ExtractAttachments)
+
+			result, err := testEnv.ExecuteActivity(activity.ExtractAttachments, ExtractAttachmentsInput{Attachments: tc.attachments})
+			require.NoError(t, err)
+
+			var output ExtractAttachmentsOutput
+			require.NoError(t, result.Get(&output))
+			assert.Equal(t, tc.expectedSnippets, output.Snippets)
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestExtractZipLimits(t *testing.T) {
+	attachment := Attachment{ID: 1, FileName: "logs.zip"}
+
+	files := make(map[string]string)
+	for i := range MaxArchiveFiles + 10 {
+		files[fmt.Sprintf("%d.log", i)] = "error"
+	}
+	assert.Len(t, extractZip(attachment, zipArchive(t, files)), MaxArchiveFiles)
+
+	// Large files stop being read once the archive's bytes are used up
+	large := strings.Repeat("error\n", MaxAttachmentBytes/len("error\n"))
+	files = make(map[string]string)
+	for i := range MaxArchiveBytes/len(large) + 2 {
+		files[fmt.Sprintf("%d.log", i)] = large
+	}
+	assert.Len(t, extractZip(attachment, zipArchive(t, files)), MaxArchiveBytes/len(large))
+}
+
+func TestExtractSnippet(t *testing.T) {
+	var log strings.Builder
+	for i := range 1000 {
+		fmt.Fprintf(&log, "INFO request %d served\n", i)
+		if i == 500 {
+			log.WriteString("ERROR upstream timed out\n")
+		}
+	}
+
+	snippet, ok := extractSnippet([]byte(log.String()))
+	require.True(t, ok)
+	assert.LessOrEqual(t, len(snippet), MaxSnippetBytes)
+	assert.Equal(t, "...\nINFO request 498 served\nINFO request 499 served\nINFO request 500 served\n"+
+		"ERROR upstream timed out\nINFO request 501 served\nINFO request 502 served\nINFO request 503 served\n...", snippet)
+
+	// Without relevant lines the start and end of the file are kept
+	plain := strings.Repeat("INFO all good\n", 2000)
+	snippet, ok = extractSnippet([]byte(plain))
+	require.True(t, ok)
+	assert.LessOrEqual(t, len(snippet), MaxSnippetBytes+len("\n...\n"))
+	assert.Contains(t, snippet, "\n...\n")
+
+	// A single line over the budget is cut short rather than dropped
+	ndjson := `{"level":"error","msg":"upstream timed out"}` + strings.Repeat(`,{"level":"info","msg":"served"}`, MaxSnippetBytes/10)
+	snippet, ok = extractSnippet([]byte(ndjson))
+	require.True(t, ok)
+	assert.LessOrEqual(t, len(snippet), MaxSnippetBytes)
+	assert.True(t, strings.HasPrefix(snippet, `{"level":"error","msg":"upstream timed out"}`))
+	assert.True(t, strings.HasSuffix(snippet, "\n..."))
+
+	_, ok = extractSnippet([]byte("  \n "))
+	assert.False(t, ok)
+}
+
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
@@ -3,15 +3,32 @@ package ticket
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strconv"
+	"strings"
 
 	gozendesk "github.com/nukosuke/go-zendesk/zendesk"
+	"github.com/taonic/ticketfu/zendesk"
 	"go.temporal.io/sdk/activity"
-	"go.temporal.io/sdk/temporal"
+	"golang.org/x/sync/errgroup"
 )
 
 const (
+	// MaxCommentsBytes bounds the comments fetched at once so the activity's
+	// result stays well below Temporal's payload size limit. Longer threads
+	// are fetched in batches, compacted in between. What reaches the LLM is
+	// budgeted in tokens when summarizing.
 	MaxCommentsBytes = 400 * 1024
+
+	// MaxAuthorLookups bounds the concurrent user lookups of a batch
+	MaxAuthorLookups = 10
+
+	// MaxCachedAuthors bounds the authors the activity keeps, starting over
+	// once exceeded
+	MaxCachedAuthors = 10_000
+
+	// UnknownAuthor names authors whose lookup failed
+	UnknownAuthor = "Unknown"
 )
 
 type (
@@ -21,8 +38,9 @@ type (
 	}
 
 	FetchCommentsOutput struct {
-		Comments   []string
+		Comments   []Comment
 		NextCursor string
+		HasMore    bool // Set when the batch filled up before the last page
 	}
 )
 
@@ -48,11 +66,14 @@ func (a *Activity) FetchComments(ctx context.Context, input FetchCommentsInput)
 		},
 	}
 
+	// Stop at the page filling up the batch, the workflow fetches the rest
+	var size int
+	var hasMore bool
 	for {
 		comments, meta, err := a.zClient.GetTicketCommentsCBP(ctx, &cpb)
 		if err != nil {
-			if zendeskErr, ok := err.(gozendesk.Error); ok && zendeskErr.Status() == 404 {
-				return nil, temporal.NewNonRetryableApplicationError("failed to find the ticket", "NotFound", err)
+			if goneErr := ticketGoneError(err); goneErr != nil {
+				return nil, goneErr
 			}
 			return nil, fmt.Errorf("failed to fetch comments: %w", err)
 		}
@@ -61,24 +82,123 @@ func (a *Activity) FetchComments(ctx context.Context, input FetchCommentsInput)
 		if !meta.HasMore {
 			break
 		}
-	}
 
-	comments := make([]string, len(rawComments))
-	for i, comment := range rawComments {
-		comments[i] = comment.PlainBody
+		for _, comment := range comments {
+			size += len(comment.PlainBody)
+		}
+		if size >= MaxCommentsBytes {
+			logger.Debug("Fetched a full batch of comments", "ticket-id", intID, "bytes", size)
+			hasMore = true
+			break
+		}
 	}
 
-	// Keep the total byte size of the comments at 100KiB to reduce context window
-	// todo: make it configurable
-	truncatedComments, truncated := truncateStringSlice(comments, MaxCommentsBytes)
-	if truncated {
-		logger.Debug("Truncated ticket summaries to the limit: ", MaxCommentsBytes)
+	// TicketFu's own summary notes aren't part of the conversation
+	rawComments = slices.DeleteFunc(rawComments, func(comment gozendesk.TicketComment) bool {
+		return isSummaryNote(comment)
+	})
+
+	authors := a.fetchAuthors(ctx, rawComments)
+
+	comments := make([]Comment, len(rawComments))
+	for i, comment := range rawComments {
+		comments[i] = newComment(comment, authors[comment.AuthorID])
 	}
 
 	response := FetchCommentsOutput{
-		Comments:   truncatedComments,
+		Comments:   comments,
 		NextCursor: cpb.CursorPagination.PageAfter,
+		HasMore:    hasMore,
 	}
 
 	return &response, nil
 }
+
+// fetchAuthors looks up each distinct comment author not cached yet. Authors
+// whose lookup fails get a placeholder without a role, so a deleted or
+// suspended user doesn't fail the batch, and are looked up again next time.
+func (a *Activity) fetchAuthors(ctx context.Context, comments []gozendesk.TicketComment) map[int64]Author {
+	logger := activity.GetLogger(ctx)
+
+	authors := make(map[int64]Author)
+	var missing []Author
+	a.authorsMu.Lock()
+	for _, comment := range comments {
+		if _, ok := authors[comment.AuthorID]; ok {
+			continue
+		}
+		author, ok := a.authors[comment.AuthorID]
+		if !ok {
+			author = Author{ID: comment.AuthorID, Name: UnknownAuthor}
+			missing = append(missing, author)
+		}
+		authors[comment.AuthorID] = author
+	}
+	a.authorsMu.Unlock()
+
+	fetched := make([]bool, len(missing))
+	var g errgroup.Group
+	g.SetLimit(MaxAuthorLookups)
+	for i := range missing {
+		g.Go(func() error {
+			user, err := a.zClient.GetUser(ctx, missing[i].ID)
+			if err != nil {
+				logger.Warn("Failed to fetch comment author", "author-id", missing[i].ID, "error", err)
+				return nil
+			}
+			missing[i].Name = user.Name
+			missing[i].Role = user.Role
+			fetched[i] = true
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	a.authorsMu.Lock()
+	defer a.authorsMu.Unlock()
+	if a.authors == nil || len(a.authors)+len(missing) > MaxCachedAuthors {
+		a.authors = make(map[int64]Author)
+	}
+	for i, author := range missing {
+		authors[author.ID] = author
+		if fetched[i] {
+			a.authors[author.ID] = author
+		}
+	}
+
+	return authors
+}
+
+func isSummaryNote(raw gozendesk.TicketComment) bool {
+	body := raw.PlainBody
+	if body == "" {
+		body = raw.Body
+	}
+	return strings.HasPrefix(strings.TrimSpace(body), zendesk.NoteMarker)
+}
+
+func newComment(raw gozendesk.TicketComment, author Author) Comment {
+	comment := Comment{
+		ID:        raw.ID,
+		Author:    author,
+		Body:      raw.PlainBody,
+		Public:    raw.Public == nil || *raw.Public,
+		CreatedAt: raw.CreatedAt,
+	}
+
+	if raw.Via != nil {
+		comment.Channel = raw.Via.Channel
+	}
+
+	for _, attachment := range raw.Attachments {
+		comment.Attachments = append(comment.Attachments, Attachment{
+			ID:          attachment.ID,
+			FileName:    attachment.FileName,
+			ContentType: attachment.ContentType,
+			ContentURL:  attachment.ContentURL,
+			Size:        attachment.Size,
+		})
+	}
+
+	return comment
+}
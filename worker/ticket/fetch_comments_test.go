@@ -3,6 +3,7 @@ package ticket
 import (
 	"errors"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/nukosuke/go-zendesk/zendesk"
@@ -18,6 +19,9 @@ func TestFetchCommentsWithActivityEnvironment(t *testing.T) {
 	testSuite := testsuite.WorkflowTestSuite{}
 	testEnv := testSuite.NewTestActivityEnvironment()
 
+	public, private := true, false
+	customer := Author{ID: 101, Name: "Customer", Role: "end-user"}
+
 	testCases := []struct {
 		name           string
 		ticketID       string
@@ -34,8 +38,8 @@ func TestFetchCommentsWithActivityEnvironment(t *testing.T) {
 			setupMock: func(m *zd.MockZendeskClient) {
 				// Set up the mock to return a single page of comments
 				comments := []zendesk.TicketComment{
-					{PlainBody: "First comment"},
-					{PlainBody: "Second comment"},
+					{ID: 1, AuthorID: 101, PlainBody: "First comment", Public: &public},
+					{ID: 2, AuthorID: 102, PlainBody: "Second comment", Public: &private, Via: &zendesk.Via{Channel: "web"}},
 				}
 				meta := zendesk.CursorPaginationMeta{
 					HasMore:     false,
@@ -44,9 +48,14 @@ func TestFetchCommentsWithActivityEnvironment(t *testing.T) {
 				m.On("GetTicketCommentsCBP", mock.Anything, mock.MatchedBy(func(opts *zendesk.CBPOptions) bool {
 					return opts.Id == 12345 && opts.PageAfter == ""
 				})).Return(comments, meta, nil).Once()
+				m.On("GetUser", mock.Anything, int64(101)).Return(zendesk.User{ID: 101, Name: "Customer", Role: "end-user"}, nil).Once()
+				m.On("GetUser", mock.Anything, int64(102)).Return(zendesk.User{ID: 102, Name: "Agent", Role: "agent"}, nil).Once()
 			},
 			expectedOutput: &FetchCommentsOutput{
-				Comments:   []string{"First comment", "Second comment"},
+				Comments: []Comment{
+					{ID: 1, Author: Author{ID: 101, Name: "Customer", Role: "end-user"}, Body: "First comment", Public: true},
+					{ID: 2, Author: Author{ID: 102, Name: "Agent", Role: "agent"}, Body: "Second comment", Public: false, Channel: "web"},
+				},
 				NextCursor: "cursor1",
 			},
 		},
@@ -57,8 +66,8 @@ func TestFetchCommentsWithActivityEnvironment(t *testing.T) {
 			setupMock: func(m *zd.MockZendeskClient) {
 				// Set up the mock to return two pages of comments
 				comments1 := []zendesk.TicketComment{
-					{PlainBody: "Page 1 comment 1"},
-					{PlainBody: "Page 1 comment 2"},
+					{AuthorID: 101, PlainBody: "Page 1 comment 1"},
+					{AuthorID: 101, PlainBody: "Page 1 comment 2"},
 				}
 				meta1 := zendesk.CursorPaginationMeta{
 					HasMore:     true,
@@ -69,8 +78,8 @@ func TestFetchCommentsWithActivityEnvironment(t *testing.T) {
 				})).Return(comments1, meta1, nil).Once()
 
 				comments2 := []zendesk.TicketComment{
-					{PlainBody: "Page 2 comment 1"},
-					{PlainBody: "Page 2 comment 2"},
+					{AuthorID: 101, PlainBody: "Page 2 comment 1"},
+					{AuthorID: 101, PlainBody: "Page 2 comment 2"},
 				}
 				meta2 := zendesk.CursorPaginationMeta{
 					HasMore:     false,
@@ -79,15 +88,59 @@ func TestFetchCommentsWithActivityEnvironment(t *testing.T) {
 				m.On("GetTicketCommentsCBP", mock.Anything, mock.MatchedBy(func(opts *zendesk.CBPOptions) bool {
 					return opts.Id == 12345 && opts.PageAfter == "cursor1"
 				})).Return(comments2, meta2, nil).Once()
+
+				// The author is shared by all comments so it's only looked up once
+				m.On("GetUser", mock.Anything, int64(101)).Return(zendesk.User{ID: 101, Name: "Customer", Role: "end-user"}, nil).Once()
 			},
 			expectedOutput: &FetchCommentsOutput{
-				Comments: []string{
-					"Page 1 comment 1", "Page 1 comment 2",
-					"Page 2 comment 1", "Page 2 comment 2",
+				Comments: []Comment{
+					{Author: customer, Body: "Page 1 comment 1", Public: true},
+					{Author: customer, Body: "Page 1 comment 2", Public: true},
+					{Author: customer, Body: "Page 2 comment 1", Public: true},
+					{Author: customer, Body: "Page 2 comment 2", Public: true},
 				},
 				NextCursor: "cursor2",
 			},
 		},
+		{
+			name:     "Stops At A Full Batch",
+			ticketID: "12345",
+			cursor:   "",
+			setupMock: func(m *zd.MockZendeskClient) {
+				// The first page fills the batch so the second is left for the next fetch
+				long := strings.Repeat("a", MaxCommentsBytes)
+				m.On("GetTicketCommentsCBP", mock.Anything, mock.MatchedBy(func(opts *zendesk.CBPOptions) bool {
+					return opts.PageAfter == ""
+				})).Return([]zendesk.TicketComment{{AuthorID: 101, PlainBody: long}},
+					zendesk.CursorPaginationMeta{HasMore: true, AfterCursor: "cursor1"}, nil).Once()
+				m.On("GetUser", mock.Anything, int64(101)).Return(zendesk.User{ID: 101, Name: "Customer", Role: "end-user"}, nil).Once()
+			},
+			expectedOutput: &FetchCommentsOutput{
+				Comments:   []Comment{{Author: customer, Body: strings.Repeat("a", MaxCommentsBytes), Public: true}},
+				NextCursor: "cursor1",
+				HasMore:    true,
+			},
+		},
+		{
+			name:     "Excludes TicketFu Notes",
+			ticketID: "12345",
+			cursor:   "cursor1",
+			setupMock: func(m *zd.MockZendeskClient) {
+				comments := []zendesk.TicketComment{
+					{ID: 3, AuthorID: 101, PlainBody: "Any update?", Public: &public},
+					{ID: 4, AuthorID: 999, PlainBody: zd.NoteMarker + "\n\nSummary: Waiting on a fix", Public: &private},
+				}
+				meta := zendesk.CursorPaginationMeta{HasMore: false, AfterCursor: "cursor2"}
+				m.On("GetTicketCommentsCBP", mock.Anything, mock.Anything).Return(comments, meta, nil).Once()
+
+				// The note's author isn't looked up
+				m.On("GetUser", mock.Anything, int64(101)).Return(zendesk.User{ID: 101, Name: "Customer", Role: "end-user"}, nil).Once()
+			},
+			expectedOutput: &FetchCommentsOutput{
+				Comments:   []Comment{{ID: 3, Author: customer, Body: "Any update?", Public: true}},
+				NextCursor: "cursor2",
+			},
+		},
 		{
 			name:     "Invalid Ticket ID",
 			ticketID: "not-a-number",
@@ -122,13 +175,30 @@ func TestFetchCommentsWithActivityEnvironment(t *testing.T) {
 			expectError: true,
 			errorType:   "NotFound", // Expected error type
 		},
+		{
+			name:     "Author API Error",
+			ticketID: "12345",
+			cursor:   "",
+			setupMock: func(m *zd.MockZendeskClient) {
+				comments := []zendesk.TicketComment{
+					{AuthorID: 101, PlainBody: "First comment"},
+				}
+				m.On("GetTicketCommentsCBP", mock.Anything, mock.Anything).
+					Return(comments, zendesk.CursorPaginationMeta{}, nil).Once()
+				m.On("GetUser", mock.Anything, int64(101)).Return(zendesk.User{}, errors.New("user API error")).Once()
+			},
+			// The author is left unknown rather than failing the batch
+			expectedOutput: &FetchCommentsOutput{
+				Comments: []Comment{{Author: Author{ID: 101, Name: UnknownAuthor}, Body: "First comment", Public: true}},
+			},
+		},
 		{
 			name:     "With Initial Cursor",
 			ticketID: "12345",
 			cursor:   "initial-cursor",
 			setupMock: func(m *zd.MockZendeskClient) {
 				comments := []zendesk.TicketComment{
-					{PlainBody: "Comment with cursor"},
+					{AuthorID: 101, PlainBody: "Comment with cursor"},
 				}
 				meta := zendesk.CursorPaginationMeta{
 					HasMore:     false,
@@ -137,9 +207,10 @@ func TestFetchCommentsWithActivityEnvironment(t *testing.T) {
 				m.On("GetTicketCommentsCBP", mock.Anything, mock.MatchedBy(func(opts *zendesk.CBPOptions) bool {
 					return opts.Id == 12345 && opts.PageAfter == "initial-cursor"
 				})).Return(comments, meta, nil).Once()
+				m.On("GetUser", mock.Anything, int64(101)).Return(zendesk.User{ID: 101, Name: "Customer", Role: "end-user"}, nil).Once()
 			},
 			expectedOutput: &FetchCommentsOutput{
-				Comments:   []string{"Comment with cursor"},
+				Comments:   []Comment{{Author: customer, Body: "Comment with cursor", Public: true}},
 				NextCursor: "next-cursor",
 			},
 		},
@@ -188,6 +259,7 @@ func TestFetchCommentsWithActivityEnvironment(t *testing.T) {
 				require.NoError(t, err)
 
 				assert.Equal(t, tc.expectedOutput.NextCursor, output.NextCursor)
+				assert.Equal(t, tc.expectedOutput.HasMore, output.HasMore)
 				assert.Equal(t, tc.expectedOutput.Comments, output.Comments)
 			}
 
@@ -195,3 +267,45 @@ func TestFetchCommentsWithActivityEnvironment(t *testing.T) {
 		})
 	}
 }
+
+func TestFetchCommentsCachesAuthors(t *testing.T) {
+	testSuite := testsuite.WorkflowTestSuite{}
+	testEnv := testSuite.NewTestActivityEnvironment()
+
+	mockClient := new(zd.MockZendeskClient)
+	activity := &Activity{zClient: mockClient}
+	testEnv.RegisterActivity(activity.FetchComments)
+
+	// Each author is looked up once across the batches of the thread, while
+	// one whose lookup failed is looked up again
+	mockClient.On("GetTicketCommentsCBP", mock.Anything, mock.MatchedBy(func(opts *zendesk.CBPOptions) bool {
+		return opts.PageAfter == ""
+	})).Return([]zendesk.TicketComment{{ID: 1, AuthorID: 101}, {ID: 2, AuthorID: 102}},
+		zendesk.CursorPaginationMeta{AfterCursor: "cursor1"}, nil).Once()
+	mockClient.On("GetTicketCommentsCBP", mock.Anything, mock.MatchedBy(func(opts *zendesk.CBPOptions) bool {
+		return opts.PageAfter == "cursor1"
+	})).Return([]zendesk.TicketComment{{ID: 3, AuthorID: 101}, {ID: 4, AuthorID: 102}},
+		zendesk.CursorPaginationMeta{AfterCursor: "cursor2"}, nil).Once()
+	mockClient.On("GetUser", mock.Anything, int64(101)).Return(zendesk.User{ID: 101, Name: "Customer", Role: "end-user"}, nil).Once()
+	mockClient.On("GetUser", mock.Anything, int64(102)).Return(zendesk.User{}, errors.New("user API error")).Once()
+	mockClient.On("GetUser", mock.Anything, int64(102)).Return(zendesk.User{ID: 102, Name: "Agent", Role: "agent"}, nil).Once()
+
+	batches := []struct {
+		cursor  string
+		authors []Author
+	}{
+		{"", []Author{{ID: 101, Name: "Customer", Role: "end-user"}, {ID: 102, Name: UnknownAuthor}}},
+		{"cursor1", []Author{{ID: 101, Name: "Customer", Role: "end-user"}, {ID: 102, Name: "Agent", Role: "agent"}}},
+	}
+	for _, batch := range batches {
+		future, err := testEnv.ExecuteActivity(activity.FetchComments, FetchCommentsInput{ID: "12345", Cursor: batch.cursor})
+		require.NoError(t, err)
+
+		var output FetchCommentsOutput
+		require.NoError(t, future.Get(&output))
+		require.Len(t, output.Comments, 2)
+		assert.Equal(t, batch.authors, []Author{output.Comments[0].Author, output.Comments[1].Author})
+	}
+
+	mockClient.AssertExpectations(t)
+}
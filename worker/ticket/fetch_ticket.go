@@ -2,14 +2,19 @@ package ticket
 
 import (
 	"context"
+	"fmt"
 	"strconv"
+	"strings"
 
+	"github.com/nukosuke/go-zendesk/zendesk"
 	"golang.org/x/sync/errgroup"
 )
 
 type (
 	FetchTicketInput struct {
 		ID string
+		// Custom fields to keep, mapping field IDs to readable names
+		CustomFields map[int64]string
 	}
 
 	FetchTicketOutput struct {
@@ -25,6 +30,9 @@ func (a *Activity) FetchTicket(ctx context.Context, input FetchTicketInput) (*Fe
 
 	rawTicket, err := a.zClient.GetTicket(ctx, num)
 	if err != nil {
+		if goneErr := ticketGoneError(err); goneErr != nil {
+			return nil, goneErr
+		}
 		return nil, err
 	}
 
@@ -34,9 +42,29 @@ func (a *Activity) FetchTicket(ctx context.Context, input FetchTicketInput) (*Fe
 		Description:    rawTicket.Description,
 		Priority:       rawTicket.Priority,
 		Status:         rawTicket.Status,
+		RequesterID:    rawTicket.RequesterID,
+		AssigneeID:     rawTicket.AssigneeID,
 		OrganizationID: rawTicket.OrganizationID,
 		CreatedAt:      rawTicket.CreatedAt,
 		UpdatedAt:      rawTicket.UpdatedAt,
+		Type:           rawTicket.Type,
+		Tags:           rawTicket.Tags,
+		CustomFields:   customFields(rawTicket.CustomFields, input.CustomFields),
+		BrandID:        rawTicket.BrandID,
+		ProblemID:      rawTicket.ProblemID,
+		HasIncidents:   rawTicket.HasIncidents,
+		DueAt:          rawTicket.DueAt,
+	}
+
+	// Zendesk returns the group ID as a number or null
+	if groupID, err := rawTicket.GroupID.Int64(); err == nil {
+		ticket.GroupID = groupID
+	}
+	if rawTicket.Via != nil {
+		ticket.Channel = rawTicket.Via.Channel
+	}
+	if rating := rawTicket.SatisfactionRating; rating != nil && rating.Score != "" && rating.Score != "unoffered" {
+		ticket.Satisfaction = &Satisfaction{Score: rating.Score, Comment: rating.Comment}
 	}
 
 	g, ctx := errgroup.WithContext(ctx)
@@ -52,14 +80,16 @@ func (a *Activity) FetchTicket(ctx context.Context, input FetchTicketInput) (*Fe
 	})
 
 	var assigneeName string
-	g.Go(func() error {
-		assignee, err := a.zClient.GetUser(ctx, rawTicket.AssigneeID)
-		if err != nil {
-			return err
-		}
-		assigneeName = assignee.Name
-		return nil
-	})
+	if rawTicket.AssigneeID != 0 {
+		g.Go(func() error {
+			assignee, err := a.zClient.GetUser(ctx, rawTicket.AssigneeID)
+			if err != nil {
+				return err
+			}
+			assigneeName = assignee.Name
+			return nil
+		})
+	}
 
 	var organizationName string
 	if rawTicket.OrganizationID != 0 {
@@ -83,3 +113,41 @@ func (a *Activity) FetchTicket(ctx context.Context, input FetchTicketInput) (*Fe
 
 	return &FetchTicketOutput{Ticket: ticket}, nil
 }
+
+// customFields keeps the allowlisted custom fields that have a value, keyed by
+// their readable names
+func customFields(fields []zendesk.CustomField, allowlist map[int64]string) map[string]string {
+	values := make(map[string]string)
+	for _, field := range fields {
+		name, ok := allowlist[field.ID]
+		if !ok {
+			continue
+		}
+
+		var value string
+		switch v := field.Value.(type) {
+		case nil:
+		case string:
+			value = v
+		case []string: // Multi-select fields, as go-zendesk decodes them
+			value = strings.Join(v, ", ")
+		case []any: // Multi-select fields decoded as plain JSON
+			options := make([]string, 0, len(v))
+			for _, option := range v {
+				options = append(options, fmt.Sprint(option))
+			}
+			value = strings.Join(options, ", ")
+		default:
+			value = fmt.Sprint(v)
+		}
+
+		if value != "" {
+			values[name] = value
+		}
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
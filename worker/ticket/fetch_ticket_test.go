@@ -1,7 +1,9 @@
 package ticket
 
 import (
+	"encoding/json"
 	"errors"
+	"net/http"
 	"testing"
 	"time"
 
@@ -20,6 +22,7 @@ func TestFetchTicket(t *testing.T) {
 	testCases := []struct {
 		name           string
 		ticketID       string
+		customFields   map[int64]string
 		setupMock      func(*zd.MockZendeskClient)
 		expectedErr    string
 		expectedTicket *Ticket
@@ -61,12 +64,62 @@ func TestFetchTicket(t *testing.T) {
 				Priority:         "high",
 				Status:           "open",
 				Requester:        "Test Requester",
+				AssigneeID:       102,
 				Assignee:         "Test Assignee",
 				OrganizationID:   201,
 				OrganizationName: "Test Organization",
 				// CreatedAt and UpdatedAt will be checked separately
 			},
 		},
+		{
+			name:         "Zendesk Metadata",
+			ticketID:     "12345",
+			customFields: map[int64]string{1001: "product_area", 1002: "regions", 1003: "plan"},
+			setupMock: func(m *zd.MockZendeskClient) {
+				now := time.Now()
+				m.On("GetTicket", mock.Anything, int64(12345)).Return(zendesk.Ticket{
+					ID:          12345,
+					Type:        "incident",
+					Status:      "open",
+					RequesterID: 101,
+					GroupID:     "360000001",
+					BrandID:     42,
+					ProblemID:   12000,
+					Tags:        []string{"vip", "billing"},
+					CustomFields: []zendesk.CustomField{
+						{ID: 1001, Value: "billing"},
+						{ID: 1002, Value: []string{"emea", "apac"}},
+						{ID: 1003, Value: nil},       // Empty values are dropped
+						{ID: 9999, Value: "ignored"}, // Not allowlisted
+					},
+					Via: &zendesk.Via{Channel: "email"},
+					SatisfactionRating: &struct {
+						ID      int64  `json:"id"`
+						Score   string `json:"score"`
+						Comment string `json:"comment"`
+					}{Score: "bad", Comment: "Took too long"},
+					CreatedAt: &now,
+					UpdatedAt: &now,
+				}, nil)
+				m.On("GetUser", mock.Anything, int64(101)).Return(zendesk.User{
+					ID:   101,
+					Name: "Test Requester",
+				}, nil)
+			},
+			expectedTicket: &Ticket{
+				ID:           12345,
+				Status:       "open",
+				Requester:    "Test Requester",
+				Type:         "incident",
+				Tags:         []string{"vip", "billing"},
+				CustomFields: map[string]string{"product_area": "billing", "regions": "emea, apac"},
+				BrandID:      42,
+				GroupID:      360000001,
+				Channel:      "email",
+				Satisfaction: &Satisfaction{Score: "bad", Comment: "Took too long"},
+				ProblemID:    12000,
+			},
+		},
 		{
 			name:     "Invalid ID",
 			ticketID: "not-a-number",
@@ -83,6 +136,24 @@ func TestFetchTicket(t *testing.T) {
 			},
 			expectedErr: "ticket API error",
 		},
+		{
+			name:     "Deleted Ticket",
+			ticketID: "12345",
+			setupMock: func(m *zd.MockZendeskClient) {
+				m.On("GetTicket", mock.Anything, int64(12345)).
+					Return(zendesk.Ticket{}, zendesk.NewError(nil, &http.Response{StatusCode: 404}))
+			},
+			expectedErr: "failed to find the ticket",
+		},
+		{
+			name:     "Inaccessible Ticket",
+			ticketID: "12345",
+			setupMock: func(m *zd.MockZendeskClient) {
+				m.On("GetTicket", mock.Anything, int64(12345)).
+					Return(zendesk.Ticket{}, zendesk.NewError(nil, &http.Response{StatusCode: 403}))
+			},
+			expectedErr: "no access to the ticket",
+		},
 		{
 			name:     "Requester API Error",
 			ticketID: "12345",
@@ -165,12 +236,42 @@ func TestFetchTicket(t *testing.T) {
 				Priority:         "high",
 				Status:           "open",
 				Requester:        "Test Requester",
+				AssigneeID:       102,
 				Assignee:         "Test Assignee",
 				OrganizationID:   0,
 				OrganizationName: "", // Should be empty since no org
 				// CreatedAt and UpdatedAt will be checked separately
 			},
 		},
+		{
+			name:     "Unassigned Ticket",
+			ticketID: "12345",
+			setupMock: func(m *zd.MockZendeskClient) {
+				now := time.Now()
+				m.On("GetTicket", mock.Anything, int64(12345)).Return(zendesk.Ticket{
+					ID:          12345,
+					Subject:     "Test Subject",
+					Status:      "new",
+					RequesterID: 101,
+					AssigneeID:  0, // Not assigned yet
+					CreatedAt:   &now,
+					UpdatedAt:   &now,
+				}, nil)
+
+				// Only the requester is looked up
+				m.On("GetUser", mock.Anything, int64(101)).Return(zendesk.User{
+					ID:   101,
+					Name: "Test Requester",
+				}, nil)
+			},
+			expectedTicket: &Ticket{
+				ID:          12345,
+				Subject:     "Test Subject",
+				Status:      "new",
+				RequesterID: 101,
+				Requester:   "Test Requester",
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -188,7 +289,7 @@ func TestFetchTicket(t *testing.T) {
 			testEnv.RegisterActivity(activity.FetchTicket)
 
 			// Create input
-			input := FetchTicketInput{ID: tc.ticketID}
+			input := FetchTicketInput{ID: tc.ticketID, CustomFields: tc.customFields}
 
 			// Execute the activity
 			future, err := testEnv.ExecuteActivity(activity.FetchTicket, input)
@@ -212,8 +313,17 @@ func TestFetchTicket(t *testing.T) {
 				assert.Equal(t, tc.expectedTicket.Status, output.Ticket.Status)
 				assert.Equal(t, tc.expectedTicket.Requester, output.Ticket.Requester)
 				assert.Equal(t, tc.expectedTicket.Assignee, output.Ticket.Assignee)
+				assert.Equal(t, tc.expectedTicket.AssigneeID, output.Ticket.AssigneeID)
 				assert.Equal(t, tc.expectedTicket.OrganizationID, output.Ticket.OrganizationID)
 				assert.Equal(t, tc.expectedTicket.OrganizationName, output.Ticket.OrganizationName)
+				assert.Equal(t, tc.expectedTicket.Type, output.Ticket.Type)
+				assert.Equal(t, tc.expectedTicket.Tags, output.Ticket.Tags)
+				assert.Equal(t, tc.expectedTicket.CustomFields, output.Ticket.CustomFields)
+				assert.Equal(t, tc.expectedTicket.BrandID, output.Ticket.BrandID)
+				assert.Equal(t, tc.expectedTicket.GroupID, output.Ticket.GroupID)
+				assert.Equal(t, tc.expectedTicket.Channel, output.Ticket.Channel)
+				assert.Equal(t, tc.expectedTicket.Satisfaction, output.Ticket.Satisfaction)
+				assert.Equal(t, tc.expectedTicket.ProblemID, output.Ticket.ProblemID)
 
 				assert.NotNil(t, output.Ticket.CreatedAt)
 				assert.NotNil(t, output.Ticket.UpdatedAt)
@@ -223,3 +333,36 @@ func TestFetchTicket(t *testing.T) {
 		})
 	}
 }
+
+func TestCustomFields(t *testing.T) {
+	data := []byte(`[
+		{"id": 1001, "value": "billing"},
+		{"id": 1002, "value": ["emea", "apac"]},
+		{"id": 1003, "value": true},
+		{"id": 1004, "value": null},
+		{"id": 1005, "value": []}
+	]`)
+	allowlist := map[int64]string{1001: "product_area", 1002: "regions", 1003: "escalated", 1004: "plan", 1005: "modules"}
+	expected := map[string]string{
+		"product_area": "billing",
+		"regions":      "emea, apac",
+		"escalated":    "true",
+	}
+
+	// Values as go-zendesk decodes them
+	var fields []zendesk.CustomField
+	require.NoError(t, json.Unmarshal(data, &fields))
+	assert.Equal(t, expected, customFields(fields, allowlist))
+
+	// Values decoded as plain JSON
+	var raw []struct {
+		ID    int64
+		Value any
+	}
+	require.NoError(t, json.Unmarshal(data, &raw))
+	fields = nil
+	for _, field := range raw {
+		fields = append(fields, zendesk.CustomField{ID: field.ID, Value: field.Value})
+	}
+	assert.Equal(t, expected, customFields(fields, allowlist))
+}
@@ -0,0 +1,37 @@
+package ticket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type (
+	GenDraftReplyInput struct {
+		Ticket Ticket
+		// Optional agent instruction, e.g. tone or points to cover
+		Instruction string
+	}
+
+	GenDraftReplyOutput struct {
+		Draft string
+	}
+)
+
+func (a *Activity) GenDraftReply(ctx context.Context, input GenDraftReplyInput) (*GenDraftReplyOutput, error) {
+	content, err := json.Marshal(struct {
+		Instruction string `json:"agent_instruction,omitempty"`
+		Ticket      Ticket `json:"ticket"`
+	}{input.Instruction, cleanse(input.Ticket)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ticket to JSON: %w", err)
+	}
+
+	draft, err := a.genAPI.GenerateContent(ctx, a.genAPI.GetConfig().DraftReplyPrompt, string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate %w", err)
+	}
+
+	return &GenDraftReplyOutput{Draft: strings.TrimSpace(draft)}, nil
+}
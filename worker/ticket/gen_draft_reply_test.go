@@ -0,0 +1,83 @@
+package ticket
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/taonic/ticketfu/config"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestGenDraftReply(t *testing.T) {
+	testSuite := testsuite.WorkflowTestSuite{}
+	testEnv := testSuite.NewTestActivityEnvironment()
+
+	ticket := Ticket{ID: 12345, Comments: []Comment{{Body: "Login keeps failing"}}}
+
+	testCases := []struct {
+		name          string
+		instruction   string
+		setupMock     func(*MockGenAIAPI)
+		expectedDraft string
+		expectedError string
+	}{
+		{
+			name:        "Draft With Instruction",
+			instruction: "keep it short",
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{DraftReplyPrompt: "draft"})
+				m.On("GenerateContent", mock.Anything, "draft", mock.MatchedBy(func(content string) bool {
+					return strings.Contains(content, `"agent_instruction":"keep it short"`) &&
+						strings.Contains(content, "Login keeps failing")
+				})).Return("\nHi, could you try clearing your cookies?\n", nil)
+			},
+			expectedDraft: "Hi, could you try clearing your cookies?",
+		},
+		{
+			name: "Draft Without Instruction",
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{DraftReplyPrompt: "draft"})
+				m.On("GenerateContent", mock.Anything, "draft", mock.MatchedBy(func(content string) bool {
+					return !strings.Contains(content, "agent_instruction")
+				})).Return("Hi there", nil)
+			},
+			expectedDraft: "Hi there",
+		},
+		{
+			name: "Generation API Error",
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{DraftReplyPrompt: "draft"})
+				m.On("GenerateContent", mock.Anything, mock.Anything, mock.Anything).Return("", errors.New("API failure"))
+			},
+			expectedError: "failed to generate",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockAPI := new(MockGenAIAPI)
+			tc.setupMock(mockAPI)
+
+			activity := &Activity{genAPI: mockAPI}
+			testEnv.RegisterActivity(activity.GenDraftReply)
+
+			future, err := testEnv.ExecuteActivity(activity.GenDraftReply, GenDraftReplyInput{Ticket: ticket, Instruction: tc.instruction})
+
+			if tc.expectedError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedError)
+			} else {
+				require.NoError(t, err)
+				var output GenDraftReplyOutput
+				require.NoError(t, future.Get(&output))
+				assert.Equal(t, tc.expectedDraft, output.Draft)
+			}
+
+			mockAPI.AssertExpectations(t)
+		})
+	}
+}
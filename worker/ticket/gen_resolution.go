@@ -0,0 +1,118 @@
+package ticket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/taonic/ticketfu/genai"
+	"go.temporal.io/sdk/activity"
+)
+
+// ResolutionSchema is the JSON schema the generated resolution must conform to
+const ResolutionSchema = `{
+  "type": "object",
+  "properties": {
+    "root_cause": {"type": "string", "minLength": 1},
+    "fix": {"type": "string", "minLength": 1}
+  },
+  "required": ["root_cause", "fix"]
+}`
+
+type (
+	// Resolution summarizes how a closed ticket was resolved
+	Resolution struct {
+		RootCause     string `json:"root_cause"`
+		Fix           string `json:"fix"`
+		TimeToResolve string `json:"time_to_resolve"`
+	}
+
+	GenResolutionInput struct {
+		Ticket   Ticket
+		SolvedAt *time.Time // Latest move to solved, if any
+	}
+
+	GenResolutionOutput struct {
+		Resolution Resolution
+	}
+)
+
+// String describes the resolution in sentences, e.g. "Resolved in 3h0m0s.
+// Root cause: Expired certificate. Fix: Rotated the certificate."
+func (r Resolution) String() string {
+	var sentences []string
+	if r.TimeToResolve != "" {
+		sentences = append(sentences, "Resolved in "+r.TimeToResolve+".")
+	}
+	sentences = append(sentences, "Root cause: "+punctuate(r.RootCause), "Fix: "+punctuate(r.Fix))
+	return strings.Join(sentences, " ")
+}
+
+// punctuate ends the text with a full stop unless it already ends a sentence
+func punctuate(text string) string {
+	text = strings.TrimSpace(text)
+	if text == "" || strings.HasSuffix(text, ".") || strings.HasSuffix(text, "!") || strings.HasSuffix(text, "?") {
+		return text
+	}
+	return text + "."
+}
+
+func (a *Activity) GenResolutionSummary(ctx context.Context, input GenResolutionInput) (*GenResolutionOutput, error) {
+	cfg := a.genAPI.GetConfig()
+	ticket, dropped, err := fitComments(genai.NewBudget(cfg), cfg.ResolutionPrompt, cleanse(input.Ticket))
+	if err != nil {
+		return nil, err
+	}
+	if dropped > 0 {
+		activity.GetLogger(ctx).Debug("Dropped older comments over the context budget", "ticket-id", input.Ticket.ID, "dropped", dropped)
+	}
+
+	ticketJSON, err := json.Marshal(ticket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ticket to JSON: %w", err)
+	}
+
+	result, err := a.genAPI.GenerateContent(ctx, cfg.ResolutionPrompt, string(ticketJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate %w", err)
+	}
+
+	var values map[string]any
+	if err := json.Unmarshal([]byte(trimCodeFence(result)), &values); err != nil {
+		return nil, fmt.Errorf("failed to parse resolution summary: %w", err)
+	}
+
+	var schema map[string]any
+	if err := json.Unmarshal([]byte(ResolutionSchema), &schema); err != nil {
+		return nil, fmt.Errorf("invalid resolution schema: %w", err)
+	}
+	if err := validateSchema(schema, values, ""); err != nil {
+		return nil, fmt.Errorf("invalid resolution summary: %w", err)
+	}
+
+	resolution := Resolution{RootCause: values["root_cause"].(string), Fix: values["fix"].(string)}
+
+	// Time to resolve is derived from the ticket rather than left to the LLM.
+	// It runs to when the ticket was solved, as solved tickets are only
+	// closed automatically days later.
+	resolvedAt := input.Ticket.UpdatedAt
+	if input.SolvedAt != nil {
+		resolvedAt = input.SolvedAt
+	}
+	if input.Ticket.CreatedAt != nil && resolvedAt != nil {
+		resolution.TimeToResolve = resolvedAt.Sub(*input.Ticket.CreatedAt).Round(time.Minute).String()
+	}
+
+	return &GenResolutionOutput{Resolution: resolution}, nil
+}
+
+// trimCodeFence strips the markdown code fence LLMs tend to wrap JSON with
+func trimCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "```json")
+	s = strings.TrimPrefix(s, "```")
+	s = strings.TrimSuffix(s, "```")
+	return strings.TrimSpace(s)
+}
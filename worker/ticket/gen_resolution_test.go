@@ -0,0 +1,121 @@
+package ticket
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/taonic/ticketfu/config"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestGenResolutionSummary(t *testing.T) {
+	testSuite := testsuite.WorkflowTestSuite{}
+	testEnv := testSuite.NewTestActivityEnvironment()
+
+	createdAt := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	updatedAt := createdAt.Add(26*time.Hour + 30*time.Minute)
+	ticket := Ticket{ID: 12345, Status: StatusClosed, CreatedAt: &createdAt, UpdatedAt: &updatedAt}
+
+	// Solved, then only closed automatically the next day
+	solvedAt := createdAt.Add(3*time.Hour + 15*time.Minute)
+
+	testCases := []struct {
+		name           string
+		solvedAt       *time.Time
+		setupMock      func(*MockGenAIAPI)
+		expectedOutput Resolution
+		expectedError  string
+	}{
+		{
+			name: "Successful Resolution",
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{ResolutionPrompt: "resolve"})
+				m.On("GenerateContent", mock.Anything, "resolve", mock.Anything).
+					Return("```json\n{\"root_cause\": \"Bad config\", \"fix\": \"Reverted config\"}\n```", nil)
+			},
+			expectedOutput: Resolution{RootCause: "Bad config", Fix: "Reverted config", TimeToResolve: "26h30m0s"},
+		},
+		{
+			name:     "Resolved When Solved",
+			solvedAt: &solvedAt,
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{ResolutionPrompt: "resolve"})
+				m.On("GenerateContent", mock.Anything, "resolve", mock.Anything).
+					Return(`{"root_cause": "Bad config", "fix": "Reverted config"}`, nil)
+			},
+			expectedOutput: Resolution{RootCause: "Bad config", Fix: "Reverted config", TimeToResolve: "3h15m0s"},
+		},
+		{
+			name: "Invalid JSON",
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{ResolutionPrompt: "resolve"})
+				m.On("GenerateContent", mock.Anything, mock.Anything, mock.Anything).Return("not json", nil)
+			},
+			expectedError: "failed to parse resolution summary",
+		},
+		{
+			name: "Empty Root Cause",
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{ResolutionPrompt: "resolve"})
+				m.On("GenerateContent", mock.Anything, mock.Anything, mock.Anything).
+					Return(`{"root_cause": "", "fix": "Reverted config"}`, nil)
+			},
+			expectedError: "field root_cause must not be empty",
+		},
+		{
+			name: "Missing Fix",
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{ResolutionPrompt: "resolve"})
+				m.On("GenerateContent", mock.Anything, mock.Anything, mock.Anything).
+					Return(`{"root_cause": "Bad config"}`, nil)
+			},
+			expectedError: "missing required fields: fix",
+		},
+		{
+			name: "Generation API Error",
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{ResolutionPrompt: "resolve"})
+				m.On("GenerateContent", mock.Anything, mock.Anything, mock.Anything).Return("", errors.New("API failure"))
+			},
+			expectedError: "failed to generate",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockAPI := new(MockGenAIAPI)
+			tc.setupMock(mockAPI)
+
+			activity := &Activity{genAPI: mockAPI}
+			testEnv.RegisterActivity(activity.GenResolutionSummary)
+
+			input := GenResolutionInput{Ticket: ticket, SolvedAt: tc.solvedAt}
+			future, err := testEnv.ExecuteActivity(activity.GenResolutionSummary, input)
+
+			if tc.expectedError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedError)
+			} else {
+				require.NoError(t, err)
+				var output GenResolutionOutput
+				require.NoError(t, future.Get(&output))
+				assert.Equal(t, tc.expectedOutput, output.Resolution)
+			}
+
+			mockAPI.AssertExpectations(t)
+		})
+	}
+}
+
+func TestResolutionString(t *testing.T) {
+	resolution := Resolution{RootCause: "Expired certificate", Fix: "Rotated the certificate", TimeToResolve: "3h0m0s"}
+	assert.Equal(t, "Resolved in 3h0m0s. Root cause: Expired certificate. Fix: Rotated the certificate.", resolution.String())
+
+	// Text already ending a sentence isn't punctuated again
+	resolution = Resolution{RootCause: "Expired certificate.", Fix: "Is it rotated yet?"}
+	assert.Equal(t, "Root cause: Expired certificate. Fix: Is it rotated yet?", resolution.String())
+}
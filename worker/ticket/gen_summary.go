@@ -1,39 +1,395 @@
 package ticket
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/taonic/ticketfu/config"
+	"github.com/taonic/ticketfu/genai"
+	"github.com/taonic/ticketfu/worker/history"
+	"github.com/taonic/ticketfu/worker/metrics"
+	"github.com/taonic/ticketfu/worker/route"
+	"go.temporal.io/sdk/activity"
+)
+
+const (
+	// MaxSummaryRepairs is the number of times an invalid summary is sent back
+	// to the LLM for repair before the activity fails.
+	MaxSummaryRepairs = 2
+
+	// summaryCallTimeout is how long each call generating or repairing a
+	// summary is given
+	summaryCallTimeout = time.Minute
+
+	// TicketSummarySchema is the JSON schema the generated summary must conform to
+	TicketSummarySchema = `{
+  "type": "object",
+  "properties": {
+    "intent": {"type": "string", "minLength": 1},
+    "summary": {"type": "string", "minLength": 1},
+    "next_step": {"type": "string", "minLength": 1}
+  },
+  "required": ["intent", "summary", "next_step"],
+  "additionalProperties": false
+}`
 )
 
 type (
+	TicketSummary struct {
+		Intent   string `json:"intent"`
+		Summary  string `json:"summary"`
+		NextStep string `json:"next_step"`
+
+		// Fields asked for by the matched prompt route, inlined in the JSON
+		Fields map[string]any `json:"-"`
+	}
+
 	GenSummaryInput struct {
 		Ticket Ticket
 	}
 
 	GenSummaryOutput struct {
-		Summary string
+		Summary TicketSummary
+		// Raw LLM output kept for debugging
+		Raw string
+
+		Model      string
+		PromptHash string
+		Route      string // Prompt route that matched, empty for the default prompt
 	}
 )
 
+// reservedFields are part of every ticket summary
+var reservedFields = []string{"intent", "summary", "next_step"}
+
+// String returns the summary as JSON
+func (s TicketSummary) String() string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// MarshalJSON inlines the route's fields after the standard ones
+func (s TicketSummary) MarshalJSON() ([]byte, error) {
+	type summary TicketSummary
+	b, err := json.Marshal(summary(s))
+	if err != nil || len(s.Fields) == 0 {
+		return b, err
+	}
+
+	names := slices.Sorted(maps.Keys(s.Fields))
+	var buf bytes.Buffer
+	buf.Write(b[:len(b)-1])
+	for _, name := range names {
+		if slices.Contains(reservedFields, name) {
+			continue
+		}
+		value, err := json.Marshal(s.Fields[name])
+		if err != nil {
+			return nil, err
+		}
+		key, _ := json.Marshal(name)
+		buf.WriteByte(',')
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON collects the fields beyond the standard ones into Fields.
+// Summaries generated before they were typed decode from their text.
+func (s *TicketSummary) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*s = TicketSummary{Summary: text}
+		return nil
+	}
+
+	type summary TicketSummary
+	var standard summary
+	if err := json.Unmarshal(data, &standard); err != nil {
+		return err
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for _, name := range reservedFields {
+		delete(fields, name)
+	}
+	if len(fields) > 0 {
+		standard.Fields = fields
+	}
+
+	*s = TicketSummary(standard)
+	return nil
+}
+
 func (a *Activity) GenTicketSummary(ctx context.Context, input GenSummaryInput) (*GenSummaryOutput, error) {
-	ticket := cleanse(input.Ticket)
+	logger := activity.GetLogger(ctx)
+
+	cfg := a.genAPI.GetConfig()
+	prompt := cfg.TicketSummaryPrompt
+
+	// Tickets matching a route get its prompt and summary fields
+	r, routed := route.ForTicket(cfg.PromptRoutes, routeTicket(input.Ticket))
+	if routed {
+		logger.Debug("Routed ticket summary prompt", "ticket-id", input.Ticket.ID, "route", r.Name)
+		prompt = r.TicketSummaryPrompt
+	}
+
+	ticket, dropped, err := fitComments(genai.NewBudget(cfg), prompt, cleanse(input.Ticket))
+	if err != nil {
+		return nil, err
+	}
+	if dropped > 0 {
+		logger.Debug("Dropped older comments over the context budget", "ticket-id", input.Ticket.ID, "dropped", dropped)
+	}
+
 	ticketJSON, err := json.Marshal(ticket)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal ticket to JSON: %w", err)
 	}
 
-	result, err := a.genAPI.GenerateContent(ctx, a.genAPI.GetConfig().TicketSummaryPrompt, string(ticketJSON))
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate %w", err)
+	content := string(ticketJSON)
+
+	for attempt := 0; ; attempt++ {
+		result, err := a.genAPI.GenerateContent(ctx, prompt, content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate %w", err)
+		}
+
+		summary, err := parseTicketSummary(result, r.SummaryFields)
+		if err == nil {
+			return &GenSummaryOutput{
+				Summary:    summary,
+				Raw:        result,
+				Model:      cfg.LLMModel,
+				PromptHash: history.PromptHash(prompt),
+				Route:      r.Name,
+			}, nil
+		}
+
+		if attempt == MaxSummaryRepairs {
+			return nil, fmt.Errorf("failed to generate a valid summary after %d repairs: %w", MaxSummaryRepairs, err)
+		}
+
+		logger.Debug("Repairing invalid ticket summary", "attempt", attempt+1, "error", err)
+		content = repairContent(string(ticketJSON), result, summarySchema(r.SummaryFields), err)
 	}
-	output := GenSummaryOutput{Summary: result}
+}
+
+// routeTicket returns what prompt routes match the ticket on
+func routeTicket(ticket Ticket) route.Ticket {
+	return route.Ticket{
+		BrandID:        ticket.BrandID,
+		GroupID:        ticket.GroupID,
+		Type:           ticket.Type,
+		Tags:           ticket.Tags,
+		CustomFields:   ticket.CustomFields,
+		OrganizationID: ticket.OrganizationID,
+	}
+}
+
+// parseTicketSummary validates the LLM output against the schema the LLM was
+// given, TicketSummarySchema extended with the route's fields
+func parseTicketSummary(raw string, fields []config.SummaryField) (TicketSummary, error) {
+	var summary TicketSummary
+
+	var values map[string]any
+	if err := json.Unmarshal([]byte(trimCodeFence(raw)), &values); err != nil {
+		return summary, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	var schema map[string]any
+	if err := json.Unmarshal([]byte(summarySchema(fields)), &schema); err != nil {
+		return summary, fmt.Errorf("invalid summary schema: %w", err)
+	}
+	if err := validateSchema(schema, values, ""); err != nil {
+		return summary, err
+	}
+
+	summary.Intent = values["intent"].(string)
+	summary.Summary = values["summary"].(string)
+	summary.NextStep = values["next_step"].(string)
+	for _, field := range fields {
+		if summary.Fields == nil {
+			summary.Fields = make(map[string]any, len(fields))
+		}
+		summary.Fields[field.Name] = values[field.Name]
+	}
+
+	return summary, nil
+}
+
+// validateSchema checks a value decoded by encoding/json against the JSON
+// schema keywords summary schemas use: type, minLength, properties, required,
+// additionalProperties and items
+func validateSchema(schema map[string]any, value any, path string) error {
+	if typ, ok := schema["type"].(string); ok && jsonType(value) != typ {
+		return fmt.Errorf("field %s must be of type %s", path, typ)
+	}
+
+	switch value := value.(type) {
+	case string:
+		if minLength, ok := schema["minLength"].(float64); ok && float64(len([]rune(value))) < minLength {
+			if value == "" {
+				return fmt.Errorf("field %s must not be empty", path)
+			}
+			return fmt.Errorf("field %s must be at least %d characters long", path, int(minLength))
+		}
+	case []any:
+		items, _ := schema["items"].(map[string]any)
+		for i, item := range value {
+			if items == nil {
+				break
+			}
+			if err := validateSchema(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+			for _, name := range slices.Sorted(maps.Keys(value)) {
+				if _, ok := properties[name]; !ok {
+					return fmt.Errorf("invalid JSON: unknown field %q", fieldPath(path, name))
+				}
+			}
+		}
+
+		var missing []string
+		required, _ := schema["required"].([]any)
+		for _, name := range required {
+			name, _ := name.(string)
+			if _, ok := value[name]; !ok {
+				missing = append(missing, fieldPath(path, name))
+			}
+		}
+		if len(missing) > 0 {
+			return errors.New("missing required fields: " + strings.Join(missing, ", "))
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(value)) {
+			property, ok := properties[name].(map[string]any)
+			if !ok {
+				continue
+			}
+			if err := validateSchema(property, value[name], fieldPath(path, name)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func fieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// summarySchema returns TicketSummarySchema extended with the route's fields
+func summarySchema(fields []config.SummaryField) string {
+	if len(fields) == 0 {
+		return TicketSummarySchema
+	}
+
+	properties := map[string]any{}
+	for _, name := range reservedFields {
+		properties[name] = map[string]any{"type": "string", "minLength": 1}
+	}
+	for _, field := range fields {
+		property := map[string]any{"type": field.Type}
+		if field.Description != "" {
+			property["description"] = field.Description
+		}
+		properties[field.Name] = property
+	}
+
+	schema, _ := json.MarshalIndent(map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             append(slices.Clone(reservedFields), fieldNames(fields)...),
+		"additionalProperties": false,
+	}, "", "  ")
+	return string(schema)
+}
+
+// summaryFields describes the route's fields a summary carries, so a
+// translation can be validated against them
+func summaryFields(summary TicketSummary) []config.SummaryField {
+	var fields []config.SummaryField
+	for _, name := range slices.Sorted(maps.Keys(summary.Fields)) {
+		fields = append(fields, config.SummaryField{Name: name, Type: jsonType(summary.Fields[name])})
+	}
+	return fields
+}
+
+func fieldNames(fields []config.SummaryField) []string {
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = field.Name
+	}
+	return names
+}
+
+// jsonType returns the JSON type of a value decoded by encoding/json
+func jsonType(value any) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return ""
+	}
+}
+
+func repairContent(ticketJSON, invalid, schema string, err error) string {
+	return fmt.Sprintf(`%s
+
+Your previous response was invalid: %s
+Previous response:
+%s
 
-	return &output, nil
+Respond with only a JSON object conforming to this JSON schema:
+%s`, ticketJSON, err, invalid, schema)
 }
 
+// cleanse drops what the workflow derives and keeps about the ticket, leaving
+// what prompts read: the ticket, its comments and the digests of those
+// compacted out
 func cleanse(ticket Ticket) Ticket {
-	ticket.Summary = ""
+	ticket.Summary = nil
+	ticket.Translations = nil
+	ticket.RawSummary = ""
 	ticket.NextCursor = ""
+	ticket.WrittenAt = nil
+	ticket.Events = nil
+	ticket.Scores = nil
+	ticket.Classification = nil
+	ticket.Resolution = nil
+	ticket.PublicCommentsSinceNote = 0
+	ticket.SummaryHistory = nil
+	ticket.Tombstone = nil
+	ticket.Metrics = metrics.Ticket{}
 	return ticket
 }
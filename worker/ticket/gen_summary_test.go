@@ -2,7 +2,9 @@ package ticket
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -10,6 +12,8 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/taonic/ticketfu/config"
+	"github.com/taonic/ticketfu/worker/history"
+	"github.com/taonic/ticketfu/worker/metrics"
 	"go.temporal.io/sdk/testsuite"
 )
 
@@ -23,6 +27,12 @@ func (m *MockGenAIAPI) GenerateContent(ctx context.Context, instruction, content
 	return args.Get(0).(string), args.Error(1)
 }
 
+func (m *MockGenAIAPI) Embed(ctx context.Context, content string) ([]float32, error) {
+	args := m.Called(ctx, content)
+	vector, _ := args.Get(0).([]float32)
+	return vector, args.Error(1)
+}
+
 func (m *MockGenAIAPI) GetConfig() config.AIConfig {
 	args := m.Called()
 	return args.Get(0).(config.AIConfig)
@@ -42,7 +52,10 @@ func createTestTicket() Ticket {
 		OrganizationName: "Test Org",
 		CreatedAt:        &now,
 		UpdatedAt:        &now,
-		Comments:         []string{"Comment 1", "Comment 2"},
+		Comments: []Comment{
+			{Author: Author{ID: 1, Name: "John Doe", Role: "end-user"}, Body: "Comment 1", Public: true, CreatedAt: now},
+			{Author: Author{ID: 2, Name: "Support Agent", Role: "agent"}, Body: "Comment 2", Public: false, CreatedAt: now},
+		},
 	}
 }
 
@@ -50,12 +63,29 @@ func TestActivity_GenSummary(t *testing.T) {
 	testSuite := testsuite.WorkflowTestSuite{}
 	testEnv := testSuite.NewTestActivityEnvironment()
 
+	validJSON := `{"intent": "Fix login", "summary": "Customer can't log in", "next_step": "Reset password"}`
+	validSummary := TicketSummary{Intent: "Fix login", Summary: "Customer can't log in", NextStep: "Reset password"}
+
+	routes := []config.PromptRoute{
+		{
+			Name:                "billing",
+			Match:               config.RouteMatch{Tags: []string{"billing"}},
+			TicketSummaryPrompt: "billing",
+			SummaryFields:       []config.SummaryField{{Name: "refund_requested", Type: "boolean"}},
+		},
+	}
+	billingTicket := createTestTicket()
+	billingTicket.Tags = []string{"billing", "vip"}
+	billingJSON := `{"intent": "Get refund", "summary": "Charged twice", "next_step": "Refund", "refund_requested": true}`
+
 	// Define test cases
 	testCases := []struct {
 		name           string
 		ticket         Ticket
 		setupMock      func(*MockGenAIAPI)
-		expectedOutput string
+		expectedOutput TicketSummary
+		expectedRaw    string
+		expectedRoute  string
 		expectedError  string
 	}{
 		{
@@ -67,33 +97,150 @@ func TestActivity_GenSummary(t *testing.T) {
 					TicketSummaryPrompt: "test",
 				})
 
-				response := "This is a generated summary"
+				m.On("GenerateContent",
+					mock.Anything,
+					mock.Anything,
+					mock.Anything).Return(validJSON, nil).Once()
+			},
+			expectedOutput: validSummary,
+			expectedRaw:    validJSON,
+		},
+		{
+			name:   "Code Fenced Summary",
+			ticket: createTestTicket(),
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{TicketSummaryPrompt: "test"})
+
+				m.On("GenerateContent",
+					mock.Anything,
+					mock.Anything,
+					mock.Anything).Return("```json\n"+validJSON+"\n```", nil).Once()
+			},
+			expectedOutput: validSummary,
+			expectedRaw:    "```json\n" + validJSON + "\n```",
+		},
+		{
+			name:   "Repaired Summary",
+			ticket: createTestTicket(),
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{TicketSummaryPrompt: "test"})
 
+				// The first response misses next_step
 				m.On("GenerateContent",
 					mock.Anything,
+					"test",
+					mock.MatchedBy(func(content string) bool {
+						return !strings.Contains(content, "Your previous response was invalid")
+					})).Return(`{"intent": "Fix login", "summary": "Customer can't log in"}`, nil).Once()
+
+				// The repair prompt carries the validation error and the schema
+				m.On("GenerateContent",
 					mock.Anything,
-					mock.Anything).Return(response, nil)
+					"test",
+					mock.MatchedBy(func(content string) bool {
+						return strings.Contains(content, "missing required fields: next_step") &&
+							strings.Contains(content, TicketSummarySchema)
+					})).Return(validJSON, nil).Once()
 			},
-			expectedOutput: "This is a generated summary",
+			expectedOutput: validSummary,
+			expectedRaw:    validJSON,
 		},
 		{
-			name:   "Generation API Error",
+			name:   "Invalid After Repairs",
+			ticket: createTestTicket(),
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{TicketSummaryPrompt: "test"})
+
+				m.On("GenerateContent",
+					mock.Anything,
+					mock.Anything,
+					mock.Anything).Return(`{"intent": "Fix login", "unknown": true}`, nil).Times(MaxSummaryRepairs + 1)
+			},
+			expectedError: "failed to generate a valid summary",
+		},
+		{
+			name:   "Routed Summary",
+			ticket: billingTicket,
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{TicketSummaryPrompt: "test", PromptRoutes: routes})
+
+				m.On("GenerateContent", mock.Anything, "billing", mock.Anything).Return(billingJSON, nil).Once()
+			},
+			expectedOutput: TicketSummary{
+				Intent:   "Get refund",
+				Summary:  "Charged twice",
+				NextStep: "Refund",
+				Fields:   map[string]any{"refund_requested": true},
+			},
+			expectedRaw:   billingJSON,
+			expectedRoute: "billing",
+		},
+		{
+			name:   "Routed Summary Repaired",
+			ticket: billingTicket,
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{TicketSummaryPrompt: "test", PromptRoutes: routes})
+
+				// The route's field has the wrong type
+				m.On("GenerateContent",
+					mock.Anything,
+					"billing",
+					mock.MatchedBy(func(content string) bool {
+						return !strings.Contains(content, "Your previous response was invalid")
+					})).Return(`{"intent": "Get refund", "summary": "Charged twice", "next_step": "Refund", "refund_requested": "yes"}`, nil).Once()
+
+				// The repair prompt's schema includes the route's field
+				m.On("GenerateContent",
+					mock.Anything,
+					"billing",
+					mock.MatchedBy(func(content string) bool {
+						return strings.Contains(content, "field refund_requested must be of type boolean") &&
+							strings.Contains(content, `"refund_requested": {`)
+					})).Return(billingJSON, nil).Once()
+			},
+			expectedOutput: TicketSummary{
+				Intent:   "Get refund",
+				Summary:  "Charged twice",
+				NextStep: "Refund",
+				Fields:   map[string]any{"refund_requested": true},
+			},
+			expectedRaw:   billingJSON,
+			expectedRoute: "billing",
+		},
+		{
+			name:   "Unmatched Route Uses Default Prompt",
+			ticket: createTestTicket(),
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{TicketSummaryPrompt: "test", PromptRoutes: routes})
+
+				m.On("GenerateContent", mock.Anything, "test", mock.Anything).Return(validJSON, nil).Once()
+			},
+			expectedOutput: validSummary,
+			expectedRaw:    validJSON,
+		},
+		{
+			name:   "Comments Over The Context Budget",
 			ticket: createTestTicket(),
 			setupMock: func(m *MockGenAIAPI) {
 				m.On("GetConfig").Return(config.AIConfig{
-					LLMModel:            "gemini-2.0-flash",
+					LLMProvider:         "anthropic",
 					TicketSummaryPrompt: "test",
+					ContextBudgets:      map[string]config.ContextBudget{"anthropic": {ContextWindow: 460, OutputReserve: 100}},
 				})
 
+				// Only the most recent comment fits
 				m.On("GenerateContent",
 					mock.Anything,
-					mock.Anything,
-					mock.Anything).Return("", errors.New("API failure"))
+					"test",
+					mock.MatchedBy(func(content string) bool {
+						return !strings.Contains(content, "Comment 1") && strings.Contains(content, "Comment 2")
+					})).Return(validJSON, nil).Once()
 			},
-			expectedError: "failed to generate",
+			expectedOutput: validSummary,
+			expectedRaw:    validJSON,
 		},
 		{
-			name:   "Empty Response",
+			name:   "Generation API Error",
 			ticket: createTestTicket(),
 			setupMock: func(m *MockGenAIAPI) {
 				m.On("GetConfig").Return(config.AIConfig{
@@ -101,14 +248,12 @@ func TestActivity_GenSummary(t *testing.T) {
 					TicketSummaryPrompt: "test",
 				})
 
-				emptyResponse := ""
-
 				m.On("GenerateContent",
 					mock.Anything,
 					mock.Anything,
-					mock.Anything).Return(emptyResponse, nil)
+					mock.Anything).Return("", errors.New("API failure"))
 			},
-			expectedOutput: "",
+			expectedError: "failed to generate",
 		},
 	}
 
@@ -136,9 +281,135 @@ func TestActivity_GenSummary(t *testing.T) {
 				err := future.Get(&output)
 				require.NoError(t, err)
 				assert.Equal(t, tc.expectedOutput, output.Summary)
+				assert.Equal(t, tc.expectedRaw, output.Raw)
+				assert.Equal(t, tc.expectedRoute, output.Route)
 			}
 
 			mockAPI.AssertExpectations(t)
 		})
 	}
 }
+
+func TestCleanse(t *testing.T) {
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	firstResponse := int64(5400)
+	ticket := Ticket{
+		ID:                 12345,
+		Subject:            "Login fails",
+		Comments:           []Comment{{Body: "Can't log in"}},
+		AttachmentSnippets: []AttachmentSnippet{{FileName: "sso.log", Text: "invalid signature"}},
+		HistoryDigest:      "Customer reported SSO errors",
+		NextCursor:         "cursor-1",
+		WrittenAt:          &at,
+		Events:             []TicketEvent{{Type: "status", From: "new", To: "solved-event", At: at}},
+		Summary:            &TicketSummary{Summary: "summary-output"},
+		RawSummary:         "raw-summary-output",
+		Translations:       map[string]TicketSummary{"fr": {Summary: "translated-output"}},
+		Scores:             &Scores{FrustrationTrend: TrendWorsening, Urgency: 0.9},
+		Classification:     map[string]string{"severity": "classified-value"},
+		Resolution:         &Resolution{RootCause: "resolution-root-cause", Fix: "resolution-fix"},
+		SummaryHistory:     []history.Version{{Version: 1, Summary: "history-version"}},
+		Tombstone:          &Tombstone{Reason: "tombstone-reason", At: at},
+		Metrics:            metrics.Ticket{FirstResponseSeconds: &firstResponse, AgentTouches: 3, SolvedAt: &at},
+
+		PublicCommentsSinceNote: 2,
+	}
+
+	ticketJSON, err := json.Marshal(cleanse(ticket))
+	require.NoError(t, err)
+
+	// Only what the workflow derives about the ticket is dropped
+	for _, kept := range []string{"Login fails", "Can't log in", "invalid signature", "Customer reported SSO errors"} {
+		assert.Contains(t, string(ticketJSON), kept)
+	}
+
+	var fields map[string]any
+	require.NoError(t, json.Unmarshal(ticketJSON, &fields))
+	for _, field := range []string{
+		"NextCursor", "WrittenAt", "Events", "Summary", "RawSummary", "Translations", "Scores",
+		"Classification", "Resolution", "SummaryHistory", "Tombstone", "PublicCommentsSinceNote",
+	} {
+		assert.Empty(t, fields[field], field)
+	}
+	assert.Equal(t, map[string]any{"customer_wait_seconds": 0.0, "agent_touches": 0.0, "reopens": 0.0}, fields["Metrics"])
+}
+
+func TestTicketSummaryJSON(t *testing.T) {
+	summary := TicketSummary{
+		Intent:   "Get refund",
+		Summary:  "Charged twice",
+		NextStep: "Refund",
+		Fields:   map[string]any{"refund_requested": true, "amount": 42.5},
+	}
+	assert.Equal(t,
+		`{"intent":"Get refund","summary":"Charged twice","next_step":"Refund","amount":42.5,"refund_requested":true}`,
+		summary.String())
+
+	var decoded TicketSummary
+	require.NoError(t, json.Unmarshal([]byte(summary.String()), &decoded))
+	assert.Equal(t, summary, decoded)
+
+	// Summaries without route fields keep Fields unset
+	require.NoError(t, json.Unmarshal([]byte(`{"intent":"a","summary":"b","next_step":"c"}`), &decoded))
+	assert.Nil(t, decoded.Fields)
+}
+
+func TestParseTicketSummary(t *testing.T) {
+	fields := []config.SummaryField{
+		{Name: "refund_requested", Type: "boolean"},
+		{Name: "amount", Type: "number"},
+		{Name: "products", Type: "array"},
+	}
+
+	testCases := []struct {
+		name          string
+		raw           string
+		fields        []config.SummaryField
+		expectedError string
+	}{
+		{
+			name:   "Valid",
+			raw:    `{"intent": "a", "summary": "b", "next_step": "c", "refund_requested": false, "amount": 0, "products": []}`,
+			fields: fields,
+		},
+		{
+			name:          "Empty Required Field",
+			raw:           `{"intent": "", "summary": "b", "next_step": "c"}`,
+			expectedError: "field intent must not be empty",
+		},
+		{
+			name:          "Missing Fields",
+			raw:           `{"intent": "a", "summary": "b", "refund_requested": true, "amount": 1}`,
+			fields:        fields,
+			expectedError: "missing required fields: next_step, products",
+		},
+		{
+			name:          "Unknown Field",
+			raw:           `{"intent": "a", "summary": "b", "next_step": "c", "refund_requested": true}`,
+			expectedError: `unknown field "refund_requested"`,
+		},
+		{
+			name:          "Route Field Of The Wrong Type",
+			raw:           `{"intent": "a", "summary": "b", "next_step": "c", "refund_requested": true, "amount": "42.50", "products": []}`,
+			fields:        fields,
+			expectedError: "field amount must be of type number",
+		},
+		{
+			name:          "Null Route Field",
+			raw:           `{"intent": "a", "summary": "b", "next_step": "c", "refund_requested": null, "amount": 1, "products": []}`,
+			fields:        fields,
+			expectedError: "field refund_requested must be of type boolean",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseTicketSummary(tc.raw, tc.fields)
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package ticket
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// pipelineChangeID versions the upsert pipeline. Runs started before it was
+// versioned replay the original pipeline, then switch over on their next
+// upsert.
+const pipelineChangeID = "upsert-pipeline"
+
+// historySizeChangeID versions continuing as new once the server suggests it,
+// as the history grows large, rather than only after updatesBeforeContinueAsNew
+const historySizeChangeID = "continue-as-new-suggested"
+
+// configChangeID versions recording the worker's config in the history when
+// a run starts, rather than reading the worker's flags on every replay
+const configChangeID = "record-config"
+
+// UnmarshalJSON also decodes the summary of runs started before summaries
+// were typed, when it was a string and empty until generated
+func (t *Ticket) UnmarshalJSON(data []byte) error {
+	type ticket Ticket
+	decoded := struct {
+		*ticket
+		Summary json.RawMessage
+	}{ticket: (*ticket)(t)}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	t.Summary = nil
+	switch string(decoded.Summary) {
+	case "", "null", `""`:
+		return nil
+	}
+	var summary TicketSummary
+	if err := json.Unmarshal(decoded.Summary, &summary); err != nil {
+		return fmt.Errorf("failed to decode summary: %w", err)
+	}
+	t.Summary = &summary
+	return nil
+}
+
+// UnmarshalJSON also decodes comments fetched before they carried their
+// author and metadata, when they were only their body
+func (c *Comment) UnmarshalJSON(data []byte) error {
+	var body string
+	if err := json.Unmarshal(data, &body); err == nil {
+		*c = Comment{Body: body}
+		return nil
+	}
+
+	type comment Comment
+	return json.Unmarshal(data, (*comment)(c))
+}
+
+// legacyPipeline reports whether the upsert about to be processed is replayed
+// from a run started before the pipeline was versioned. Each upsert is
+// versioned on its own, since a version is fixed for the whole run once
+// looked up.
+func (s *ticketWorkflow) legacyPipeline(updateCount int) bool {
+	changeID := fmt.Sprintf("%s-%d", pipelineChangeID, updateCount)
+	return workflow.GetVersion(s, changeID, workflow.DefaultVersion, 1) == workflow.DefaultVersion
+}
+
+// processLegacyUpsert replays the original pipeline: fetch the ticket once,
+// fetch the next page of comments, summarize and signal the organization
+func (s *ticketWorkflow) processLegacyUpsert(pendingUpsert *UpsertTicketInput) error {
+	if s.ticket.ID == 0 {
+		fetchTicketInput := FetchTicketInput{ID: pendingUpsert.TicketID}
+		fetchTicketOutput := FetchTicketOutput{}
+
+		if err := workflow.ExecuteActivity(s.Context, s.activity.FetchTicket, fetchTicketInput).
+			Get(s.Context, &fetchTicketOutput); err != nil {
+			return err
+		}
+
+		s.ticket = fetchTicketOutput.Ticket
+	}
+
+	fetchCommentsInput := FetchCommentsInput{ID: pendingUpsert.TicketID, Cursor: s.ticket.NextCursor}
+	fetchCommentsOutput := FetchCommentsOutput{}
+
+	if err := workflow.ExecuteActivity(s.Context, s.activity.FetchComments, fetchCommentsInput).
+		Get(s.Context, &fetchCommentsOutput); err != nil {
+		return err
+	}
+
+	if len(fetchCommentsOutput.Comments) != 0 {
+		s.ticket.Comments = fetchCommentsOutput.Comments
+		s.ticket.NextCursor = fetchCommentsOutput.NextCursor
+	}
+
+	genSummaryInput := GenSummaryInput{Ticket: s.ticket}
+	genSummaryOutput := GenSummaryOutput{}
+
+	if err := workflow.ExecuteActivity(s.Context, s.activity.GenTicketSummary, genSummaryInput).
+		Get(s.Context, &genSummaryOutput); err != nil {
+		return err
+	}
+
+	if genSummaryOutput.Summary.Summary != "" {
+		s.ticket.Summary = &genSummaryOutput.Summary
+	}
+
+	if s.ticket.OrganizationID == 0 || s.ticket.Summary == nil {
+		return nil
+	}
+
+	signalOrganizationInput := SignalOrganizationInput{
+		OrganizationID: s.ticket.OrganizationID,
+		TicketID:       s.ticket.ID,
+		TicketSummary:  s.ticket.Summary.String(),
+	}
+
+	return workflow.ExecuteActivity(s.Context, s.activity.SignalOrganization, signalOrganizationInput).
+		Get(s.Context, nil)
+}
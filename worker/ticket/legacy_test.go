@@ -0,0 +1,60 @@
+package ticket
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/worker"
+)
+
+// baseline_history.json was recorded from the original pipeline: two upserts
+// of a ticket whose comments were strings and whose summary was a string
+func TestReplayBaselineHistory(t *testing.T) {
+	replayer := worker.NewWorkflowReplayer()
+	replayer.RegisterWorkflow(TicketWorkflow)
+
+	require.NoError(t, replayer.ReplayWorkflowHistoryFromJSONFile(nil, "testdata/baseline_history.json"))
+}
+
+func TestUnmarshalLegacyTicket(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected Ticket
+	}{
+		{
+			name:  "Legacy Comments And Summary",
+			input: `{"ID":12345,"Comments":["I can't log in","Try resetting"],"NextCursor":"cursor-1","Summary":"Customer can't log in"}`,
+			expected: Ticket{
+				ID:         12345,
+				Comments:   []Comment{{Body: "I can't log in"}, {Body: "Try resetting"}},
+				NextCursor: "cursor-1",
+				Summary:    &TicketSummary{Summary: "Customer can't log in"},
+			},
+		},
+		{
+			name:     "Legacy Ticket Not Summarized Yet",
+			input:    `{"ID":12345,"Comments":null,"Summary":""}`,
+			expected: Ticket{ID: 12345},
+		},
+		{
+			name:  "Current Ticket",
+			input: `{"ID":12345,"Comments":[{"ID":1,"Body":"Hello","Public":true}],"Summary":{"intent":"Login","summary":"Login fails","next_step":"Reset"}}`,
+			expected: Ticket{
+				ID:       12345,
+				Comments: []Comment{{ID: 1, Body: "Hello", Public: true}},
+				Summary:  &TicketSummary{Intent: "Login", Summary: "Login fails", NextStep: "Reset"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var ticket Ticket
+			require.NoError(t, json.Unmarshal([]byte(tc.input), &ticket))
+			assert.Equal(t, tc.expected, ticket)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package ticket
+
+import (
+	"time"
+
+	"github.com/taonic/ticketfu/worker/metrics"
+)
+
+// RoleEndUser is the Zendesk role of customers. Agents and admins have other roles.
+const RoleEndUser = "end-user"
+
+// trackComments updates the metrics with the public comments of the thread.
+// Private notes don't affect what the customer sees. When the comments open
+// the thread, an agent's first comment is the ticket's description rather
+// than a reply, so first response is measured from the agent's next reply.
+func trackComments(m *metrics.Ticket, createdAt *time.Time, comments []Comment, opening bool) {
+	var created time.Time
+	if createdAt != nil {
+		created = *createdAt
+	}
+
+	for i, comment := range comments {
+		if !comment.Public || comment.Author.Role == "" {
+			continue
+		}
+
+		if comment.Author.Role == RoleEndUser {
+			m.CustomerComment(comment.CreatedAt)
+		} else if !opening || i > 0 {
+			m.AgentReply(comment.CreatedAt, created)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package ticket
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	gozendesk "github.com/nukosuke/go-zendesk/zendesk"
+	"github.com/taonic/ticketfu/zendesk"
+)
+
+type (
+	PostSummaryNoteInput struct {
+		TicketID int64
+		Summary  TicketSummary
+	}
+
+	PostSummaryNoteOutput struct {
+		UpdatedAt *time.Time // When posting the note updated the ticket
+	}
+)
+
+// PostSummaryNote posts the summary to the ticket as a private internal note
+func (a *Activity) PostSummaryNote(ctx context.Context, input PostSummaryNoteInput) (*PostSummaryNoteOutput, error) {
+	public := false
+	note := gozendesk.TicketComment{
+		Body:   noteBody(input.Summary),
+		Public: &public,
+	}
+
+	ticket, err := a.zClient.UpdateTicket(ctx, input.TicketID, gozendesk.Ticket{Comment: &note})
+	if err != nil {
+		return nil, fmt.Errorf("failed to post summary note: %w", err)
+	}
+
+	return &PostSummaryNoteOutput{UpdatedAt: ticket.UpdatedAt}, nil
+}
+
+func noteBody(summary TicketSummary) string {
+	var b strings.Builder
+	b.WriteString(zendesk.NoteMarker + "\n\n")
+	fmt.Fprintf(&b, "Intent: %s\n", summary.Intent)
+	fmt.Fprintf(&b, "Summary: %s\n", summary.Summary)
+	fmt.Fprintf(&b, "Next step: %s", summary.NextStep)
+	return b.String()
+}
@@ -0,0 +1,75 @@
+package ticket
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nukosuke/go-zendesk/zendesk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	zd "github.com/taonic/ticketfu/zendesk"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestPostSummaryNote(t *testing.T) {
+	testSuite := testsuite.WorkflowTestSuite{}
+	testEnv := testSuite.NewTestActivityEnvironment()
+
+	summary := TicketSummary{Intent: "Restore access", Summary: "SSO login fails", NextStep: "Rotate the IdP certificate"}
+	updatedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name           string
+		setupMock      func(*zd.MockZendeskClient)
+		expectedOutput *PostSummaryNoteOutput
+		expectedError  string
+	}{
+		{
+			name: "Successful Post",
+			setupMock: func(m *zd.MockZendeskClient) {
+				m.On("UpdateTicket", mock.Anything, int64(12345), mock.MatchedBy(func(ticket zendesk.Ticket) bool {
+					comment := ticket.Comment
+					return comment != nil && comment.Public != nil && !*comment.Public &&
+						strings.HasPrefix(comment.Body, zd.NoteMarker) &&
+						strings.Contains(comment.Body, "Next step: Rotate the IdP certificate")
+				})).Return(zendesk.Ticket{ID: 12345, UpdatedAt: &updatedAt}, nil).Once()
+			},
+			expectedOutput: &PostSummaryNoteOutput{UpdatedAt: &updatedAt},
+		},
+		{
+			name: "API Error",
+			setupMock: func(m *zd.MockZendeskClient) {
+				m.On("UpdateTicket", mock.Anything, int64(12345), mock.Anything).
+					Return(zendesk.Ticket{}, errors.New("API error")).Once()
+			},
+			expectedError: "failed to post summary note",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockClient := new(zd.MockZendeskClient)
+			tc.setupMock(mockClient)
+
+			activity := &Activity{zClient: mockClient}
+			testEnv.RegisterActivity(activity.PostSummaryNote)
+
+			result, err := testEnv.ExecuteActivity(activity.PostSummaryNote, PostSummaryNoteInput{TicketID: 12345, Summary: summary})
+
+			if tc.expectedError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedError)
+			} else {
+				require.NoError(t, err)
+				var output PostSummaryNoteOutput
+				require.NoError(t, result.Get(&output))
+				assert.Equal(t, tc.expectedOutput, &output)
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
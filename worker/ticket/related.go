@@ -0,0 +1,133 @@
+package ticket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/taonic/ticketfu/genai"
+	"github.com/taonic/ticketfu/worker/related"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+const (
+	// DefaultRelatedTickets is the number of related tickets returned unless
+	// a limit is requested
+	DefaultRelatedTickets = 5
+	// MaxRelatedTickets bounds the related tickets returned
+	MaxRelatedTickets = 50
+)
+
+type (
+	IndexSummaryInput struct {
+		TicketID       int64
+		OrganizationID int64
+		Summary        TicketSummary
+	}
+
+	UnindexTicketInput struct {
+		TicketID int64
+	}
+
+	FindRelatedTicketsInput struct {
+		TicketID       int64
+		OrganizationID int64
+		Summary        TicketSummary
+		Limit          int
+	}
+
+	FindRelatedTicketsOutput struct {
+		Tickets []related.Match
+	}
+)
+
+// IndexSummary embeds the ticket's summary into the related tickets index.
+// Providers without an embedding API leave the index empty.
+func (a *Activity) IndexSummary(ctx context.Context, input IndexSummaryInput) error {
+	_, err := a.indexSummary(ctx, input.TicketID, input.OrganizationID, input.Summary)
+	if errors.Is(err, genai.ErrEmbeddingUnsupported) {
+		activity.GetLogger(ctx).Debug("Skipped indexing summary", "ticket-id", input.TicketID, "error", err)
+		return nil
+	}
+	return err
+}
+
+// UnindexTicket removes a ticket that went away from the related tickets index
+func (a *Activity) UnindexTicket(ctx context.Context, input UnindexTicketInput) error {
+	if err := a.index.Delete(input.TicketID); err != nil {
+		return fmt.Errorf("failed to unindex ticket: %w", err)
+	}
+	return nil
+}
+
+// FindRelatedTickets returns the indexed tickets across all organizations
+// whose summaries are most similar to the ticket's. A summary missing from
+// the index, e.g. generated before indexing was enabled, is embedded first.
+func (a *Activity) FindRelatedTickets(ctx context.Context, input FindRelatedTicketsInput) (*FindRelatedTicketsOutput, error) {
+	entry, ok := a.index.Get(input.TicketID)
+	if !ok || entry.Summary != input.Summary.String() {
+		var err error
+		entry, err = a.indexSummary(ctx, input.TicketID, input.OrganizationID, input.Summary)
+		if errors.Is(err, genai.ErrEmbeddingUnsupported) {
+			return nil, temporal.NewNonRetryableApplicationError(err.Error(), "EmbeddingUnsupported", err)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &FindRelatedTicketsOutput{Tickets: a.index.Search(entry.Vector, input.Limit, input.TicketID)}, nil
+}
+
+func (a *Activity) indexSummary(ctx context.Context, ticketID, organizationID int64, summary TicketSummary) (related.Entry, error) {
+	vector, err := a.genAPI.Embed(ctx, embeddingContent(summary))
+	if err != nil {
+		return related.Entry{}, err
+	}
+
+	entry := related.Entry{
+		TicketID:       ticketID,
+		OrganizationID: organizationID,
+		Summary:        summary.String(),
+		Vector:         vector,
+		UpdatedAt:      activity.GetInfo(ctx).StartedTime,
+	}
+	if err := a.index.Upsert(entry); err != nil {
+		return related.Entry{}, fmt.Errorf("failed to index summary: %w", err)
+	}
+	return entry, nil
+}
+
+// embeddingContent is the part of the summary describing the problem. The
+// next step says more about the ticket's progress than what it's about.
+func embeddingContent(summary TicketSummary) string {
+	return summary.Intent + "\n" + summary.Summary
+}
+
+// RelatedTicketsWorkflow looks up the tickets related to the one described
+// by the input, as returned by QueryRelatedTicketsLookup. It runs the lookup
+// on the worker serving the index apart from the ticket's workflow, so reads
+// don't add to its history.
+func RelatedTicketsWorkflow(ctx workflow.Context, input FindRelatedTicketsInput) (RelatedTicketsOutput, error) {
+	ctx = indexContext(workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 30 * time.Second,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    time.Minute,
+			MaximumAttempts:    3,
+		},
+	}))
+
+	var a *Activity
+	findRelatedTicketsOutput := FindRelatedTicketsOutput{}
+	if err := workflow.ExecuteActivity(ctx, a.FindRelatedTickets, input).
+		Get(ctx, &findRelatedTicketsOutput); err != nil {
+		return RelatedTicketsOutput{}, err
+	}
+
+	return RelatedTicketsOutput{Tickets: findRelatedTicketsOutput.Tickets}, nil
+}
@@ -0,0 +1,168 @@
+package ticket
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/taonic/ticketfu/config"
+	"github.com/taonic/ticketfu/genai"
+	"github.com/taonic/ticketfu/worker/related"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func newTestIndex(t *testing.T) *related.Index {
+	index, err := related.NewIndex(config.WorkerConfig{EmbeddingIndexPath: filepath.Join(t.TempDir(), "embeddings.json")})
+	require.NoError(t, err)
+	return index
+}
+
+func TestIndexSummary(t *testing.T) {
+	testSuite := testsuite.WorkflowTestSuite{}
+	testEnv := testSuite.NewTestActivityEnvironment()
+
+	summary := TicketSummary{Intent: "Log in", Summary: "Login keeps failing", NextStep: "Reset password"}
+
+	testCases := []struct {
+		name          string
+		setupMock     func(*MockGenAIAPI)
+		expectIndexed bool
+		expectedError string
+	}{
+		{
+			name: "Indexed",
+			setupMock: func(m *MockGenAIAPI) {
+				// The next step isn't embedded
+				m.On("Embed", mock.Anything, "Log in\nLogin keeps failing").Return([]float32{1, 0}, nil).Once()
+			},
+			expectIndexed: true,
+		},
+		{
+			name: "Provider Without Embeddings",
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("Embed", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("%w: anthropic", genai.ErrEmbeddingUnsupported)).Once()
+			},
+		},
+		{
+			name: "Embedding API Error",
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("Embed", mock.Anything, mock.Anything).Return(nil, errors.New("API failure"))
+			},
+			expectedError: "API failure",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockAPI := new(MockGenAIAPI)
+			tc.setupMock(mockAPI)
+
+			index := newTestIndex(t)
+			activity := &Activity{genAPI: mockAPI, index: index}
+			testEnv.RegisterActivity(activity.IndexSummary)
+
+			_, err := testEnv.ExecuteActivity(activity.IndexSummary, IndexSummaryInput{TicketID: 12345, OrganizationID: 101, Summary: summary})
+
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+
+			entry, ok := index.Get(12345)
+			assert.Equal(t, tc.expectIndexed, ok)
+			if tc.expectIndexed {
+				assert.Equal(t, int64(101), entry.OrganizationID)
+				assert.Equal(t, summary.String(), entry.Summary)
+				assert.Equal(t, []float32{1, 0}, entry.Vector)
+			}
+
+			mockAPI.AssertExpectations(t)
+		})
+	}
+}
+
+func TestFindRelatedTickets(t *testing.T) {
+	testSuite := testsuite.WorkflowTestSuite{}
+	testEnv := testSuite.NewTestActivityEnvironment()
+
+	summary := TicketSummary{Intent: "Log in", Summary: "Login keeps failing"}
+
+	seed := func(t *testing.T, index *related.Index) {
+		require.NoError(t, index.Upsert(related.Entry{TicketID: 1, OrganizationID: 202, Summary: "login", Vector: []float32{1, 0.1}}))
+		require.NoError(t, index.Upsert(related.Entry{TicketID: 2, OrganizationID: 303, Summary: "billing", Vector: []float32{0, 1}}))
+	}
+
+	testCases := []struct {
+		name          string
+		setup         func(*testing.T, *related.Index, *MockGenAIAPI)
+		expected      []int64
+		expectedError string
+	}{
+		{
+			name: "Indexed Summary",
+			setup: func(t *testing.T, index *related.Index, m *MockGenAIAPI) {
+				seed(t, index)
+				require.NoError(t, index.Upsert(related.Entry{TicketID: 12345, Summary: summary.String(), Vector: []float32{1, 0}}))
+			},
+			expected: []int64{1, 2},
+		},
+		{
+			name: "Summary Missing From Index",
+			setup: func(t *testing.T, index *related.Index, m *MockGenAIAPI) {
+				seed(t, index)
+				m.On("Embed", mock.Anything, mock.Anything).Return([]float32{0, 1}, nil).Once()
+			},
+			expected: []int64{2, 1},
+		},
+		{
+			name: "Stale Summary",
+			setup: func(t *testing.T, index *related.Index, m *MockGenAIAPI) {
+				seed(t, index)
+				require.NoError(t, index.Upsert(related.Entry{TicketID: 12345, Summary: "old", Vector: []float32{1, 0}}))
+				m.On("Embed", mock.Anything, mock.Anything).Return([]float32{0, 1}, nil).Once()
+			},
+			expected: []int64{2, 1},
+		},
+		{
+			name: "Provider Without Embeddings",
+			setup: func(t *testing.T, index *related.Index, m *MockGenAIAPI) {
+				m.On("Embed", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("%w: anthropic", genai.ErrEmbeddingUnsupported)).Once()
+			},
+			expectedError: "embeddings are not supported",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockAPI := new(MockGenAIAPI)
+			index := newTestIndex(t)
+			tc.setup(t, index, mockAPI)
+
+			activity := &Activity{genAPI: mockAPI, index: index}
+			testEnv.RegisterActivity(activity.FindRelatedTickets)
+
+			future, err := testEnv.ExecuteActivity(activity.FindRelatedTickets, FindRelatedTicketsInput{TicketID: 12345, Summary: summary, Limit: 5})
+
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+			} else {
+				require.NoError(t, err)
+				var output FindRelatedTicketsOutput
+				require.NoError(t, future.Get(&output))
+
+				var ids []int64
+				for _, match := range output.Tickets {
+					ids = append(ids, match.TicketID)
+				}
+				assert.Equal(t, tc.expected, ids)
+			}
+
+			mockAPI.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package ticket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/taonic/ticketfu/genai"
+	"go.temporal.io/sdk/activity"
+)
+
+const (
+	TrendImproving = "improving"
+	TrendStable    = "stable"
+	TrendWorsening = "worsening"
+)
+
+type (
+	// Scores rate the customer's state on a ticket
+	Scores struct {
+		Sentiment        float64 `json:"sentiment"`         // -1 (very negative) to 1 (very positive)
+		FrustrationTrend string  `json:"frustration_trend"` // improving, stable or worsening
+		Urgency          float64 `json:"urgency"`           // 0 (not urgent) to 1 (critical)
+	}
+
+	ScoreTicketInput struct {
+		Ticket Ticket
+	}
+
+	ScoreTicketOutput struct {
+		Scores Scores
+	}
+)
+
+func (a *Activity) ScoreTicket(ctx context.Context, input ScoreTicketInput) (*ScoreTicketOutput, error) {
+	cfg := a.genAPI.GetConfig()
+	ticket, dropped, err := fitComments(genai.NewBudget(cfg), cfg.ScoringPrompt, cleanse(input.Ticket))
+	if err != nil {
+		return nil, err
+	}
+	if dropped > 0 {
+		activity.GetLogger(ctx).Debug("Dropped older comments over the context budget", "ticket-id", input.Ticket.ID, "dropped", dropped)
+	}
+
+	ticketJSON, err := json.Marshal(ticket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ticket to JSON: %w", err)
+	}
+
+	result, err := a.genAPI.GenerateContent(ctx, cfg.ScoringPrompt, string(ticketJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate %w", err)
+	}
+
+	var scores Scores
+	if err := json.Unmarshal([]byte(trimCodeFence(result)), &scores); err != nil {
+		return nil, fmt.Errorf("failed to parse scores: %w", err)
+	}
+
+	if err := scores.validate(); err != nil {
+		return nil, fmt.Errorf("invalid scores: %w", err)
+	}
+
+	return &ScoreTicketOutput{Scores: scores}, nil
+}
+
+func (s Scores) validate() error {
+	if s.Sentiment < -1 || s.Sentiment > 1 {
+		return fmt.Errorf("sentiment %v out of range [-1, 1]", s.Sentiment)
+	}
+	if s.Urgency < 0 || s.Urgency > 1 {
+		return fmt.Errorf("urgency %v out of range [0, 1]", s.Urgency)
+	}
+	if !slices.Contains([]string{TrendImproving, TrendStable, TrendWorsening}, s.FrustrationTrend) {
+		return fmt.Errorf("unknown frustration trend %q", s.FrustrationTrend)
+	}
+	return nil
+}
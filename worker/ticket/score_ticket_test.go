@@ -0,0 +1,120 @@
+package ticket
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/taonic/ticketfu/config"
+	"github.com/taonic/ticketfu/genai"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestScoreTicket(t *testing.T) {
+	testSuite := testsuite.WorkflowTestSuite{}
+	testEnv := testSuite.NewTestActivityEnvironment()
+
+	ticket := Ticket{ID: 12345, Subject: "Outage", Comments: []Comment{{Body: "This is the third time this week!"}}}
+
+	// Only the latest comment fits the budget
+	long := ticket
+	long.Comments = []Comment{{Body: strings.Repeat("a", 10_000)}, {Body: "This is the third time this week!"}}
+
+	testCases := []struct {
+		name           string
+		ticket         *Ticket
+		setupMock      func(*MockGenAIAPI)
+		expectedOutput Scores
+		expectedError  string
+	}{
+		{
+			name: "Successful Scoring",
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{ScoringPrompt: "score"})
+				m.On("GenerateContent", mock.Anything, "score", mock.Anything).
+					Return("```json\n{\"sentiment\": -0.6, \"frustration_trend\": \"worsening\", \"urgency\": 0.7}\n```", nil)
+			},
+			expectedOutput: Scores{Sentiment: -0.6, FrustrationTrend: TrendWorsening, Urgency: 0.7},
+		},
+		{
+			name:   "Older Comments Over The Budget",
+			ticket: &long,
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{
+					ScoringPrompt:  "score",
+					LLMProvider:    genai.Anthropic,
+					ContextBudgets: map[string]config.ContextBudget{genai.Anthropic: {ContextWindow: 2_000, OutputReserve: 100}},
+				})
+				m.On("GenerateContent", mock.Anything, "score", mock.MatchedBy(func(content string) bool {
+					return !strings.Contains(content, "aaaa") && strings.Contains(content, "third time")
+				})).Return(`{"sentiment": -0.6, "frustration_trend": "worsening", "urgency": 0.7}`, nil)
+			},
+			expectedOutput: Scores{Sentiment: -0.6, FrustrationTrend: TrendWorsening, Urgency: 0.7},
+		},
+		{
+			name: "Out Of Range",
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{ScoringPrompt: "score"})
+				m.On("GenerateContent", mock.Anything, mock.Anything, mock.Anything).
+					Return(`{"sentiment": -3, "frustration_trend": "stable", "urgency": 0.1}`, nil)
+			},
+			expectedError: "invalid scores",
+		},
+		{
+			name: "Unknown Trend",
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{ScoringPrompt: "score"})
+				m.On("GenerateContent", mock.Anything, mock.Anything, mock.Anything).
+					Return(`{"sentiment": 0, "frustration_trend": "furious", "urgency": 0.1}`, nil)
+			},
+			expectedError: "unknown frustration trend",
+		},
+		{
+			name: "Invalid JSON",
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{ScoringPrompt: "score"})
+				m.On("GenerateContent", mock.Anything, mock.Anything, mock.Anything).Return("not json", nil)
+			},
+			expectedError: "failed to parse scores",
+		},
+		{
+			name: "Generation API Error",
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{ScoringPrompt: "score"})
+				m.On("GenerateContent", mock.Anything, mock.Anything, mock.Anything).Return("", errors.New("API failure"))
+			},
+			expectedError: "failed to generate",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockAPI := new(MockGenAIAPI)
+			tc.setupMock(mockAPI)
+
+			activity := &Activity{genAPI: mockAPI}
+			testEnv.RegisterActivity(activity.ScoreTicket)
+
+			input := ScoreTicketInput{Ticket: ticket}
+			if tc.ticket != nil {
+				input.Ticket = *tc.ticket
+			}
+			future, err := testEnv.ExecuteActivity(activity.ScoreTicket, input)
+
+			if tc.expectedError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedError)
+			} else {
+				require.NoError(t, err)
+				var output ScoreTicketOutput
+				require.NoError(t, future.Get(&output))
+				assert.Equal(t, tc.expectedOutput, output.Scores)
+			}
+
+			mockAPI.AssertExpectations(t)
+		})
+	}
+}
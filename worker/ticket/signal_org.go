@@ -2,9 +2,12 @@ package ticket
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/taonic/ticketfu/worker/metrics"
 	"github.com/taonic/ticketfu/worker/org"
+	"go.temporal.io/api/serviceerror"
 	"go.temporal.io/sdk/activity"
 	"go.temporal.io/sdk/client"
 )
@@ -13,12 +16,14 @@ type SignalOrganizationInput struct {
 	OrganizationID int64
 	TicketID       int64
 	TicketSummary  string
+	Metrics        *metrics.Ticket
 }
 
 type UpdateOrganizationSignal struct {
 	OrganizationID int64
 	TicketID       int64
 	TicketSummary  string
+	Metrics        *metrics.Ticket
 }
 
 func (a *Activity) SignalOrganization(ctx context.Context, input SignalOrganizationInput) error {
@@ -33,6 +38,7 @@ func (a *Activity) SignalOrganization(ctx context.Context, input SignalOrganizat
 		OrganizationID: input.OrganizationID,
 		TicketID:       input.TicketID,
 		TicketSummary:  input.TicketSummary,
+		Metrics:        input.Metrics,
 	}
 
 	_, err := a.tClient.SignalWithStartWorkflow(ctx,
@@ -49,3 +55,66 @@ func (a *Activity) SignalOrganization(ctx context.Context, input SignalOrganizat
 
 	return nil
 }
+
+type EscalateOrganizationInput struct {
+	OrganizationID int64
+	Escalation     org.Escalation
+}
+
+func (a *Activity) EscalateOrganization(ctx context.Context, input EscalateOrganizationInput) error {
+	workflowID := fmt.Sprintf(org.OrganizationWorkflowIDTemplate, fmt.Sprintf("%d", input.OrganizationID))
+
+	workflowOptions := client.StartWorkflowOptions{
+		ID:        workflowID,
+		TaskQueue: activity.GetInfo(ctx).TaskQueue,
+	}
+
+	signalPayload := org.EscalateTicketInput{
+		OrganizationID: input.OrganizationID,
+		Escalation:     input.Escalation,
+	}
+
+	_, err := a.tClient.SignalWithStartWorkflow(ctx,
+		workflowID,
+		org.EscalateTicketSignal,
+		signalPayload,
+		workflowOptions,
+		org.OrganizationWorkflow,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to signal org workflow: %w", err)
+	}
+
+	return nil
+}
+
+type RemoveFromOrganizationInput struct {
+	OrganizationID int64
+	TicketID       int64
+	MergedInto     int64
+}
+
+// RemoveFromOrganization tells the org workflow to drop the ticket, or
+// redirect it to the ticket it was merged into
+func (a *Activity) RemoveFromOrganization(ctx context.Context, input RemoveFromOrganizationInput) error {
+	workflowID := fmt.Sprintf(org.OrganizationWorkflowIDTemplate, fmt.Sprintf("%d", input.OrganizationID))
+
+	signalPayload := org.RemoveTicketInput{
+		OrganizationID: input.OrganizationID,
+		TicketID:       input.TicketID,
+		MergedInto:     input.MergedInto,
+	}
+
+	err := a.tClient.SignalWorkflow(ctx, workflowID, "", org.RemoveTicketSignal, signalPayload)
+	if err != nil {
+		// Without a running org workflow there's nothing to remove
+		var notFound *serviceerror.NotFound
+		if errors.As(err, &notFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to signal org workflow: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package ticket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"unicode/utf8"
+
+	"github.com/taonic/ticketfu/genai"
+	"go.temporal.io/sdk/temporal"
+)
+
+// DefaultChunkTokens is the estimated tokens of comments summarized together
+// in map-reduce mode unless configured
+const DefaultChunkTokens = 8_000
+
+type (
+	// ChunkSummary is the LLM generated summary of consecutive comments
+	ChunkSummary struct {
+		FirstCommentID int64
+		LastCommentID  int64
+		Summary        string
+	}
+
+	SummarizeChunkInput struct {
+		TicketID int64
+		Subject  string
+		Comments []Comment
+
+		// Earlier chunk summaries to combine into one, oldest first
+		Summaries []string
+	}
+
+	SummarizeChunkOutput struct {
+		Summary string
+	}
+)
+
+// SummarizeChunk summarizes a chunk of a long thread, or earlier chunk
+// summaries, to be combined with the other chunks into the ticket summary
+func (a *Activity) SummarizeChunk(ctx context.Context, input SummarizeChunkInput) (*SummarizeChunkOutput, error) {
+	cfg := a.genAPI.GetConfig()
+	inputJSON, err := fitChunk(genai.NewBudget(cfg), cfg.ChunkSummaryPrompt, input)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := a.genAPI.GenerateContent(ctx, cfg.ChunkSummaryPrompt, inputJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate %w", err)
+	}
+
+	return &SummarizeChunkOutput{Summary: result}, nil
+}
+
+// unchunkedComments returns the comments after the last chunk summarized.
+// Comment IDs grow over time, so this holds after older comments were
+// compacted out of the thread.
+func unchunkedComments(comments []Comment, chunks []ChunkSummary) []Comment {
+	if len(chunks) == 0 {
+		return comments
+	}
+
+	last := chunks[len(chunks)-1].LastCommentID
+	start := slices.IndexFunc(comments, func(comment Comment) bool { return comment.ID > last })
+	if start == -1 {
+		return nil
+	}
+	return comments[start:]
+}
+
+// fitChunk returns the chunk as JSON, halving its longest comment or summary
+// until it fits the budget beside the prompt. Chunks are sized by estimated
+// tokens, which a single long comment or the model's tokenizer can exceed.
+func fitChunk(budget genai.Budget, prompt string, input SummarizeChunkInput) (string, error) {
+	input.Comments = slices.Clone(input.Comments)
+	input.Summaries = slices.Clone(input.Summaries)
+
+	texts := []*string{&input.Subject}
+	for i := range input.Comments {
+		texts = append(texts, &input.Comments[i].Body)
+	}
+	for i := range input.Summaries {
+		texts = append(texts, &input.Summaries[i])
+	}
+
+	inputJSON, err := fitHalving(budget, prompt, &input, texts)
+	if err != nil {
+		return "", err
+	}
+	if inputJSON == "" {
+		return "", temporal.NewNonRetryableApplicationError("chunk doesn't fit the context budget", "OverContextBudget", nil)
+	}
+	return inputJSON, nil
+}
+
+// fitHalving returns v as JSON, halving the longest of its texts until it
+// fits the budget beside the prompt. It returns an empty string when it
+// doesn't fit with all of them cut.
+func fitHalving(budget genai.Budget, prompt string, v any, texts []*string) (string, error) {
+	available := budget.Allocate(prompt, "").Content
+
+	for {
+		vJSON, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal comments to JSON: %w", err)
+		}
+		if budget.CountTokens(string(vJSON)) <= available {
+			return string(vJSON), nil
+		}
+
+		var longest *string
+		for _, text := range texts {
+			if longest == nil || len(*text) > len(*longest) {
+				longest = text
+			}
+		}
+		if longest == nil || *longest == "" {
+			return "", nil
+		}
+		*longest = halve(*longest)
+	}
+}
+
+// halve cuts the text to half its length, on a rune boundary
+func halve(text string) string {
+	cut := len(text) / 2
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut]
+}
+
+// chunkComments splits comments into chunks of up to limit estimated tokens,
+// leaving the latest chunk out until a comment follows that doesn't fit in
+// it. A comment over the limit makes a chunk of its own.
+func chunkComments(comments []Comment, limit int) [][]Comment {
+	var chunks [][]Comment
+	start, used := 0, 0
+	for i, comment := range comments {
+		tokens := genai.EstimateTokens(comment.Body)
+		if i > start && used+tokens > limit {
+			chunks = append(chunks, comments[start:i])
+			start, used = i, 0
+		}
+		used += tokens
+	}
+	return chunks
+}
+
+// chunkSummariesTokens estimates the tokens of the chunk summaries
+func chunkSummariesTokens(chunks []ChunkSummary) int {
+	tokens := 0
+	for _, chunk := range chunks {
+		tokens += genai.EstimateTokens(chunk.Summary)
+	}
+	return tokens
+}
@@ -0,0 +1,142 @@
+package ticket
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/taonic/ticketfu/config"
+	"github.com/taonic/ticketfu/genai"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestSummarizeChunk(t *testing.T) {
+	testSuite := testsuite.WorkflowTestSuite{}
+	testEnv := testSuite.NewTestActivityEnvironment()
+
+	testCases := []struct {
+		name            string
+		setupMock       func(*MockGenAIAPI)
+		input           SummarizeChunkInput
+		expectedSummary string
+		expectedError   string
+	}{
+		{
+			name: "Successful Summary",
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{ChunkSummaryPrompt: "chunk"})
+				m.On("GenerateContent", mock.Anything, "chunk", mock.MatchedBy(func(content string) bool {
+					return strings.Contains(content, "Login fails") && strings.Contains(content, "Comment 1")
+				})).Return("Chunk summary", nil)
+			},
+			expectedSummary: "Chunk summary",
+		},
+		{
+			name: "Long Comment Cut To The Budget",
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{
+					ChunkSummaryPrompt: "chunk",
+					LLMProvider:        genai.Anthropic,
+					ContextBudgets:     map[string]config.ContextBudget{genai.Anthropic: {ContextWindow: 1_000, OutputReserve: 100}},
+				})
+				m.On("GenerateContent", mock.Anything, "chunk", mock.MatchedBy(func(content string) bool {
+					return genai.EstimateTokens(content) <= 900 && strings.Contains(content, "Comment 2")
+				})).Return("Chunk summary", nil)
+			},
+			input: SummarizeChunkInput{
+				TicketID: 12345,
+				Subject:  "Login fails",
+				Comments: []Comment{{ID: 1, Body: strings.Repeat("a", 10_000)}, {ID: 2, Body: "Comment 2"}},
+			},
+			expectedSummary: "Chunk summary",
+		},
+		{
+			name: "Generation API Error",
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{ChunkSummaryPrompt: "chunk"})
+				m.On("GenerateContent", mock.Anything, mock.Anything, mock.Anything).Return("", errors.New("API failure"))
+			},
+			expectedError: "failed to generate",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockAPI := new(MockGenAIAPI)
+			tc.setupMock(mockAPI)
+
+			activity := &Activity{genAPI: mockAPI}
+			testEnv.RegisterActivity(activity.SummarizeChunk)
+
+			input := tc.input
+			if input.TicketID == 0 {
+				input = SummarizeChunkInput{
+					TicketID: 12345,
+					Subject:  "Login fails",
+					Comments: []Comment{{ID: 1, Body: "Comment 1"}, {ID: 2, Body: "Comment 2"}},
+				}
+			}
+			future, err := testEnv.ExecuteActivity(activity.SummarizeChunk, input)
+
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+			} else {
+				require.NoError(t, err)
+				var output SummarizeChunkOutput
+				require.NoError(t, future.Get(&output))
+				assert.Equal(t, tc.expectedSummary, output.Summary)
+			}
+
+			mockAPI.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUnchunkedComments(t *testing.T) {
+	comments := []Comment{{ID: 3}, {ID: 4}, {ID: 5}}
+
+	tests := []struct {
+		name     string
+		chunks   []ChunkSummary
+		expected []Comment
+	}{
+		{name: "No Chunks", expected: comments},
+		{name: "After Last Chunk", chunks: []ChunkSummary{{FirstCommentID: 1, LastCommentID: 2}, {FirstCommentID: 3, LastCommentID: 4}}, expected: comments[2:]},
+		{name: "Chunked Comments Compacted Away", chunks: []ChunkSummary{{FirstCommentID: 1, LastCommentID: 2}}, expected: comments},
+		{name: "Everything Chunked", chunks: []ChunkSummary{{FirstCommentID: 3, LastCommentID: 5}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, unchunkedComments(comments, tt.chunks))
+		})
+	}
+}
+
+func TestChunkComments(t *testing.T) {
+	// Each comment is estimated at 3 tokens
+	comments := []Comment{{ID: 1, Body: "Comment 1"}, {ID: 2, Body: "Comment 2"}, {ID: 3, Body: "Comment 3"}}
+	long := Comment{ID: 4, Body: strings.Repeat("a", 30)}
+
+	tests := []struct {
+		name     string
+		comments []Comment
+		limit    int
+		expected [][]Comment
+	}{
+		{name: "No Comments", limit: 6},
+		{name: "Latest Chunk Left Out", comments: comments, limit: 6, expected: [][]Comment{comments[:2]}},
+		{name: "Everything Fits One Chunk", comments: comments, limit: 9},
+		{name: "Long Comment Alone", comments: append(slices.Clone(comments[:1]), long, comments[2]), limit: 6, expected: [][]Comment{{comments[0]}, {long}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, chunkComments(tt.comments, tt.limit))
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package ticket
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	gozendesk "github.com/nukosuke/go-zendesk/zendesk"
+	"go.temporal.io/sdk/temporal"
+)
+
+const (
+	TombstoneDeleted      = "deleted"
+	TombstoneMerged       = "merged"
+	TombstoneInaccessible = "inaccessible"
+
+	// Application error types for tickets that can no longer be fetched
+	ErrTypeNotFound     = "NotFound"
+	ErrTypeInaccessible = "Inaccessible"
+
+	// MergedTag is added by Zendesk to tickets closed by merging them into another
+	MergedTag = "closed_by_merge"
+)
+
+// mergedInto matches the comment Zendesk adds to a ticket merged into another
+var mergedInto = regexp.MustCompile(`(?i)merged into request #(\d+)`)
+
+// Tombstone records why a ticket went away. The workflow completes once set.
+type Tombstone struct {
+	Reason     string    `json:"reason"`
+	MergedInto int64     `json:"merged_into,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+// String describes the tombstone, e.g. "merged into #123"
+func (t Tombstone) String() string {
+	if t.Reason == TombstoneMerged && t.MergedInto != 0 {
+		return fmt.Sprintf("merged into #%d", t.MergedInto)
+	}
+	return t.Reason
+}
+
+// ticketGoneError marks the Zendesk responses for deleted and inaccessible
+// tickets as non-retryable. It returns nil for other errors.
+func ticketGoneError(err error) error {
+	zendeskErr, ok := err.(gozendesk.Error)
+	if !ok {
+		return nil
+	}
+
+	switch zendeskErr.Status() {
+	case 404:
+		return temporal.NewNonRetryableApplicationError("failed to find the ticket", ErrTypeNotFound, err)
+	case 403:
+		return temporal.NewNonRetryableApplicationError("no access to the ticket", ErrTypeInaccessible, err)
+	}
+	return nil
+}
+
+// goneReason returns the tombstone reason for an activity error raised by
+// ticketGoneError, or "" for any other error
+func goneReason(err error) string {
+	var appErr *temporal.ApplicationError
+	if !errors.As(err, &appErr) {
+		return ""
+	}
+
+	switch appErr.Type() {
+	case ErrTypeNotFound:
+		return TombstoneDeleted
+	case ErrTypeInaccessible:
+		return TombstoneInaccessible
+	}
+	return ""
+}
+
+// mergeTarget finds the ticket a merged ticket was merged into from the
+// comment Zendesk adds on merge. It returns 0 when there's no such comment.
+func mergeTarget(comments []Comment) int64 {
+	for i := len(comments) - 1; i >= 0; i-- {
+		if matches := mergedInto.FindStringSubmatch(comments[i].Body); matches != nil {
+			id, _ := strconv.ParseInt(matches[1], 10, 64)
+			return id
+		}
+	}
+	return 0
+}
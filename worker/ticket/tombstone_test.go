@@ -0,0 +1,32 @@
+package ticket
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.temporal.io/sdk/temporal"
+)
+
+func TestTombstoneString(t *testing.T) {
+	assert.Equal(t, "deleted", Tombstone{Reason: TombstoneDeleted}.String())
+	assert.Equal(t, "merged into #123", Tombstone{Reason: TombstoneMerged, MergedInto: 123}.String())
+	assert.Equal(t, "merged", Tombstone{Reason: TombstoneMerged}.String())
+}
+
+func TestGoneReason(t *testing.T) {
+	assert.Equal(t, TombstoneDeleted, goneReason(temporal.NewNonRetryableApplicationError("gone", ErrTypeNotFound, nil)))
+	assert.Equal(t, TombstoneInaccessible, goneReason(temporal.NewNonRetryableApplicationError("gone", ErrTypeInaccessible, nil)))
+	assert.Equal(t, "", goneReason(temporal.NewApplicationError("failed", "Other")))
+	assert.Equal(t, "", goneReason(errors.New("failed")))
+	assert.Equal(t, "", goneReason(nil))
+}
+
+func TestMergeTarget(t *testing.T) {
+	comments := []Comment{
+		{Body: "Can you look into this?"},
+		{Body: "This request was closed and merged into request #4567 \"Login issues\"."},
+	}
+	assert.Equal(t, int64(4567), mergeTarget(comments))
+	assert.Equal(t, int64(0), mergeTarget(comments[:1]))
+}
@@ -0,0 +1,113 @@
+package ticket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/taonic/ticketfu/genai"
+	"go.temporal.io/sdk/activity"
+)
+
+const (
+	// MaxLanguageSampleBytes bounds the customer text sent for language detection
+	MaxLanguageSampleBytes = 4 * 1024
+	// MaxSummaryTranslations bounds the translations cached per summary
+	MaxSummaryTranslations = 10
+)
+
+type (
+	DetectLanguageInput struct {
+		Ticket Ticket
+	}
+
+	DetectLanguageOutput struct {
+		// BCP 47 tag of the language the customer writes in, empty if unknown
+		Language string
+	}
+
+	TranslateSummaryInput struct {
+		Summary TicketSummary
+		Locale  string
+	}
+
+	TranslateSummaryOutput struct {
+		Summary TicketSummary
+	}
+)
+
+// DetectLanguage detects the language the customer writes the ticket in. An
+// answer that isn't a valid language tag is reported as unknown rather than
+// retried.
+func (a *Activity) DetectLanguage(ctx context.Context, input DetectLanguageInput) (*DetectLanguageOutput, error) {
+	sample := languageSample(input.Ticket)
+	if sample == "" {
+		return &DetectLanguageOutput{}, nil
+	}
+
+	result, err := a.genAPI.GenerateContent(ctx, a.genAPI.GetConfig().LanguagePrompt, sample)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate %w", err)
+	}
+
+	language, err := genai.ParseLocale(strings.Trim(trimCodeFence(result), "\"'` \n"))
+	if err != nil {
+		activity.GetLogger(ctx).Warn("Failed to detect ticket language", "ticket-id", input.Ticket.ID, "error", err)
+		return &DetectLanguageOutput{}, nil
+	}
+
+	return &DetectLanguageOutput{Language: language}, nil
+}
+
+// TranslateSummary translates the canonical summary to the locale rather than
+// summarizing the thread again
+func (a *Activity) TranslateSummary(ctx context.Context, input TranslateSummaryInput) (*TranslateSummaryOutput, error) {
+	content, err := json.Marshal(struct {
+		Locale  string        `json:"locale"`
+		Summary TicketSummary `json:"summary"`
+	}{input.Locale, input.Summary})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal summary to JSON: %w", err)
+	}
+
+	result, err := a.genAPI.GenerateContent(ctx, a.genAPI.GetConfig().TranslatePrompt, string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate %w", err)
+	}
+
+	summary, err := parseTicketSummary(result, summaryFields(input.Summary))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse translated summary: %w", err)
+	}
+
+	return &TranslateSummaryOutput{Summary: summary}, nil
+}
+
+// languageSample returns the text the customer wrote on the ticket, bounded
+// by MaxLanguageSampleBytes
+func languageSample(ticket Ticket) string {
+	parts := []string{ticket.Subject, ticket.Description}
+	for _, comment := range ticket.Comments {
+		if comment.Author.Role == RoleEndUser {
+			parts = append(parts, comment.Body)
+		}
+	}
+
+	var b strings.Builder
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(part)
+		if b.Len() >= MaxLanguageSampleBytes {
+			break
+		}
+	}
+
+	return truncateUTF8(b.String(), MaxLanguageSampleBytes)
+}
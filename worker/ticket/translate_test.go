@@ -0,0 +1,150 @@
+package ticket
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/taonic/ticketfu/config"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	testSuite := testsuite.WorkflowTestSuite{}
+	testEnv := testSuite.NewTestActivityEnvironment()
+
+	ticket := Ticket{
+		ID:      12345,
+		Subject: "Connexion impossible",
+		Comments: []Comment{
+			{Author: Author{Role: RoleEndUser}, Body: "Je ne peux pas me connecter"},
+			{Author: Author{Role: "agent"}, Body: "Internal note in English"},
+		},
+	}
+
+	testCases := []struct {
+		name             string
+		ticket           Ticket
+		setupMock        func(*MockGenAIAPI)
+		expectedLanguage string
+		expectedError    string
+	}{
+		{
+			name:   "Detected From Customer Text",
+			ticket: ticket,
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{LanguagePrompt: "detect"})
+				m.On("GenerateContent", mock.Anything, "detect", mock.MatchedBy(func(content string) bool {
+					return strings.Contains(content, "Je ne peux pas") && !strings.Contains(content, "Internal note")
+				})).Return(" \"fr\"\n", nil)
+			},
+			expectedLanguage: "fr",
+		},
+		{
+			name:   "Invalid Tag Is Unknown",
+			ticket: ticket,
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{LanguagePrompt: "detect"})
+				m.On("GenerateContent", mock.Anything, "detect", mock.Anything).Return("The ticket is in French", nil)
+			},
+		},
+		{
+			name:      "Nothing To Detect",
+			ticket:    Ticket{ID: 12345},
+			setupMock: func(m *MockGenAIAPI) {},
+		},
+		{
+			name:   "Generation API Error",
+			ticket: ticket,
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{LanguagePrompt: "detect"})
+				m.On("GenerateContent", mock.Anything, mock.Anything, mock.Anything).Return("", errors.New("API failure"))
+			},
+			expectedError: "failed to generate",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockAPI := new(MockGenAIAPI)
+			tc.setupMock(mockAPI)
+
+			activity := &Activity{genAPI: mockAPI}
+			testEnv.RegisterActivity(activity.DetectLanguage)
+
+			future, err := testEnv.ExecuteActivity(activity.DetectLanguage, DetectLanguageInput{Ticket: tc.ticket})
+
+			if tc.expectedError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedError)
+			} else {
+				require.NoError(t, err)
+				var output DetectLanguageOutput
+				require.NoError(t, future.Get(&output))
+				assert.Equal(t, tc.expectedLanguage, output.Language)
+			}
+
+			mockAPI.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTranslateSummary(t *testing.T) {
+	testSuite := testsuite.WorkflowTestSuite{}
+	testEnv := testSuite.NewTestActivityEnvironment()
+
+	summary := TicketSummary{Intent: "Login", Summary: "Login fails", NextStep: "Reset password"}
+
+	testCases := []struct {
+		name            string
+		setupMock       func(*MockGenAIAPI)
+		expectedSummary TicketSummary
+		expectedError   string
+	}{
+		{
+			name: "Translated",
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{TranslatePrompt: "translate"})
+				m.On("GenerateContent", mock.Anything, "translate", mock.MatchedBy(func(content string) bool {
+					return strings.Contains(content, `"locale":"fr"`) && strings.Contains(content, "Login fails")
+				})).Return("```json\n{\"intent\": \"Connexion\", \"summary\": \"La connexion échoue\", \"next_step\": \"Réinitialiser\"}\n```", nil)
+			},
+			expectedSummary: TicketSummary{Intent: "Connexion", Summary: "La connexion échoue", NextStep: "Réinitialiser"},
+		},
+		{
+			name: "Invalid Translation",
+			setupMock: func(m *MockGenAIAPI) {
+				m.On("GetConfig").Return(config.AIConfig{TranslatePrompt: "translate"})
+				m.On("GenerateContent", mock.Anything, "translate", mock.Anything).Return(`{"summary": "La connexion échoue"}`, nil)
+			},
+			expectedError: "failed to parse translated summary",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockAPI := new(MockGenAIAPI)
+			tc.setupMock(mockAPI)
+
+			activity := &Activity{genAPI: mockAPI}
+			testEnv.RegisterActivity(activity.TranslateSummary)
+
+			future, err := testEnv.ExecuteActivity(activity.TranslateSummary, TranslateSummaryInput{Summary: summary, Locale: "fr"})
+
+			if tc.expectedError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedError)
+			} else {
+				require.NoError(t, err)
+				var output TranslateSummaryOutput
+				require.NoError(t, future.Get(&output))
+				assert.Equal(t, tc.expectedSummary, output.Summary)
+			}
+
+			mockAPI.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package ticket
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nukosuke/go-zendesk/zendesk"
+	"github.com/taonic/ticketfu/config"
+)
+
+type (
+	UpdateTicketFieldsInput struct {
+		TicketID       int64
+		Taxonomy       []config.TaxonomyField
+		Classification map[string]string
+	}
+
+	UpdateTicketFieldsOutput struct {
+		UpdatedAt *time.Time // When the write updated the ticket, unset when nothing was written
+	}
+)
+
+// UpdateTicketFields writes a classification back to Zendesk, as custom field
+// values for taxonomy fields mapped to one and as tags otherwise. Tags are
+// added rather than the whole list replaced, so tags agents set concurrently
+// aren't lost.
+func (a *Activity) UpdateTicketFields(ctx context.Context, input UpdateTicketFieldsInput) (*UpdateTicketFieldsOutput, error) {
+	var customFields []zendesk.CustomField
+	var tags []string
+
+	for _, field := range input.Taxonomy {
+		value, ok := input.Classification[field.Name]
+		if !ok {
+			continue
+		}
+
+		if field.FieldID != 0 {
+			customFields = append(customFields, zendesk.CustomField{ID: field.FieldID, Value: value})
+			continue
+		}
+		tags = append(tags, taxonomyTag(field.Name, value))
+	}
+
+	if len(tags) == 0 && len(customFields) == 0 {
+		return &UpdateTicketFieldsOutput{}, nil
+	}
+
+	ticket, err := a.zClient.AddTicketTagsAndFields(ctx, input.TicketID, tags, customFields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update ticket: %w", err)
+	}
+
+	return &UpdateTicketFieldsOutput{UpdatedAt: ticket.UpdatedAt}, nil
+}
+
+// taxonomyTag formats a taxonomy value as a Zendesk tag, e.g. product_area_billing
+func taxonomyTag(name, value string) string {
+	return strings.ToLower(strings.ReplaceAll(name+"_"+value, " ", "_"))
+}
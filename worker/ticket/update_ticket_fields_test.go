@@ -0,0 +1,98 @@
+package ticket
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nukosuke/go-zendesk/zendesk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/taonic/ticketfu/config"
+	zd "github.com/taonic/ticketfu/zendesk"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestUpdateTicketFields(t *testing.T) {
+	testSuite := testsuite.WorkflowTestSuite{}
+	testEnv := testSuite.NewTestActivityEnvironment()
+
+	taxonomy := []config.TaxonomyField{
+		{Name: "product_area", Values: []string{"Billing", "API"}},
+		{Name: "severity", Values: []string{"low", "high"}, FieldID: 360001},
+	}
+
+	updatedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name           string
+		classification map[string]string
+		setupMock      func(*zd.MockZendeskClient)
+		expectedOutput *UpdateTicketFieldsOutput
+		expectedError  string
+	}{
+		{
+			name:           "Adds Tags And Sets Custom Fields",
+			classification: map[string]string{"product_area": "Billing", "severity": "high"},
+			setupMock: func(m *zd.MockZendeskClient) {
+				m.On("AddTicketTagsAndFields", mock.Anything, int64(12345), []string{"product_area_billing"},
+					[]zendesk.CustomField{{ID: 360001, Value: "high"}}).
+					Return(zendesk.Ticket{ID: 12345, UpdatedAt: &updatedAt}, nil).Once()
+			},
+			expectedOutput: &UpdateTicketFieldsOutput{UpdatedAt: &updatedAt},
+		},
+		{
+			name:           "Tags Only",
+			classification: map[string]string{"product_area": "API"},
+			setupMock: func(m *zd.MockZendeskClient) {
+				m.On("AddTicketTagsAndFields", mock.Anything, int64(12345), []string{"product_area_api"}, []zendesk.CustomField(nil)).
+					Return(zendesk.Ticket{ID: 12345, UpdatedAt: &updatedAt}, nil).Once()
+			},
+			expectedOutput: &UpdateTicketFieldsOutput{UpdatedAt: &updatedAt},
+		},
+		{
+			name:           "Nothing Classified",
+			classification: map[string]string{},
+			setupMock:      func(m *zd.MockZendeskClient) {},
+			expectedOutput: &UpdateTicketFieldsOutput{},
+		},
+		{
+			name:           "Update Error",
+			classification: map[string]string{"severity": "low"},
+			setupMock: func(m *zd.MockZendeskClient) {
+				m.On("AddTicketTagsAndFields", mock.Anything, int64(12345), mock.Anything, mock.Anything).
+					Return(zendesk.Ticket{}, errors.New("API failure"))
+			},
+			expectedError: "failed to update ticket",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockClient := new(zd.MockZendeskClient)
+			tc.setupMock(mockClient)
+
+			activity := &Activity{zClient: mockClient}
+			testEnv.RegisterActivity(activity.UpdateTicketFields)
+
+			result, err := testEnv.ExecuteActivity(activity.UpdateTicketFields, UpdateTicketFieldsInput{
+				TicketID:       12345,
+				Taxonomy:       taxonomy,
+				Classification: tc.classification,
+			})
+
+			if tc.expectedError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedError)
+			} else {
+				require.NoError(t, err)
+				var output UpdateTicketFieldsOutput
+				require.NoError(t, result.Get(&output))
+				assert.Equal(t, tc.expectedOutput, &output)
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
@@ -1,29 +1,119 @@
 package ticket
 
-func truncateStringSlice(strings []string, limit int) ([]string, bool) {
-	if limit <= 0 {
-		return []string{}, true
-	}
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/taonic/ticketfu/genai"
+	"go.temporal.io/sdk/temporal"
+)
 
-	total := 0
-	for _, s := range strings {
-		total += len(s)
+func commentsSize(comments []Comment) int {
+	size := 0
+	for _, c := range comments {
+		size += len(c.Body)
 	}
+	return size
+}
 
-	if total <= limit {
-		return strings, false
+// splitComments splits comments into older ones and the most recent ones
+// whose cumulative size fits within 'limit'. At least one comment is always
+// kept in recent.
+func splitComments(comments []Comment, limit int) ([]Comment, []Comment) {
+	if len(comments) == 0 {
+		return nil, comments
 	}
 
-	// Iterate backwards from the end to find the smallest trailing slice
-	// whose cumulative length is at least 'limit'
 	sum := 0
-	for i := len(strings) - 1; i >= 0; i-- {
-		sum += len(strings[i])
-		if sum >= limit {
-			return strings[i:], true
+	for i := len(comments) - 1; i >= 0; i-- {
+		sum += len(comments[i].Body)
+		if sum > limit {
+			split := min(i+1, len(comments)-1)
+			return comments[:split], comments[split:]
+		}
+	}
+
+	return nil, comments
+}
+
+// fitComments keeps the most recent comments fitting the tokens the budget
+// leaves after the prompt and the rest of the ticket. Attachment snippets, then
+// the history digest, then the chunk summaries are left out when not even the
+// latest comment fits beside them. It returns how many older comments were
+// dropped.
+func fitComments(budget genai.Budget, prompt string, ticket Ticket) (Ticket, int, error) {
+	comments := ticket.Comments
+	ticket.Comments = nil
+
+	trims := []func(*Ticket){
+		func(t *Ticket) { t.AttachmentSnippets = nil },
+		func(t *Ticket) { t.HistoryDigest = "" },
+		func(t *Ticket) { t.ChunkSummaries = nil },
+	}
+	for {
+		start, err := fitRecentComments(budget, prompt, ticket, comments)
+		if err != nil {
+			return ticket, 0, err
 		}
+		if start < len(comments) || len(comments) == 0 {
+			ticket.Comments = comments[start:]
+			return ticket, start, nil
+		}
+		if len(trims) == 0 {
+			return ticket, 0, temporal.NewNonRetryableApplicationError("no comments fit the context budget", "OverContextBudget", nil)
+		}
+		trims[0](&ticket)
+		trims = trims[1:]
+	}
+}
+
+// fitRecentComments returns where the most recent comments fitting beside
+// the prompt and the ticket start
+func fitRecentComments(budget genai.Budget, prompt string, ticket Ticket, comments []Comment) (int, error) {
+	metadata, err := json.Marshal(ticket)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal ticket to JSON: %w", err)
 	}
+	available := budget.Allocate(prompt, string(metadata)).Content
+
+	start := len(comments)
+	for used := 0; start > 0; start-- {
+		commentJSON, err := json.Marshal(comments[start-1])
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal comment to JSON: %w", err)
+		}
+		used += budget.CountTokens(string(commentJSON))
+		if used > available {
+			break
+		}
+	}
+	return start, nil
+}
 
-	// In theory, this should never be reached.
-	return strings, true
+// mergeMetadata copies the metadata fetched from Zendesk onto the ticket
+// while keeping the state accumulated by the workflow.
+func mergeMetadata(dst *Ticket, src Ticket) {
+	dst.ID = src.ID
+	dst.Subject = src.Subject
+	dst.Description = src.Description
+	dst.Priority = src.Priority
+	dst.Status = src.Status
+	dst.RequesterID = src.RequesterID
+	dst.Requester = src.Requester
+	dst.AssigneeID = src.AssigneeID
+	dst.Assignee = src.Assignee
+	dst.OrganizationID = src.OrganizationID
+	dst.OrganizationName = src.OrganizationName
+	dst.CreatedAt = src.CreatedAt
+	dst.UpdatedAt = src.UpdatedAt
+	dst.Type = src.Type
+	dst.Tags = src.Tags
+	dst.CustomFields = src.CustomFields
+	dst.BrandID = src.BrandID
+	dst.GroupID = src.GroupID
+	dst.Channel = src.Channel
+	dst.Satisfaction = src.Satisfaction
+	dst.ProblemID = src.ProblemID
+	dst.HasIncidents = src.HasIncidents
+	dst.DueAt = src.DueAt
 }
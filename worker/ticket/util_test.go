@@ -1,61 +1,116 @@
 package ticket
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taonic/ticketfu/config"
+	"github.com/taonic/ticketfu/genai"
 )
 
-func TestTruncateStringSlice(t *testing.T) {
+func TestSplitComments(t *testing.T) {
 	tests := []struct {
-		name      string
-		input     []string
-		limit     int
-		expected  []string
-		truncated bool
+		name           string
+		input          []Comment
+		limit          int
+		expectedOlder  []Comment
+		expectedRecent []Comment
 	}{
 		{
-			name:      "No truncation needed",
-			input:     []string{"a", "b", "c"},
-			limit:     10,
-			expected:  []string{"a", "b", "c"},
-			truncated: false,
+			name:           "Everything fits",
+			input:          []Comment{{Body: "a"}, {Body: "b"}},
+			limit:          10,
+			expectedOlder:  nil,
+			expectedRecent: []Comment{{Body: "a"}, {Body: "b"}},
 		},
 		{
-			name:      "Exact limit",
-			input:     []string{"a", "b", "c"},
-			limit:     3,
-			expected:  []string{"a", "b", "c"},
-			truncated: false,
+			name:           "Split older comments",
+			input:          []Comment{{Body: "a"}, {Body: "bb"}, {Body: "ccc"}, {Body: "dddd"}},
+			limit:          7,
+			expectedOlder:  []Comment{{Body: "a"}, {Body: "bb"}},
+			expectedRecent: []Comment{{Body: "ccc"}, {Body: "dddd"}},
 		},
 		{
-			name:      "Truncation required",
-			input:     []string{"a", "bb", "ccc", "dddd"},
-			limit:     5,
-			expected:  []string{"ccc", "dddd"},
-			truncated: true,
+			name:           "Latest comment is always kept",
+			input:          []Comment{{Body: "a"}, {Body: "bbbb"}},
+			limit:          2,
+			expectedOlder:  []Comment{{Body: "a"}},
+			expectedRecent: []Comment{{Body: "bbbb"}},
 		},
 		{
-			name:      "Empty input",
-			input:     []string{},
-			limit:     10,
-			expected:  []string{},
-			truncated: false,
-		},
-		{
-			name:      "Negative limit",
-			input:     []string{"a", "b"},
-			limit:     -1,
-			expected:  []string{},
-			truncated: true,
+			name:           "Empty input",
+			input:          []Comment{},
+			limit:          10,
+			expectedOlder:  nil,
+			expectedRecent: []Comment{},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, truncated := truncateStringSlice(tt.input, tt.limit)
-			assert.Equal(t, tt.expected, result)
-			assert.Equal(t, tt.truncated, truncated)
+			older, recent := splitComments(tt.input, tt.limit)
+			assert.Equal(t, tt.expectedOlder, older)
+			assert.Equal(t, tt.expectedRecent, recent)
+		})
+	}
+}
+
+func TestFitComments(t *testing.T) {
+	ticket := Ticket{ID: 1, Subject: "Login fails"}
+	ticket.Comments = []Comment{
+		{ID: 1, Body: strings.Repeat("a", 300)},
+		{ID: 2, Body: strings.Repeat("b", 30)},
+		{ID: 3, Body: strings.Repeat("c", 30)},
+	}
+
+	tokens := func(budget genai.Budget, v any) int {
+		content, err := json.Marshal(v)
+		require.NoError(t, err)
+		return budget.CountTokens(string(content))
+	}
+	budgetFor := func(contextWindow int) genai.Budget {
+		return genai.NewBudget(config.AIConfig{
+			LLMProvider:    genai.Anthropic,
+			ContextBudgets: map[string]config.ContextBudget{genai.Anthropic: {ContextWindow: contextWindow, OutputReserve: 10}},
 		})
 	}
+
+	// Everything fits
+	fitted, dropped, err := fitComments(budgetFor(100_000), "prompt", ticket)
+	require.NoError(t, err)
+	assert.Equal(t, 0, dropped)
+	assert.Equal(t, ticket.Comments, fitted.Comments)
+
+	// Only room for the two most recent comments after the prompt, metadata
+	// and output reserve
+	budget := budgetFor(1)
+	metadata := ticket
+	metadata.Comments = nil
+	window := budget.CountTokens("prompt") + tokens(budget, metadata) + 10 + tokens(budget, ticket.Comments[1]) + tokens(budget, ticket.Comments[2])
+
+	fitted, dropped, err = fitComments(budgetFor(window), "prompt", ticket)
+	require.NoError(t, err)
+	assert.Equal(t, 1, dropped)
+	assert.Equal(t, ticket.Comments[1:], fitted.Comments)
+	assert.Equal(t, "Login fails", fitted.Subject)
+
+	// The digest and chunk summaries are left out before every comment is
+	digested := ticket
+	digested.HistoryDigest = strings.Repeat("d", 600)
+	digested.AttachmentSnippets = []AttachmentSnippet{{FileName: "log.txt", Text: strings.Repeat("e", 600)}}
+	digested.ChunkSummaries = []ChunkSummary{{FirstCommentID: 1, LastCommentID: 1, Summary: strings.Repeat("f", 600)}}
+	fitted, dropped, err = fitComments(budgetFor(window), "prompt", digested)
+	require.NoError(t, err)
+	assert.Equal(t, 1, dropped)
+	assert.Equal(t, ticket.Comments[1:], fitted.Comments)
+	assert.Empty(t, fitted.HistoryDigest)
+	assert.Empty(t, fitted.AttachmentSnippets)
+	assert.Empty(t, fitted.ChunkSummaries)
+
+	// No room left for comments
+	_, _, err = fitComments(budgetFor(20), "prompt", ticket)
+	assert.ErrorContains(t, err, "no comments fit the context budget")
 }
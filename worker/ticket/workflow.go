@@ -1,16 +1,60 @@
 package ticket
 
 import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
 	"time"
 
+	"github.com/taonic/ticketfu/config"
+	"github.com/taonic/ticketfu/genai"
+	"github.com/taonic/ticketfu/worker/history"
+	"github.com/taonic/ticketfu/worker/metrics"
+	"github.com/taonic/ticketfu/worker/org"
+	"github.com/taonic/ticketfu/worker/related"
+	sdklog "go.temporal.io/sdk/log"
 	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 )
 
 const (
-	UpsertTicketSignal       = "upsert-ticket-signal"
-	QueryTicketSummary       = "query-ticket-summary"
-	TicketWorkflowIDTemplate = "ticket-workflow-%s" // e.g. ticket-workflow-1234 where 1234 is the ticket ID
+	TicketWorkflowName        = "TicketWorkflow"
+	UpsertTicketSignal        = "upsert-ticket-signal"
+	QueryTicketSummary        = "query-ticket-summary"
+	QueryTicketSummaryHistory = "query-ticket-summary-history"
+	QueryTicketTranslation    = "query-ticket-translation"
+	QueryRelatedTicketsLookup = "query-related-tickets-lookup"
+	DraftReplyUpdate          = "draft-reply-update"
+	RefreshTicketUpdate       = "refresh-ticket-update"
+	TranslateTicketUpdate     = "translate-ticket-update"
+	TicketWorkflowIDTemplate  = "ticket-workflow-%s" // e.g. ticket-workflow-1234 where 1234 is the ticket ID
+
+	// MaxSummaryVersions bounds the summary history kept per ticket
+	MaxSummaryVersions = 20
+
+	// MaxThreadBytes is the budget of comments kept verbatim in the workflow.
+	// Once exceeded, the older half is folded into the history digest.
+	MaxThreadBytes = 200 * 1024
+
+	// StatusClosed is the terminal Zendesk ticket status. Closed tickets can
+	// no longer be updated so the workflow completes with a resolution summary.
+	StatusClosed = "closed"
+)
+
+var (
+	updatesBeforeContinueAsNew = 500
+
+	// indexScheduleToStartTimeout bounds how long index activities wait for
+	// the worker serving the related tickets index
+	indexScheduleToStartTimeout = time.Minute
+
+	DefaultWorkflowConfig = config.TicketWorkflowConfig{
+		QuietPeriod:        10 * time.Second,
+		MaxDelay:           time.Minute,
+		SentimentThreshold: -0.5,
+		UrgencyThreshold:   0.8,
+	}
 )
 
 type Ticket struct {
@@ -19,42 +63,181 @@ type Ticket struct {
 	Description      string
 	Priority         string
 	Status           string
+	RequesterID      int64
 	Requester        string
+	AssigneeID       int64
 	Assignee         string
 	OrganizationID   int64
 	OrganizationName string
 	CreatedAt        *time.Time
 	UpdatedAt        *time.Time
 
+	// When TicketFu's own latest write, e.g. its classification or summary
+	// note, updated the ticket in Zendesk
+	WrittenAt *time.Time
+
+	// Zendesk metadata
+	Type         string
+	Tags         []string
+	CustomFields map[string]string // Allowlisted custom field values keyed by their configured names
+	BrandID      int64
+	GroupID      int64
+	Channel      string // Channel the ticket was created through, e.g. email or web
+	Satisfaction *Satisfaction
+	ProblemID    int64 // Problem ticket this incident is linked to
+	HasIncidents bool  // Whether incidents are linked to this problem ticket
+	DueAt        *time.Time
+
 	// Comments and cursor
-	Comments   []string
+	Comments   []Comment
 	NextCursor string
 
-	// LLM generated summary
-	Summary string
+	// Relevant text from text-like attachments, oldest first
+	AttachmentSnippets []AttachmentSnippet
+
+	// LLM generated digest of the comments compacted out of Comments
+	HistoryDigest string
+
+	// LLM generated summaries of consecutive chunks of comments, oldest
+	// first. Only maintained in map-reduce mode.
+	ChunkSummaries []ChunkSummary
+
+	// Metadata changes detected across upserts
+	Events []TicketEvent
+
+	// Language the customer writes in as a BCP 47 tag, empty until detected
+	Language string
+
+	// LLM generated summary and its raw output
+	Summary    *TicketSummary
+	RawSummary string
+
+	// Translations of Summary keyed by locale, dropped whenever it changes
+	Translations map[string]TicketSummary
+
+	// LLM generated resolution summary once the ticket is closed
+	Resolution *Resolution
+
+	// LLM rated sentiment and urgency as of the latest update
+	Scores *Scores
+
+	// LLM suggested taxonomy values keyed by taxonomy field name
+	Classification map[string]string
+
+	// Public comments added since the summary was last posted as a note
+	PublicCommentsSinceNote int
+
+	// Previous summaries, oldest first
+	SummaryHistory []history.Version
+
+	// Set once the ticket is deleted, merged or no longer accessible
+	Tombstone *Tombstone
+
+	// Response-time and SLA metrics derived from the comments and status changes
+	Metrics metrics.Ticket
+}
+
+// Satisfaction is the customer's rating of the support they received
+type Satisfaction struct {
+	Score   string
+	Comment string
 }
 
+type (
+	// Comment is a ticket comment enriched with its author and metadata
+	Comment struct {
+		ID          int64
+		Author      Author
+		Body        string
+		Public      bool
+		CreatedAt   time.Time
+		Channel     string // e.g. email, web, api
+		Attachments []Attachment
+	}
+
+	Author struct {
+		ID   int64
+		Name string
+		Role string // end-user, agent or admin
+	}
+
+	Attachment struct {
+		ID          int64
+		FileName    string
+		ContentType string
+		ContentURL  string
+		Size        int64
+	}
+)
+
 type (
 	UpsertTicketInput struct {
 		TicketID string
+
+		// When the ticket was last updated in Zendesk. Set by reconciliation
+		// and the webhook so updates the workflow has already caught up
+		// with, including its own writes, are skipped.
+		UpdatedAt *time.Time
 	}
 
+	// QueryTicketOutput is returned by the summary query and as the result
+	// of the workflow once the ticket is closed.
 	QueryTicketOutput struct {
-		Summary string `json:"summary"`
+		Summary    *TicketSummary `json:"summary"`
+		RawSummary string         `json:"raw_summary"`
+		Locale     string         `json:"locale,omitempty"`   // Set when the summary is translated
+		Language   string         `json:"language,omitempty"` // Language the customer writes in
+		Status     string         `json:"status"`
+		Events     []TicketEvent  `json:"events"`
+		Resolution *Resolution    `json:"resolution,omitempty"`
+		Scores     *Scores        `json:"scores,omitempty"`
+		Tombstone  *Tombstone     `json:"tombstone,omitempty"`
+		Metrics    metrics.Ticket `json:"metrics"`
+
+		Classification map[string]string `json:"classification,omitempty"`
+	}
+
+	DraftReplyInput struct {
+		// Optional agent instruction, e.g. tone or points to cover
+		Instruction string `json:"instruction"`
+	}
+
+	DraftReplyOutput struct {
+		Draft string `json:"draft"`
+	}
+
+	TranslateInput struct {
+		Locale string `json:"locale"`
+	}
+
+	RelatedTicketsInput struct {
+		// Number of tickets to return, DefaultRelatedTickets when unset
+		Limit int `json:"limit"`
+	}
+
+	RelatedTicketsOutput struct {
+		Tickets []related.Match `json:"tickets"`
 	}
 
 	ticketWorkflow struct {
 		workflow.Context
+		logger                     sdklog.Logger
 		signalCh                   workflow.ReceiveChannel
+		refreshCh                  workflow.Channel
+		passesStarted              int
+		passesCompleted            int
+		translationsRun            int // Translations run by updates, counted toward continuing as new
+		translatedCh               workflow.Channel
 		updatesBeforeContinueAsNew int
 		activity                   Activity
+		config                     config.TicketWorkflowConfig
 
 		// Ticket state
 		ticket Ticket
 	}
 )
 
-func newTicketWorkflow(ctx workflow.Context, ticket Ticket) *ticketWorkflow {
+func newTicketWorkflow(ctx workflow.Context, config config.TicketWorkflowConfig, ticket Ticket) *ticketWorkflow {
 	return &ticketWorkflow{
 		Context: workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
 			StartToCloseTimeout: 30 * time.Second,
@@ -65,19 +248,36 @@ func newTicketWorkflow(ctx workflow.Context, ticket Ticket) *ticketWorkflow {
 				MaximumAttempts:    10,
 			},
 		}),
+		logger:                     sdklog.With(workflow.GetLogger(ctx)),
 		signalCh:                   workflow.GetSignalChannel(ctx, UpsertTicketSignal),
-		updatesBeforeContinueAsNew: 500,
+		refreshCh:                  workflow.NewBufferedChannel(ctx, 1),
+		translatedCh:               workflow.NewBufferedChannel(ctx, 1),
+		updatesBeforeContinueAsNew: updatesBeforeContinueAsNew,
+		config:                     config,
 		ticket:                     ticket,
 	}
 }
 
 // Define the workflow
-func TicketWorkflow(ctx workflow.Context, ticket Ticket) error {
-	t := newTicketWorkflow(ctx, ticket)
+func TicketWorkflow(ctx workflow.Context, ticket Ticket) (*QueryTicketOutput, error) {
+	t := newTicketWorkflow(ctx, DefaultWorkflowConfig, ticket)
 	return t.run()
 }
 
-func (s *ticketWorkflow) run() error {
+// NewTicketWorkflow returns the ticket workflow recording the given config as
+// each run starts. It's meant to be registered under TicketWorkflowName.
+func NewTicketWorkflow(config config.TicketWorkflowConfig) func(workflow.Context, Ticket) (*QueryTicketOutput, error) {
+	return func(ctx workflow.Context, ticket Ticket) (*QueryTicketOutput, error) {
+		t := newTicketWorkflow(ctx, config, ticket)
+		return t.run()
+	}
+}
+
+func (s *ticketWorkflow) run() (*QueryTicketOutput, error) {
+	if err := s.recordConfig(); err != nil {
+		return nil, err
+	}
+
 	selector := workflow.NewSelector(s)
 
 	// Listen for cancellation
@@ -93,79 +293,418 @@ func (s *ticketWorkflow) run() error {
 		ch.Receive(s.Context, &pendingUpsert)
 	})
 
+	// Listen for refresh updates, which skip the quiet period
+	var refresh bool
+	selector.AddReceive(s.refreshCh, func(ch workflow.ReceiveChannel, _ bool) {
+		ch.Receive(s.Context, &pendingUpsert)
+		refresh = true
+	})
+
 	// Set query summary handler
 	if err := workflow.SetQueryHandler(s.Context, QueryTicketSummary, s.handleQuerySummary); err != nil {
-		return err
+		return nil, err
+	}
+
+	// Set summary history query handler
+	if err := workflow.SetQueryHandler(s.Context, QueryTicketSummaryHistory, s.handleQuerySummaryHistory); err != nil {
+		return nil, err
+	}
+
+	// Set cached translation query handler
+	if err := workflow.SetQueryHandler(s.Context, QueryTicketTranslation, s.handleQueryTranslation); err != nil {
+		return nil, err
+	}
+
+	// Set draft reply update handler
+	if err := workflow.SetUpdateHandlerWithOptions(s.Context, DraftReplyUpdate, s.handleDraftReply, workflow.UpdateHandlerOptions{
+		Validator: s.validateDraftReply,
+	}); err != nil {
+		return nil, err
+	}
+
+	// Set refresh update handler
+	if err := workflow.SetUpdateHandlerWithOptions(s.Context, RefreshTicketUpdate, s.handleRefresh, workflow.UpdateHandlerOptions{
+		Validator: s.validateRefresh,
+	}); err != nil {
+		return nil, err
 	}
 
-	// Continually select until there are too many requests and no pending
-	// selects.
-	for updateCount < s.updatesBeforeContinueAsNew || selector.HasPending() {
+	// Set translate update handler
+	if err := workflow.SetUpdateHandlerWithOptions(s.Context, TranslateTicketUpdate, s.handleTranslate, workflow.UpdateHandlerOptions{
+		Validator: s.validateTranslate,
+	}); err != nil {
+		return nil, err
+	}
+
+	// Set related tickets lookup query handler
+	if err := workflow.SetQueryHandler(s.Context, QueryRelatedTicketsLookup, s.handleQueryRelatedTicketsLookup); err != nil {
+		return nil, err
+	}
+
+	// Runs started before the pipeline was versioned replay the upserts they
+	// already processed with the original pipeline
+	legacy := workflow.GetVersion(s, pipelineChangeID, workflow.DefaultVersion, 1) == workflow.DefaultVersion
+
+	// Runs started before the history size was watched only count upserts
+	watchHistory := workflow.GetVersion(s, historySizeChangeID, workflow.DefaultVersion, 1) == 1
+
+	// Wake up to count translations toward continuing as new
+	selector.AddReceive(s.translatedCh, func(ch workflow.ReceiveChannel, _ bool) {
+		ch.Receive(s.Context, nil)
+	})
+
+	// Continually select until there are too many requests, or the history
+	// grew too large, and no pending selects.
+	for (updateCount+s.translationsRun < s.updatesBeforeContinueAsNew && !(watchHistory && workflow.GetInfo(s).GetContinueAsNewSuggested())) ||
+		selector.HasPending() {
 		selector.Select(s)
 
-		if pendingUpsert != nil {
-			if err := s.processPendingUpsert(pendingUpsert); err != nil {
-				return err
+		if pendingUpsert != nil && legacy {
+			legacy = s.legacyPipeline(updateCount)
+		}
+		if pendingUpsert != nil && legacy {
+			if err := s.processLegacyUpsert(pendingUpsert); err != nil {
+				return nil, err
 			}
 			pendingUpsert = nil
+			refresh = false
 			updateCount++
 		}
 
+		if pendingUpsert != nil {
+			// A refresh update waits for a pass, so it's never skipped
+			coalesced, ok := 0, true
+			if !refresh && s.upToDate(pendingUpsert) {
+				s.logger.Debug("Skipping upsert of a ticket already up to date", "ticket-id", s.ticket.ID)
+				ok = false
+			} else if !refresh {
+				// Coalesce the burst of signals into a single pass
+				coalesced, refresh, ok = s.awaitQuietPeriod()
+			}
+			if ok {
+				if err := s.process(pendingUpsert); err != nil {
+					return nil, err
+				}
+			}
+			pendingUpsert = nil
+			refresh = false
+			updateCount += 1 + coalesced
+		}
+
+		// Closed, deleted and merged tickets can't change anymore so complete the workflow
+		if s.completed() {
+			return s.complete()
+		}
+
+		if cancelled {
+			return nil, temporal.NewCanceledError()
+		}
+	}
+
+	if err := s.awaitHandlers(); err != nil {
+		return nil, err
+	}
+	// A refresh served while draining may have closed the ticket
+	if s.completed() {
+		return s.complete()
+	}
+	return nil, workflow.NewContinueAsNewError(s, TicketWorkflowName, s.ticket)
+}
+
+// recordConfig records the worker's config in the history as the run starts.
+// The config decides which commands the workflow issues, so the run replays
+// with the config it started with however the worker's flags change since.
+// Changes take effect as the workflow continues as new.
+func (s *ticketWorkflow) recordConfig() error {
+	// Runs started before the config was recorded replay with the worker's
+	if workflow.GetVersion(s, configChangeID, workflow.DefaultVersion, 1) == workflow.DefaultVersion {
+		return nil
+	}
+
+	workerConfig := s.config
+	return workflow.SideEffect(s, func(workflow.Context) any {
+		return workerConfig
+	}).Get(&s.config)
+}
+
+// completed reports whether the ticket is closed, deleted or merged
+func (s *ticketWorkflow) completed() bool {
+	return s.ticket.Resolution != nil || s.ticket.Tombstone != nil
+}
+
+func (s *ticketWorkflow) complete() (*QueryTicketOutput, error) {
+	s.logger.Debug("Completing workflow for closed ticket", "ticket-id", s.ticket.ID)
+	if err := s.awaitHandlers(); err != nil {
+		return nil, err
+	}
+	output := s.output()
+	return &output, nil
+}
+
+// upToDate reports whether the workflow has already processed the ticket as
+// of the upsert, e.g. when reconciliation finds a ticket the webhook covered
+// or an update TicketFu wrote itself
+func (s *ticketWorkflow) upToDate(upsert *UpsertTicketInput) bool {
+	if upsert.UpdatedAt == nil {
+		return false
+	}
+	for _, seen := range []*time.Time{s.ticket.UpdatedAt, s.ticket.WrittenAt} {
+		if seen != nil && !upsert.UpdatedAt.After(*seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordWrite records when TicketFu's own write updated the ticket, so the
+// update isn't mistaken for one the workflow has yet to process
+func (s *ticketWorkflow) recordWrite(updatedAt *time.Time) {
+	if updatedAt != nil && (s.ticket.WrittenAt == nil || updatedAt.After(*s.ticket.WrittenAt)) {
+		s.ticket.WrittenAt = updatedAt
+	}
+}
+
+// awaitHandlers lets in-flight updates finish before the run ends. Refreshes
+// queued after the main loop exited are still served, from the final state
+// once the ticket is closed, so they don't wait forever.
+func (s *ticketWorkflow) awaitHandlers() error {
+	for {
+		_ = workflow.Await(s, func() bool { return workflow.AllHandlersFinished(s) || s.refreshCh.Len() > 0 })
+
+		var refresh *UpsertTicketInput
+		if !s.refreshCh.ReceiveAsync(&refresh) {
+			return nil
+		}
+		if s.completed() {
+			s.passesStarted++
+			s.passesCompleted = s.passesStarted
+			continue
+		}
+		if err := s.process(refresh); err != nil {
+			return err
+		}
+	}
+}
+
+// awaitQuietPeriod blocks until no upsert signal has arrived for the quiet
+// period, or until the max delay has elapsed since it was called. Signals
+// received in the meantime are drained and counted as coalesced. It returns
+// false if the workflow is cancelled while waiting. A refresh update ends the
+// wait right away and is reported, so the pass it waits for isn't skipped.
+func (s *ticketWorkflow) awaitQuietPeriod() (coalesced int, refreshed bool, ok bool) {
+	if s.config.QuietPeriod <= 0 {
+		return 0, false, true
+	}
+
+	deadline := workflow.Now(s).Add(s.config.MaxDelay)
+
+	for {
+		wait := min(s.config.QuietPeriod, deadline.Sub(workflow.Now(s)))
+		if wait <= 0 {
+			return coalesced, false, true
+		}
+
+		timerCtx, cancelTimer := workflow.WithCancel(s.Context)
+		timer := workflow.NewTimer(timerCtx, wait)
+
+		var received, cancelled bool
+		selector := workflow.NewSelector(s)
+		selector.AddFuture(timer, func(workflow.Future) {})
+		selector.AddReceive(s.signalCh, func(ch workflow.ReceiveChannel, _ bool) {
+			ch.Receive(s, nil)
+			received = true
+		})
+		selector.AddReceive(s.refreshCh, func(ch workflow.ReceiveChannel, _ bool) {
+			ch.Receive(s, nil)
+			refreshed = true
+		})
+		selector.AddReceive(s.Done(), func(workflow.ReceiveChannel, bool) {
+			cancelled = true
+		})
+		selector.Select(s)
+		cancelTimer()
+
+		if refreshed {
+			return coalesced, true, true
+		}
+
 		if cancelled {
-			return temporal.NewCanceledError()
+			return coalesced, false, false
 		}
+		if !received {
+			return coalesced, false, true
+		}
+		coalesced++
 	}
+}
 
-	return workflow.NewContinueAsNewError(s, TicketWorkflow, s.ticket)
+// process runs the pipeline for an upsert, counting passes so refresh updates
+// can wait for one that started after they were requested
+func (s *ticketWorkflow) process(pendingUpsert *UpsertTicketInput) error {
+	s.passesStarted++
+	if err := s.processPendingUpsert(pendingUpsert); err != nil {
+		return err
+	}
+	s.passesCompleted = s.passesStarted
+	return nil
 }
 
 func (s *ticketWorkflow) processPendingUpsert(pendingUpsert *UpsertTicketInput) error {
-	// fetch ticket if it hasn't been assigned
-	if s.ticket.ID == 0 {
-		fetchTicketInput := FetchTicketInput{ID: pendingUpsert.TicketID}
-		fetchTicketOutput := FetchTicketOutput{}
+	// refresh ticket metadata on every upsert
+	prevOrganizationID := s.ticket.OrganizationID
+	events, err := s.refreshTicket(pendingUpsert.TicketID)
+	if reason := goneReason(err); reason != "" {
+		return s.bury(reason, 0)
+	}
+	if err != nil {
+		return err
+	}
+
+	// the previous org drops a ticket that moved, the new org picks it up below
+	if prevOrganizationID != 0 && prevOrganizationID != s.ticket.OrganizationID {
+		if err := s.removeFromOrganization(prevOrganizationID, 0); err != nil {
+			return err
+		}
+	}
+
+	// fetch comments with the cursor, in batches folded into the thread's
+	// budget in between so long threads are never cut short. Without a
+	// cursor, they start with the comment opening the ticket.
+	opening := s.ticket.NextCursor == ""
+	var newComments []Comment
+	for {
+		fetchCommentsInput := FetchCommentsInput{ID: pendingUpsert.TicketID, Cursor: s.ticket.NextCursor}
+		fetchCommentsOutput := FetchCommentsOutput{}
+
+		if err := workflow.ExecuteActivity(s.Context, s.activity.FetchComments, fetchCommentsInput).
+			Get(s.Context, &fetchCommentsOutput); err != nil {
+			if reason := goneReason(err); reason != "" {
+				return s.bury(reason, 0)
+			}
+			return err
+		}
 
-		if err := workflow.ExecuteActivity(s.Context, s.activity.FetchTicket, fetchTicketInput).
-			Get(s.Context, &fetchTicketOutput); err != nil {
+		if len(fetchCommentsOutput.Comments) != 0 || fetchCommentsOutput.HasMore {
+			s.ticket.Comments = append(s.ticket.Comments, fetchCommentsOutput.Comments...)
+			s.ticket.NextCursor = fetchCommentsOutput.NextCursor
+		}
+		newComments = append(newComments, fetchCommentsOutput.Comments...)
+
+		if !fetchCommentsOutput.HasMore {
+			break
+		}
+		if err := s.foldComments(); err != nil {
 			return err
 		}
+	}
+	trackComments(&s.ticket.Metrics, s.ticket.CreatedAt, newComments, opening)
+
+	// merged tickets live on in the ticket they were merged into
+	if slices.Contains(s.ticket.Tags, MergedTag) {
+		return s.bury(TombstoneMerged, mergeTarget(s.ticket.Comments))
+	}
 
-		s.ticket = fetchTicketOutput.Ticket
+	for _, comment := range newComments {
+		if comment.Public {
+			s.ticket.PublicCommentsSinceNote++
+		}
 	}
 
-	// fetch comments with the cursor
-	fetchCommentsInput := FetchCommentsInput{ID: pendingUpsert.TicketID, Cursor: s.ticket.NextCursor}
-	fetchCommentsOutput := FetchCommentsOutput{}
+	// detect the customer's language once there is something to go on
+	if s.ticket.Language == "" {
+		if err := s.detectLanguage(); err != nil {
+			return err
+		}
+	}
 
-	if err := workflow.ExecuteActivity(s.Context, s.activity.FetchComments, fetchCommentsInput).
-		Get(s.Context, &fetchCommentsOutput); err != nil {
+	// pull logs, configs and traces out of new attachments
+	if err := s.extractAttachments(newComments); err != nil {
 		return err
 	}
 
-	if len(fetchCommentsOutput.Comments) != 0 {
-		s.ticket.Comments = fetchCommentsOutput.Comments
-		s.ticket.NextCursor = fetchCommentsOutput.NextCursor
+	if err := s.foldComments(); err != nil {
+		return err
 	}
 
-	// gen summary
-	genSummaryInput := GenSummaryInput{Ticket: s.ticket}
+	// gen summary, leaving time to repair an invalid one
+	options := workflow.GetActivityOptions(s)
+	options.StartToCloseTimeout = (MaxSummaryRepairs + 1) * summaryCallTimeout
+	ctx := workflow.WithActivityOptions(s, options)
+
+	genSummaryInput := GenSummaryInput{Ticket: s.promptTicket()}
 	genSummaryOutput := GenSummaryOutput{}
 
-	if err := workflow.ExecuteActivity(s.Context, s.activity.GenTicketSummary, genSummaryInput).
-		Get(s.Context, &genSummaryOutput); err != nil {
+	if err := workflow.ExecuteActivity(ctx, s.activity.GenTicketSummary, genSummaryInput).
+		Get(ctx, &genSummaryOutput); err != nil {
 		return err
 	}
 
-	if genSummaryOutput.Summary != "" {
-		s.ticket.Summary = genSummaryOutput.Summary
+	// translations of the previous summary are stale
+	summaryChanged := s.ticket.Summary == nil || s.ticket.Summary.String() != genSummaryOutput.Summary.String()
+	if summaryChanged {
+		s.ticket.Translations = nil
+	}
+	s.ticket.Summary = &genSummaryOutput.Summary
+	s.ticket.RawSummary = genSummaryOutput.Raw
+	s.ticket.SummaryHistory = history.Append(s.ticket.SummaryHistory, history.Version{
+		Summary:    genSummaryOutput.Summary.String(),
+		At:         workflow.Now(s),
+		Trigger:    summaryTrigger(events, len(newComments)),
+		Model:      genSummaryOutput.Model,
+		PromptHash: genSummaryOutput.PromptHash,
+		Route:      genSummaryOutput.Route,
+	}, MaxSummaryVersions)
+
+	// embed the new summary for related ticket lookups, which are best-effort
+	if summaryChanged && s.config.RelatedTickets {
+		if err := s.indexSummary(); err != nil {
+			s.logger.Warn("Failed to index summary", "ticket-id", s.ticket.ID, "error", err)
+		}
+	}
+
+	// score sentiment and urgency, escalating when thresholds are crossed;
+	// scores are an enrichment, so the summary is kept when scoring fails
+	if err := s.scoreTicket(); err != nil {
+		s.logger.Warn("Failed to score ticket", "ticket-id", s.ticket.ID, "error", err)
+	}
+
+	// classify against the configured taxonomy
+	if len(s.config.Classification.Taxonomy) > 0 {
+		if err := s.classifyTicket(); err != nil {
+			s.logger.Warn("Failed to classify ticket", "ticket-id", s.ticket.ID, "error", err)
+		}
+	}
+
+	// post the summary as an internal note for agents without the app
+	if s.shouldPostSummaryNote(events) {
+		if err := s.postSummaryNote(); err != nil {
+			s.logger.Warn("Failed to post summary note", "ticket-id", s.ticket.ID, "error", err)
+		}
+	}
+
+	// gen resolution summary for closed tickets
+	orgTicketSummary := s.ticket.Summary.String()
+	if s.ticket.Status == StatusClosed {
+		genResolutionInput := GenResolutionInput{Ticket: s.promptTicket(), SolvedAt: s.ticket.Metrics.SolvedAt}
+		genResolutionOutput := GenResolutionOutput{}
+
+		if err := workflow.ExecuteActivity(s.Context, s.activity.GenResolutionSummary, genResolutionInput).
+			Get(s.Context, &genResolutionOutput); err != nil {
+			return err
+		}
+
+		s.ticket.Resolution = &genResolutionOutput.Resolution
+		orgTicketSummary = genResolutionOutput.Resolution.String()
 	}
 
 	// signal organization
 	if s.ticket.OrganizationID != 0 {
+		ticketMetrics := s.ticket.Metrics.At(s.ticket.Status, workflow.Now(s))
 		signalOrganizationInput := SignalOrganizationInput{
 			OrganizationID: s.ticket.OrganizationID,
 			TicketID:       s.ticket.ID,
-			TicketSummary:  s.ticket.Summary,
+			TicketSummary:  orgTicketSummary,
+			Metrics:        &ticketMetrics,
 		}
 
 		if err := workflow.ExecuteActivity(s.Context, s.activity.SignalOrganization, signalOrganizationInput).
@@ -177,6 +716,585 @@ func (s *ticketWorkflow) processPendingUpsert(pendingUpsert *UpsertTicketInput)
 	return nil
 }
 
+// refreshTicket merges the latest ticket metadata and returns the change
+// events it recorded
+func (s *ticketWorkflow) refreshTicket(ticketID string) ([]TicketEvent, error) {
+	fetchTicketInput := FetchTicketInput{ID: ticketID, CustomFields: s.config.CustomFields}
+	fetchTicketOutput := FetchTicketOutput{}
+
+	if err := workflow.ExecuteActivity(s.Context, s.activity.FetchTicket, fetchTicketInput).
+		Get(s.Context, &fetchTicketOutput); err != nil {
+		return nil, err
+	}
+
+	// Only diff once the ticket has been fetched before
+	var events []TicketEvent
+	if s.ticket.ID != 0 {
+		events = diffTicket(s.ticket, fetchTicketOutput.Ticket, workflow.Now(s))
+		for _, event := range events {
+			s.logger.Debug("Detected ticket change", "ticket-id", s.ticket.ID, "type", event.Type, "from", event.From, "to", event.To)
+		}
+		s.ticket.Events = appendEvents(s.ticket.Events, events...)
+	}
+
+	prevStatus := s.ticket.Status
+	mergeMetadata(&s.ticket, fetchTicketOutput.Ticket)
+
+	// Zendesk only reports the current status, so time in status covers the
+	// transitions the workflow observed, from when it first saw the ticket
+	s.ticket.Metrics.StatusChange(prevStatus, s.ticket.Status, workflow.Now(s))
+
+	// a ticket first seen solved was solved by its latest update
+	if prevStatus == "" && s.ticket.Status == metrics.StatusSolved && s.ticket.UpdatedAt != nil {
+		s.ticket.Metrics.SolvedAt = s.ticket.UpdatedAt
+	}
+
+	return events, nil
+}
+
+// bury records the tombstone of a ticket that went away and removes it from
+// the related tickets index and its organization
+func (s *ticketWorkflow) bury(reason string, mergedInto int64) error {
+	s.ticket.Tombstone = &Tombstone{Reason: reason, MergedInto: mergedInto, At: workflow.Now(s)}
+	s.logger.Debug("Ticket is gone", "ticket-id", s.ticket.ID, "tombstone", s.ticket.Tombstone.String())
+
+	if s.config.RelatedTickets {
+		ctx := indexContext(s)
+		if err := workflow.ExecuteActivity(ctx, s.activity.UnindexTicket, UnindexTicketInput{TicketID: s.ticket.ID}).
+			Get(ctx, nil); err != nil {
+			s.logger.Warn("Failed to unindex ticket", "ticket-id", s.ticket.ID, "error", err)
+		}
+	}
+
+	if s.ticket.OrganizationID == 0 {
+		return nil
+	}
+
+	return s.removeFromOrganization(s.ticket.OrganizationID, mergedInto)
+}
+
+func (s *ticketWorkflow) removeFromOrganization(organizationID, mergedInto int64) error {
+	removeFromOrganizationInput := RemoveFromOrganizationInput{
+		OrganizationID: organizationID,
+		TicketID:       s.ticket.ID,
+		MergedInto:     mergedInto,
+	}
+
+	return workflow.ExecuteActivity(s.Context, s.activity.RemoveFromOrganization, removeFromOrganizationInput).
+		Get(s.Context, nil)
+}
+
+func (s *ticketWorkflow) indexSummary() error {
+	indexSummaryInput := IndexSummaryInput{
+		TicketID:       s.ticket.ID,
+		OrganizationID: s.ticket.OrganizationID,
+		Summary:        *s.ticket.Summary,
+	}
+
+	ctx := indexContext(s)
+	return workflow.ExecuteActivity(ctx, s.activity.IndexSummary, indexSummaryInput).
+		Get(ctx, nil)
+}
+
+// indexContext runs activities on the worker serving the related tickets
+// index, which is local to it. They fail after a minute when no worker
+// serves it, so they're only scheduled when one is configured to.
+func indexContext(ctx workflow.Context) workflow.Context {
+	options := workflow.GetActivityOptions(ctx)
+	options.TaskQueue = related.TaskQueue
+	options.ScheduleToStartTimeout = indexScheduleToStartTimeout
+	return workflow.WithActivityOptions(ctx, options)
+}
+
+func (s *ticketWorkflow) detectLanguage() error {
+	detectLanguageInput := DetectLanguageInput{Ticket: s.languageTicket()}
+	detectLanguageOutput := DetectLanguageOutput{}
+
+	if err := workflow.ExecuteActivity(s.Context, s.activity.DetectLanguage, detectLanguageInput).
+		Get(s.Context, &detectLanguageOutput); err != nil {
+		return err
+	}
+
+	s.ticket.Language = detectLanguageOutput.Language
+	return nil
+}
+
+func (s *ticketWorkflow) extractAttachments(comments []Comment) error {
+	attachments := textAttachments(comments)
+	if len(attachments) == 0 {
+		return nil
+	}
+
+	// Downloading many attachments takes longer than other activities, so it
+	// heartbeats between them instead
+	options := workflow.GetActivityOptions(s)
+	options.StartToCloseTimeout = 10 * time.Minute
+	options.HeartbeatTimeout = time.Minute
+	ctx := workflow.WithActivityOptions(s, options)
+
+	extractAttachmentsInput := ExtractAttachmentsInput{Attachments: attachments}
+	extractAttachmentsOutput := ExtractAttachmentsOutput{}
+
+	if err := workflow.ExecuteActivity(ctx, s.activity.ExtractAttachments, extractAttachmentsInput).
+		Get(ctx, &extractAttachmentsOutput); err != nil {
+		return err
+	}
+
+	s.ticket.AttachmentSnippets = append(s.ticket.AttachmentSnippets, extractAttachmentsOutput.Snippets...)
+	if len(s.ticket.AttachmentSnippets) > MaxAttachmentSnippets {
+		s.ticket.AttachmentSnippets = s.ticket.AttachmentSnippets[len(s.ticket.AttachmentSnippets)-MaxAttachmentSnippets:]
+	}
+
+	return nil
+}
+
+// foldComments keeps the thread within its budget as comments are appended.
+// In map-reduce mode chunk summaries stand in for the comments they cover,
+// so the thread isn't compacted too.
+func (s *ticketWorkflow) foldComments() error {
+	if s.config.MapReduce.Enabled {
+		if err := s.summarizeChunks(); err != nil {
+			return err
+		}
+		s.ticket.Comments = unchunkedComments(s.ticket.Comments, s.ticket.ChunkSummaries)
+		return nil
+	}
+
+	// fold older comments into the history digest when the thread is over budget
+	return s.compactComments()
+}
+
+func (s *ticketWorkflow) compactComments() error {
+	if commentsSize(s.ticket.Comments) <= MaxThreadBytes {
+		return nil
+	}
+
+	older, recent := splitComments(s.ticket.Comments, MaxThreadBytes/2)
+	if len(older) == 0 {
+		return nil
+	}
+
+	compactCommentsInput := CompactCommentsInput{Digest: s.ticket.HistoryDigest, Comments: older}
+	compactCommentsOutput := CompactCommentsOutput{}
+
+	if err := workflow.ExecuteActivity(s.Context, s.activity.CompactComments, compactCommentsInput).
+		Get(s.Context, &compactCommentsOutput); err != nil {
+		return err
+	}
+
+	s.ticket.HistoryDigest = compactCommentsOutput.Digest
+	s.ticket.Comments = recent
+
+	return nil
+}
+
+// summarizeChunks summarizes the comments after the cached chunk summaries
+// in chunks of the configured estimated tokens, in parallel. The latest
+// comments, up to a chunk, are left verbatim until more follow.
+func (s *ticketWorkflow) summarizeChunks() error {
+	limit := s.config.MapReduce.ChunkTokens
+	if limit <= 0 {
+		limit = DefaultChunkTokens
+	}
+
+	chunks := chunkComments(unchunkedComments(s.ticket.Comments, s.ticket.ChunkSummaries), limit)
+
+	futures := make([]workflow.Future, len(chunks))
+	for n, chunk := range chunks {
+		summarizeChunkInput := SummarizeChunkInput{
+			TicketID: s.ticket.ID,
+			Subject:  s.ticket.Subject,
+			Comments: chunk,
+		}
+		futures[n] = workflow.ExecuteActivity(s.Context, s.activity.SummarizeChunk, summarizeChunkInput)
+	}
+
+	// chunks summarized before a failure stay cached for the retry
+	for n, future := range futures {
+		summarizeChunkOutput := SummarizeChunkOutput{}
+		if err := future.Get(s.Context, &summarizeChunkOutput); err != nil {
+			return err
+		}
+
+		chunk := chunks[n]
+		s.ticket.ChunkSummaries = append(s.ticket.ChunkSummaries, ChunkSummary{
+			FirstCommentID: chunk[0].ID,
+			LastCommentID:  chunk[len(chunk)-1].ID,
+			Summary:        summarizeChunkOutput.Summary,
+		})
+	}
+
+	return s.combineChunkSummaries(limit)
+}
+
+// combineChunkSummaries summarizes the oldest chunk summaries together again
+// while together they exceed a chunk, so the ticket summary's input stays
+// bounded however long the thread grows
+func (s *ticketWorkflow) combineChunkSummaries(limit int) error {
+	for len(s.ticket.ChunkSummaries) > 1 && chunkSummariesTokens(s.ticket.ChunkSummaries) > limit {
+		// at least two are combined, so every pass shortens the list
+		n := 2
+		for n < len(s.ticket.ChunkSummaries) && chunkSummariesTokens(s.ticket.ChunkSummaries[:n+1]) <= limit {
+			n++
+		}
+		group := s.ticket.ChunkSummaries[:n]
+
+		summarizeChunkInput := SummarizeChunkInput{TicketID: s.ticket.ID, Subject: s.ticket.Subject}
+		for _, chunk := range group {
+			summarizeChunkInput.Summaries = append(summarizeChunkInput.Summaries, chunk.Summary)
+		}
+		summarizeChunkOutput := SummarizeChunkOutput{}
+
+		if err := workflow.ExecuteActivity(s.Context, s.activity.SummarizeChunk, summarizeChunkInput).
+			Get(s.Context, &summarizeChunkOutput); err != nil {
+			return err
+		}
+
+		combined := ChunkSummary{
+			FirstCommentID: group[0].FirstCommentID,
+			LastCommentID:  group[n-1].LastCommentID,
+			Summary:        summarizeChunkOutput.Summary,
+		}
+		s.ticket.ChunkSummaries = append([]ChunkSummary{combined}, s.ticket.ChunkSummaries[n:]...)
+	}
+
+	return nil
+}
+
+// promptTicket is the ticket as prompts read it, without the LLM output the
+// workflow keeps about it, so activity inputs stay small in the history. In
+// map-reduce mode the comments covered by chunk summaries are left out.
+func (s *ticketWorkflow) promptTicket() Ticket {
+	ticket := cleanse(s.ticket)
+	if s.config.MapReduce.Enabled {
+		ticket.Comments = unchunkedComments(ticket.Comments, ticket.ChunkSummaries)
+	} else {
+		ticket.ChunkSummaries = nil
+	}
+	return ticket
+}
+
+// languageTicket is the part of the ticket language detection reads: the
+// text the customer wrote
+func (s *ticketWorkflow) languageTicket() Ticket {
+	ticket := Ticket{ID: s.ticket.ID, Subject: s.ticket.Subject, Description: s.ticket.Description}
+	for _, comment := range s.ticket.Comments {
+		if comment.Author.Role == RoleEndUser {
+			ticket.Comments = append(ticket.Comments, comment)
+		}
+	}
+	return ticket
+}
+
+func (s *ticketWorkflow) scoreTicket() error {
+	scoreTicketInput := ScoreTicketInput{Ticket: s.promptTicket()}
+	scoreTicketOutput := ScoreTicketOutput{}
+
+	if err := workflow.ExecuteActivity(s.Context, s.activity.ScoreTicket, scoreTicketInput).
+		Get(s.Context, &scoreTicketOutput); err != nil {
+		return err
+	}
+
+	reason := escalationReason(s.ticket.Scores, scoreTicketOutput.Scores, s.config)
+	s.ticket.Scores = &scoreTicketOutput.Scores
+
+	if reason == "" || s.ticket.Status == StatusClosed {
+		return nil
+	}
+
+	now := workflow.Now(s)
+	s.logger.Debug("Escalating ticket", "ticket-id", s.ticket.ID, "reason", reason)
+	s.ticket.Events = appendEvents(s.ticket.Events, TicketEvent{Type: EventEscalated, To: reason, At: now})
+
+	if s.ticket.OrganizationID == 0 {
+		return nil
+	}
+
+	escalateOrganizationInput := EscalateOrganizationInput{
+		OrganizationID: s.ticket.OrganizationID,
+		Escalation: org.Escalation{
+			TicketID:  s.ticket.ID,
+			Reason:    reason,
+			Sentiment: s.ticket.Scores.Sentiment,
+			Urgency:   s.ticket.Scores.Urgency,
+			At:        now,
+		},
+	}
+
+	return workflow.ExecuteActivity(s.Context, s.activity.EscalateOrganization, escalateOrganizationInput).
+		Get(s.Context, nil)
+}
+
+// classifyTicket classifies the ticket against the taxonomy fields it hasn't
+// been classified by yet. Classifications are kept once written back, as
+// reclassifying would rewrite the ticket on every update echoing back through
+// the webhook.
+func (s *ticketWorkflow) classifyTicket() error {
+	taxonomy := slices.DeleteFunc(slices.Clone(s.config.Classification.Taxonomy), func(field config.TaxonomyField) bool {
+		_, ok := s.ticket.Classification[field.Name]
+		return ok
+	})
+	if len(taxonomy) == 0 {
+		return nil
+	}
+
+	classifyTicketInput := ClassifyTicketInput{Ticket: s.promptTicket(), Taxonomy: taxonomy}
+	classifyTicketOutput := ClassifyTicketOutput{}
+
+	if err := workflow.ExecuteActivity(s.Context, s.activity.ClassifyTicket, classifyTicketInput).
+		Get(s.Context, &classifyTicketOutput); err != nil {
+		return err
+	}
+
+	if len(classifyTicketOutput.Classification) == 0 {
+		return nil
+	}
+
+	// Closed tickets can no longer be updated in Zendesk
+	if s.config.Classification.DryRun || s.ticket.Status == StatusClosed {
+		s.logger.Debug("Recorded classification without writing back", "ticket-id", s.ticket.ID, "dry-run", s.config.Classification.DryRun)
+	} else {
+		updateTicketFieldsInput := UpdateTicketFieldsInput{
+			TicketID:       s.ticket.ID,
+			Taxonomy:       taxonomy,
+			Classification: classifyTicketOutput.Classification,
+		}
+
+		updateTicketFieldsOutput := UpdateTicketFieldsOutput{}
+
+		// Fields are only recorded once written back, so a failed write is
+		// classified again on the next update
+		if err := workflow.ExecuteActivity(s.Context, s.activity.UpdateTicketFields, updateTicketFieldsInput).
+			Get(s.Context, &updateTicketFieldsOutput); err != nil {
+			return err
+		}
+		s.recordWrite(updateTicketFieldsOutput.UpdatedAt)
+	}
+
+	if s.ticket.Classification == nil {
+		s.ticket.Classification = make(map[string]string)
+	}
+	maps.Copy(s.ticket.Classification, classifyTicketOutput.Classification)
+
+	return nil
+}
+
+func (s *ticketWorkflow) shouldPostSummaryNote(events []TicketEvent) bool {
+	cfg := s.config.SummaryNote
+
+	// Closed tickets can no longer be commented on
+	if !cfg.Enabled || s.ticket.Status == StatusClosed {
+		return false
+	}
+
+	reassigned := slices.ContainsFunc(events, func(event TicketEvent) bool {
+		return event.Type == EventAssigneeChanged
+	})
+	if cfg.OnReassignment && reassigned {
+		return true
+	}
+
+	return cfg.EveryPublicComments > 0 && s.ticket.PublicCommentsSinceNote >= cfg.EveryPublicComments
+}
+
+func (s *ticketWorkflow) postSummaryNote() error {
+	postSummaryNoteInput := PostSummaryNoteInput{TicketID: s.ticket.ID, Summary: *s.ticket.Summary}
+	postSummaryNoteOutput := PostSummaryNoteOutput{}
+
+	if err := workflow.ExecuteActivity(s.Context, s.activity.PostSummaryNote, postSummaryNoteInput).
+		Get(s.Context, &postSummaryNoteOutput); err != nil {
+		return err
+	}
+	s.recordWrite(postSummaryNoteOutput.UpdatedAt)
+
+	s.logger.Debug("Posted summary note", "ticket-id", s.ticket.ID)
+	s.ticket.PublicCommentsSinceNote = 0
+
+	return nil
+}
+
+func (s *ticketWorkflow) validateDraftReply(input DraftReplyInput) error {
+	if s.ticket.ID == 0 {
+		return errors.New("ticket has not been fetched yet")
+	}
+	return nil
+}
+
+func (s *ticketWorkflow) handleDraftReply(ctx workflow.Context, input DraftReplyInput) (DraftReplyOutput, error) {
+	ctx = workflow.WithActivityOptions(ctx, workflow.GetActivityOptions(s))
+
+	genDraftReplyInput := GenDraftReplyInput{Ticket: s.promptTicket(), Instruction: input.Instruction}
+	genDraftReplyOutput := GenDraftReplyOutput{}
+
+	if err := workflow.ExecuteActivity(ctx, s.activity.GenDraftReply, genDraftReplyInput).
+		Get(ctx, &genDraftReplyOutput); err != nil {
+		return DraftReplyOutput{}, err
+	}
+
+	return DraftReplyOutput{Draft: genDraftReplyOutput.Draft}, nil
+}
+
+func (s *ticketWorkflow) validateRefresh(input UpsertTicketInput) error {
+	if input.TicketID == "" {
+		return errors.New("ticket ID is required")
+	}
+	if s.ticket.Resolution != nil {
+		return errors.New("ticket is closed")
+	}
+	if s.ticket.Tombstone != nil {
+		return fmt.Errorf("ticket is %s", s.ticket.Tombstone)
+	}
+	return nil
+}
+
+// handleRefresh asks the main loop to run the pipeline right away, skipping
+// the quiet period, and returns the fresh summary. A refresh already queued
+// serves concurrent requests too.
+func (s *ticketWorkflow) handleRefresh(ctx workflow.Context, input UpsertTicketInput) (*QueryTicketOutput, error) {
+	requestedAfter := s.passesStarted
+	s.refreshCh.SendAsync(&input)
+
+	if err := workflow.Await(ctx, func() bool { return s.passesCompleted > requestedAfter }); err != nil {
+		return nil, err
+	}
+
+	output := s.output()
+	return &output, nil
+}
+
+func (s *ticketWorkflow) validateTranslate(input TranslateInput) error {
+	if _, err := genai.ParseLocale(input.Locale); err != nil {
+		return err
+	}
+	if s.ticket.Summary == nil {
+		return errors.New("ticket has not been summarized yet")
+	}
+	return nil
+}
+
+// handleTranslate returns the summary translated to the locale, translating
+// the canonical summary on first request and caching it until the summary
+// changes. Cached translations are served by QueryTicketTranslation instead,
+// so this only runs on a cache miss.
+func (s *ticketWorkflow) handleTranslate(ctx workflow.Context, input TranslateInput) (*QueryTicketOutput, error) {
+	locale, _ := genai.ParseLocale(input.Locale)
+
+	translation, ok := s.ticket.Translations[locale]
+	if !ok {
+		ctx = workflow.WithActivityOptions(ctx, workflow.GetActivityOptions(s))
+		s.translationsRun++
+		s.translatedCh.SendAsync(struct{}{})
+
+		summary := *s.ticket.Summary
+		translateSummaryInput := TranslateSummaryInput{Summary: summary, Locale: locale}
+		translateSummaryOutput := TranslateSummaryOutput{}
+
+		if err := workflow.ExecuteActivity(ctx, s.activity.TranslateSummary, translateSummaryInput).
+			Get(ctx, &translateSummaryOutput); err != nil {
+			return nil, err
+		}
+		translation = translateSummaryOutput.Summary
+
+		// Only cache if the summary wasn't regenerated while translating
+		if s.ticket.Summary != nil && s.ticket.Summary.String() == summary.String() {
+			if s.ticket.Translations == nil || len(s.ticket.Translations) >= MaxSummaryTranslations {
+				s.ticket.Translations = make(map[string]TicketSummary)
+			}
+			s.ticket.Translations[locale] = translation
+		}
+	}
+
+	output := s.translatedOutput(locale, translation)
+	return &output, nil
+}
+
+// handleQueryTranslation returns the cached translation of the summary to
+// the locale, failing when it hasn't been translated yet
+func (s *ticketWorkflow) handleQueryTranslation(input TranslateInput) (QueryTicketOutput, error) {
+	locale, err := genai.ParseLocale(input.Locale)
+	if err != nil {
+		return QueryTicketOutput{}, err
+	}
+
+	translation, ok := s.ticket.Translations[locale]
+	if !ok {
+		return QueryTicketOutput{}, errors.New("summary has not been translated to the locale yet")
+	}
+
+	return s.translatedOutput(locale, translation), nil
+}
+
+func (s *ticketWorkflow) translatedOutput(locale string, translation TicketSummary) QueryTicketOutput {
+	output := s.output()
+	output.Summary = &translation
+	output.RawSummary = translation.String()
+	output.Locale = locale
+	return output
+}
+
+func (s *ticketWorkflow) validateRelatedTickets(input RelatedTicketsInput) error {
+	if input.Limit < 0 || input.Limit > MaxRelatedTickets {
+		return fmt.Errorf("limit must be between 1 and %d", MaxRelatedTickets)
+	}
+	if s.ticket.Summary == nil {
+		return errors.New("ticket has not been summarized yet")
+	}
+	if s.ticket.Tombstone != nil {
+		return fmt.Errorf("ticket is %s", s.ticket.Tombstone)
+	}
+	return nil
+}
+
+// handleQueryRelatedTicketsLookup returns what RelatedTicketsWorkflow looks
+// related tickets up by
+func (s *ticketWorkflow) handleQueryRelatedTicketsLookup(input RelatedTicketsInput) (FindRelatedTicketsInput, error) {
+	if err := s.validateRelatedTickets(input); err != nil {
+		return FindRelatedTicketsInput{}, err
+	}
+	return s.relatedTicketsLookup(input), nil
+}
+
+func (s *ticketWorkflow) relatedTicketsLookup(input RelatedTicketsInput) FindRelatedTicketsInput {
+	limit := input.Limit
+	if limit == 0 {
+		limit = DefaultRelatedTickets
+	}
+
+	return FindRelatedTicketsInput{
+		TicketID:       s.ticket.ID,
+		OrganizationID: s.ticket.OrganizationID,
+		Summary:        *s.ticket.Summary,
+		Limit:          limit,
+	}
+}
+
+func (s *ticketWorkflow) handleQuerySummaryHistory() ([]history.Version, error) {
+	return s.ticket.SummaryHistory, nil
+}
+
 func (s *ticketWorkflow) handleQuerySummary() (QueryTicketOutput, error) {
-	return QueryTicketOutput{Summary: s.ticket.Summary}, nil
+	return s.output(), nil
+}
+
+func (s *ticketWorkflow) output() QueryTicketOutput {
+	output := QueryTicketOutput{
+		Summary:    s.ticket.Summary,
+		RawSummary: s.ticket.RawSummary,
+		Language:   s.ticket.Language,
+		Status:     s.ticket.Status,
+		Events:     s.ticket.Events,
+		Resolution: s.ticket.Resolution,
+		Scores:     s.ticket.Scores,
+		Tombstone:  s.ticket.Tombstone,
+		Metrics:    s.ticket.Metrics.At(s.ticket.Status, workflow.Now(s)),
+
+		Classification: s.ticket.Classification,
+	}
+
+	// Report what happened to the ticket rather than its last status
+	if s.ticket.Tombstone != nil {
+		output.Status = s.ticket.Tombstone.String()
+	}
+
+	return output
 }
@@ -1,13 +1,23 @@
 package ticket
 
 import (
+	"context"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
+	"github.com/taonic/ticketfu/config"
+	"github.com/taonic/ticketfu/genai"
+	"github.com/taonic/ticketfu/worker/history"
+	"github.com/taonic/ticketfu/worker/related"
+	"go.temporal.io/sdk/activity"
 	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
 )
 
 type TicketWorkflowTestSuite struct {
@@ -24,7 +34,19 @@ func (s *TicketWorkflowTestSuite) TearDownTest() {
 	s.env.AssertExpectations(s.T())
 }
 
+// mockDefaults scores every update below the escalation thresholds and
+// detects tickets as written in English
+func (s *TicketWorkflowTestSuite) mockDefaults() {
+	s.env.OnActivity((*Activity)(nil).ScoreTicket, mock.Anything, mock.Anything).
+		Return(&ScoreTicketOutput{Scores: Scores{FrustrationTrend: TrendStable, Urgency: 0.2}}, nil)
+	s.env.OnActivity((*Activity)(nil).DetectLanguage, mock.Anything, mock.Anything).
+		Return(&DetectLanguageOutput{Language: "en"}, nil).Maybe()
+	s.env.OnActivity((*Activity)(nil).IndexSummary, mock.Anything, mock.Anything).Return(nil).Maybe()
+}
+
 func (s *TicketWorkflowTestSuite) TestBasicTicketWorkflow() {
+	s.mockDefaults()
+
 	// Create initial empty ticket
 	ticket := Ticket{ID: 0}
 
@@ -44,6 +66,8 @@ func (s *TicketWorkflowTestSuite) TestBasicTicketWorkflow() {
 			OrganizationName: "Test Organization",
 			CreatedAt:        &time.Time{},
 			UpdatedAt:        &time.Time{},
+			Tags:             []string{"vip"},
+			CustomFields:     map[string]string{"product_area": "billing"},
 		},
 	}, nil).Once()
 
@@ -51,20 +75,22 @@ func (s *TicketWorkflowTestSuite) TestBasicTicketWorkflow() {
 		ID:     "12345",
 		Cursor: "",
 	}).Return(&FetchCommentsOutput{
-		Comments:   []string{"First comment", "Second comment"},
+		Comments:   []Comment{{Body: "First comment"}, {Body: "Second comment"}},
 		NextCursor: "next-page-token",
 	}, nil).Once()
 
+	// The summary sees the ticket's Zendesk metadata
 	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.MatchedBy(func(input GenSummaryInput) bool {
-		return input.Ticket.ID == 12345 && len(input.Ticket.Comments) == 2
+		return input.Ticket.ID == 12345 && len(input.Ticket.Comments) == 2 &&
+			len(input.Ticket.Tags) == 1 && input.Ticket.CustomFields["product_area"] == "billing"
 	})).Return(&GenSummaryOutput{
-		Summary: "Test ticket summary",
+		Summary: TicketSummary{Summary: "Test ticket summary"},
 	}, nil).Once()
 
 	s.env.OnActivity((*Activity)(nil).SignalOrganization, mock.Anything, mock.MatchedBy(func(input SignalOrganizationInput) bool {
 		return input.OrganizationID == 101 &&
 			input.TicketID == 12345 &&
-			input.TicketSummary == "Test ticket summary"
+			input.TicketSummary == TicketSummary{Summary: "Test ticket summary"}.String()
 	})).Return(nil).Once()
 
 	// Send signal to start processing
@@ -85,10 +111,12 @@ func (s *TicketWorkflowTestSuite) TestBasicTicketWorkflow() {
 	future, err := s.env.QueryWorkflow(QueryTicketSummary, nil)
 	future.Get(&output)
 	s.NoError(err)
-	s.Equal("Test ticket summary", output.Summary)
+	s.Equal("Test ticket summary", output.Summary.Summary)
 }
 
 func (s *TicketWorkflowTestSuite) TestTicketWithoutOrganization() {
+	s.mockDefaults()
+
 	// Create initial empty ticket
 	ticket := Ticket{}
 
@@ -113,13 +141,13 @@ func (s *TicketWorkflowTestSuite) TestTicketWithoutOrganization() {
 
 	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
 		Return(&FetchCommentsOutput{
-			Comments:   []string{"First comment", "Second comment"},
+			Comments:   []Comment{{Body: "First comment"}, {Body: "Second comment"}},
 			NextCursor: "",
 		}, nil).Once()
 
 	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.Anything).
 		Return(&GenSummaryOutput{
-			Summary: "Test ticket summary",
+			Summary: TicketSummary{Summary: "Test ticket summary"},
 		}, nil).Once()
 
 	// SignalOrganization should NOT be called since OrganizationID is 0
@@ -138,7 +166,36 @@ func (s *TicketWorkflowTestSuite) TestTicketWithoutOrganization() {
 	s.True(s.env.IsWorkflowCompleted())
 }
 
+func (s *TicketWorkflowTestSuite) TestSummaryRepairTimeout() {
+	s.mockDefaults()
+
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{Comments: []Comment{{Body: "First comment"}}}, nil).Once()
+
+	// Each call generating or repairing the summary gets its own share of the timeout
+	var timeout time.Duration
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.Anything).
+		Return(func(ctx context.Context, input GenSummaryInput) (*GenSummaryOutput, error) {
+			info := activity.GetInfo(ctx)
+			timeout = info.Deadline.Sub(info.StartedTime)
+			return &GenSummaryOutput{Summary: TicketSummary{Summary: "Test ticket summary"}}, nil
+		}).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345"})
+	}, time.Millisecond*100)
+
+	s.env.ExecuteWorkflow(TicketWorkflow, Ticket{})
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.Equal((MaxSummaryRepairs+1)*summaryCallTimeout, timeout)
+}
+
 func (s *TicketWorkflowTestSuite) TestMultipleUpdates() {
+	s.mockDefaults()
+
 	// Create initial empty ticket
 	ticket := Ticket{ID: 0}
 
@@ -150,6 +207,7 @@ func (s *TicketWorkflowTestSuite) TestMultipleUpdates() {
 			ID:               12345,
 			Subject:          "Test Subject",
 			Description:      "Test Description",
+			Status:           "new",
 			OrganizationID:   101,
 			OrganizationName: "Test Organization",
 		},
@@ -159,32 +217,52 @@ func (s *TicketWorkflowTestSuite) TestMultipleUpdates() {
 		ID:     "12345",
 		Cursor: "",
 	}).Return(&FetchCommentsOutput{
-		Comments:   []string{"First comment"},
+		Comments:   []Comment{{Body: "First comment"}},
 		NextCursor: "cursor1",
 	}, nil).Once()
 
 	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.MatchedBy(func(input GenSummaryInput) bool {
 		return len(input.Ticket.Comments) == 1
 	})).Return(&GenSummaryOutput{
-		Summary: "First summary",
+		Summary: TicketSummary{Summary: "First summary"},
 	}, nil).Once()
 
 	s.env.OnActivity((*Activity)(nil).SignalOrganization, mock.Anything, mock.Anything).
 		Return(nil).Once()
 
+	// Metadata is refreshed on the second update
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, FetchTicketInput{
+		ID: "12345",
+	}).Return(&FetchTicketOutput{
+		Ticket: Ticket{
+			ID:               12345,
+			Subject:          "Test Subject",
+			Description:      "Test Description",
+			Status:           "open",
+			AssigneeID:       201,
+			Assignee:         "Test Agent",
+			OrganizationID:   101,
+			OrganizationName: "Test Organization",
+		},
+	}, nil).Once()
+
 	// Mock the activities for second update (with the cursor from first update)
 	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, FetchCommentsInput{
 		ID:     "12345",
 		Cursor: "cursor1",
 	}).Return(&FetchCommentsOutput{
-		Comments:   []string{"Second comment", "Third comment"},
+		Comments:   []Comment{{Body: "Second comment"}, {Body: "Third comment"}},
 		NextCursor: "cursor2",
 	}, nil).Once()
 
 	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.MatchedBy(func(input GenSummaryInput) bool {
-		return len(input.Ticket.Comments) == 2 // Only the new comments, not the old ones
+		return len(input.Ticket.Comments) == 3 && // The new comments are appended to the old ones
+			input.Ticket.Status == "open" &&
+			len(input.Ticket.Events) == 0 // Events are kept for the query, not the prompt
 	})).Return(&GenSummaryOutput{
-		Summary: "Updated summary",
+		Summary:    TicketSummary{Summary: "Updated summary"},
+		Model:      "test-model",
+		PromptHash: "abc123",
 	}, nil).Once()
 
 	s.env.OnActivity((*Activity)(nil).SignalOrganization, mock.Anything, mock.Anything).
@@ -197,17 +275,17 @@ func (s *TicketWorkflowTestSuite) TestMultipleUpdates() {
 		})
 	}, time.Millisecond*100)
 
-	// Send second signal
+	// Send second signal after the quiet period of the first one
 	s.env.RegisterDelayedCallback(func() {
 		s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{
 			TicketID: "12345",
 		})
-	}, time.Millisecond*200)
+	}, time.Minute)
 
 	// Cancel the workflow after processing both signals
 	s.env.RegisterDelayedCallback(func() {
 		s.env.CancelWorkflow()
-	}, time.Millisecond*300)
+	}, 2*time.Minute)
 
 	// Execute workflow
 	s.env.ExecuteWorkflow(TicketWorkflow, ticket)
@@ -222,9 +300,1530 @@ func (s *TicketWorkflowTestSuite) TestMultipleUpdates() {
 	future, err := s.env.QueryWorkflow(QueryTicketSummary, nil)
 	future.Get(&output)
 	s.NoError(err)
-	s.Equal("Updated summary", output.Summary)
+	s.Equal("Updated summary", output.Summary.Summary)
+	s.Require().Len(output.Events, 2)
+	s.Equal(EventStatusChanged, output.Events[0].Type)
+	s.Equal("new", output.Events[0].From)
+	s.Equal("open", output.Events[0].To)
+	s.Equal(EventAssigneeChanged, output.Events[1].Type)
+	s.Equal("Test Agent", output.Events[1].To)
+
+	// Both summaries are kept as versions
+	var versions []history.Version
+	future, err = s.env.QueryWorkflow(QueryTicketSummaryHistory)
+	s.NoError(err)
+	s.NoError(future.Get(&versions))
+	s.Require().Len(versions, 2)
+	s.Equal(1, versions[0].Version)
+	s.Equal(TriggerNewComments, versions[0].Trigger)
+	s.Equal(2, versions[1].Version)
+	s.Equal(TicketSummary{Summary: "Updated summary"}.String(), versions[1].Summary)
+	s.Equal("status_changed, assignee_changed, new_comments", versions[1].Trigger)
+	s.Equal("test-model", versions[1].Model)
+	s.Equal("abc123", versions[1].PromptHash)
 }
 
-func TestTicketWorkflowSuite(t *testing.T) {
-	suite.Run(t, new(TicketWorkflowTestSuite))
+func (s *TicketWorkflowTestSuite) TestCommentCompaction() {
+	s.mockDefaults()
+
+	// Start with a thread that is just under the budget
+	large := strings.Repeat("a", MaxThreadBytes/2)
+	ticket := Ticket{
+		ID:            12345,
+		Comments:      []Comment{{Body: "Original problem"}, {Body: large}},
+		NextCursor:    "cursor1",
+		HistoryDigest: "Previous digest",
+	}
+
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, FetchTicketInput{
+		ID: "12345",
+	}).Return(&FetchTicketOutput{
+		Ticket: Ticket{ID: 12345},
+	}, nil).Once()
+
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, FetchCommentsInput{
+		ID:     "12345",
+		Cursor: "cursor1",
+	}).Return(&FetchCommentsOutput{
+		Comments:   []Comment{{Body: large}},
+		NextCursor: "cursor2",
+	}, nil).Once()
+
+	// The older comments are folded into the digest along with the previous digest
+	s.env.OnActivity((*Activity)(nil).CompactComments, mock.Anything, mock.MatchedBy(func(input CompactCommentsInput) bool {
+		return input.Digest == "Previous digest" &&
+			len(input.Comments) == 2 &&
+			input.Comments[0].Body == "Original problem"
+	})).Return(&CompactCommentsOutput{
+		Digest: "Compacted digest",
+	}, nil).Once()
+
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.MatchedBy(func(input GenSummaryInput) bool {
+		return input.Ticket.HistoryDigest == "Compacted digest" && len(input.Ticket.Comments) == 1
+	})).Return(&GenSummaryOutput{
+		Summary: TicketSummary{Summary: "Summary with digest"},
+	}, nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{
+			TicketID: "12345",
+		})
+	}, time.Millisecond*100)
+
+	s.env.ExecuteWorkflow(TicketWorkflow, ticket)
+
+	s.True(s.env.IsWorkflowCompleted())
+}
+
+func (s *TicketWorkflowTestSuite) TestCommentBatchesCompacted() {
+	s.mockDefaults()
+
+	older := Comment{ID: 1, Body: strings.Repeat("a", MaxThreadBytes/2+1)}
+	recent := Comment{ID: 2, Body: strings.Repeat("b", MaxThreadBytes/2+1)}
+	latest := Comment{ID: 3, Body: "Still broken"}
+
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345}}, nil).Once()
+
+	// A thread too long for one batch is fetched in several, compacted in
+	// between instead of losing its oldest comments
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, FetchCommentsInput{ID: "12345"}).
+		Return(&FetchCommentsOutput{Comments: []Comment{older, recent}, NextCursor: "cursor1", HasMore: true}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).CompactComments, mock.Anything, CompactCommentsInput{Comments: []Comment{older}}).
+		Return(&CompactCommentsOutput{Digest: "Digest"}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, FetchCommentsInput{ID: "12345", Cursor: "cursor1"}).
+		Return(&FetchCommentsOutput{Comments: []Comment{latest}, NextCursor: "cursor2"}, nil).Once()
+
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.MatchedBy(func(input GenSummaryInput) bool {
+		return input.Ticket.HistoryDigest == "Digest" &&
+			assert.ObjectsAreEqual([]Comment{recent, latest}, input.Ticket.Comments)
+	})).Return(&GenSummaryOutput{Summary: TicketSummary{Summary: "Summary"}}, nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345"})
+	}, time.Millisecond*100)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, time.Minute)
+
+	s.env.ExecuteWorkflow(TicketWorkflow, Ticket{})
+
+	s.True(s.env.IsWorkflowCompleted())
+}
+
+func (s *TicketWorkflowTestSuite) TestMapReduceSummary() {
+	s.mockDefaults()
+
+	comments := func(ids ...int64) []Comment {
+		var comments []Comment
+		for _, id := range ids {
+			comments = append(comments, Comment{ID: id, Body: fmt.Sprintf("Comment %d", id)})
+		}
+		return comments
+	}
+	chunk := func(first int64) any {
+		return mock.MatchedBy(func(input SummarizeChunkInput) bool {
+			return len(input.Comments) == 2 && input.Comments[0].ID == first
+		})
+	}
+
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345}}, nil).Twice()
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{Comments: comments(1, 2, 3, 4, 5)}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{Comments: comments(6, 7, 8)}, nil).Once()
+
+	// Complete chunks are summarized once, leaving the latest comments verbatim
+	s.env.OnActivity((*Activity)(nil).SummarizeChunk, mock.Anything, chunk(1)).
+		Return(&SummarizeChunkOutput{Summary: "Chunk 1"}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).SummarizeChunk, mock.Anything, chunk(3)).
+		Return(&SummarizeChunkOutput{Summary: "Chunk 2"}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).SummarizeChunk, mock.Anything, chunk(5)).
+		Return(&SummarizeChunkOutput{Summary: "Chunk 3"}, nil).Once()
+
+	// Together the chunk summaries then exceed a chunk, so the oldest are
+	// combined
+	s.env.OnActivity((*Activity)(nil).SummarizeChunk, mock.Anything, SummarizeChunkInput{
+		TicketID:  12345,
+		Summaries: []string{"Chunk 1", "Chunk 2"},
+	}).Return(&SummarizeChunkOutput{Summary: "Early"}, nil).Once()
+
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.MatchedBy(func(input GenSummaryInput) bool {
+		return assert.ObjectsAreEqual([]ChunkSummary{
+			{FirstCommentID: 1, LastCommentID: 2, Summary: "Chunk 1"},
+			{FirstCommentID: 3, LastCommentID: 4, Summary: "Chunk 2"},
+		}, input.Ticket.ChunkSummaries) && assert.ObjectsAreEqual(comments(5), input.Ticket.Comments)
+	})).Return(&GenSummaryOutput{Summary: TicketSummary{Summary: "First"}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.MatchedBy(func(input GenSummaryInput) bool {
+		return assert.ObjectsAreEqual([]ChunkSummary{
+			{FirstCommentID: 1, LastCommentID: 4, Summary: "Early"},
+			{FirstCommentID: 5, LastCommentID: 6, Summary: "Chunk 3"},
+		}, input.Ticket.ChunkSummaries) && assert.ObjectsAreEqual(comments(7, 8), input.Ticket.Comments)
+	})).Return(&GenSummaryOutput{Summary: TicketSummary{Summary: "Second"}}, nil).Once()
+
+	for i := 1; i <= 2; i++ {
+		s.env.RegisterDelayedCallback(func() {
+			s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345"})
+		}, time.Duration(i)*time.Minute)
+	}
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, 3*time.Minute)
+
+	cfg := DefaultWorkflowConfig
+	// "Comment N" is estimated at 3 tokens, so two fit in a chunk
+	cfg.MapReduce = config.MapReduceConfig{Enabled: true, ChunkTokens: 6}
+	s.env.ExecuteWorkflow(NewTicketWorkflow(cfg), Ticket{ID: 12345})
+
+	s.True(s.env.IsWorkflowCompleted())
+}
+
+func (s *TicketWorkflowTestSuite) TestMapReduceLongThread() {
+	s.mockDefaults()
+
+	comment := func(id int64, body string) Comment { return Comment{ID: id, Body: body} }
+	large := strings.Repeat("a", MaxThreadBytes/2)
+
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345}}, nil).Once()
+
+	// Every batch is chunked, none of the thread is compacted or cut short
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, FetchCommentsInput{ID: "12345"}).
+		Return(&FetchCommentsOutput{
+			Comments:   []Comment{comment(1, large), comment(2, large), comment(3, large)},
+			NextCursor: "cursor1",
+			HasMore:    true,
+		}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).SummarizeChunk, mock.Anything, SummarizeChunkInput{
+		TicketID: 12345,
+		Comments: []Comment{comment(1, large), comment(2, large)},
+	}).Return(&SummarizeChunkOutput{Summary: "Chunk 1"}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, FetchCommentsInput{ID: "12345", Cursor: "cursor1"}).
+		Return(&FetchCommentsOutput{Comments: []Comment{comment(4, "Still broken")}, NextCursor: "cursor2"}, nil).Once()
+
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.MatchedBy(func(input GenSummaryInput) bool {
+		return input.Ticket.HistoryDigest == "" &&
+			assert.ObjectsAreEqual([]ChunkSummary{{FirstCommentID: 1, LastCommentID: 2, Summary: "Chunk 1"}}, input.Ticket.ChunkSummaries) &&
+			assert.ObjectsAreEqual([]Comment{comment(3, large), comment(4, "Still broken")}, input.Ticket.Comments)
+	})).Return(&GenSummaryOutput{Summary: TicketSummary{Summary: "Summary"}}, nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345"})
+	}, time.Millisecond*100)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, time.Minute)
+
+	cfg := DefaultWorkflowConfig
+	// Two of the large comments fit in a chunk
+	cfg.MapReduce = config.MapReduceConfig{Enabled: true, ChunkTokens: 2*genai.EstimateTokens(large) + 10}
+	s.env.ExecuteWorkflow(NewTicketWorkflow(cfg), Ticket{})
+
+	s.True(s.env.IsWorkflowCompleted())
+}
+
+func (s *TicketWorkflowTestSuite) TestAttachmentSnippets() {
+	s.mockDefaults()
+
+	ticket := Ticket{ID: 12345}
+
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{Comments: []Comment{{
+			Body: "Logs attached",
+			Attachments: []Attachment{
+				{ID: 1, FileName: "server.log", ContentType: "text/plain"},
+				{ID: 2, FileName: "screenshot.png", ContentType: "image/png"},
+			},
+		}}}, nil).Once()
+
+	// Only text-like attachments are extracted
+	s.env.OnActivity((*Activity)(nil).ExtractAttachments, mock.Anything, mock.MatchedBy(func(input ExtractAttachmentsInput) bool {
+		return len(input.Attachments) == 1 && input.Attachments[0].ID == 1
+	})).Return(&ExtractAttachmentsOutput{Snippets: []AttachmentSnippet{
+		{AttachmentID: 1, FileName: "server.log", Text: "panic: nil pointer dereference"},
+	}}, nil).Once()
+
+	// The snippets are summarized alongside the comments
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.MatchedBy(func(input GenSummaryInput) bool {
+		return len(input.Ticket.AttachmentSnippets) == 1 &&
+			input.Ticket.AttachmentSnippets[0].Text == "panic: nil pointer dereference"
+	})).Return(&GenSummaryOutput{Summary: TicketSummary{Summary: "Server crashes"}}, nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345"})
+	}, time.Millisecond*100)
+
+	s.env.ExecuteWorkflow(TicketWorkflow, ticket)
+
+	s.True(s.env.IsWorkflowCompleted())
+}
+
+func (s *TicketWorkflowTestSuite) TestDebounceCoalescesSignals() {
+	s.mockDefaults()
+
+	ticket := Ticket{ID: 12345, OrganizationID: 0}
+
+	// A burst of signals results in a single fetch and summarize pass
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{Comments: []Comment{{Body: "comment"}}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: TicketSummary{Summary: "Coalesced summary"}}, nil).Once()
+
+	for _, delay := range []time.Duration{100 * time.Millisecond, 5 * time.Second, 12 * time.Second} {
+		s.env.RegisterDelayedCallback(func() {
+			s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345"})
+		}, delay)
+	}
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, time.Minute)
+
+	s.env.ExecuteWorkflow(TicketWorkflow, ticket)
+
+	s.True(s.env.IsWorkflowCompleted())
+	var canceledErr *temporal.CanceledError
+	s.ErrorAs(s.env.GetWorkflowError(), &canceledErr)
+}
+
+func (s *TicketWorkflowTestSuite) TestConfigRecorded() {
+	s.mockDefaults()
+
+	cfg := DefaultWorkflowConfig
+	cfg.QuietPeriod = 0
+	cfg.CustomFields = map[int64]string{360001234567: "product_area"}
+
+	// The config recorded as the run starts is the one the pipeline reads
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, FetchTicketInput{ID: "12345", CustomFields: cfg.CustomFields}).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: TicketSummary{Summary: "Summary"}}, nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345"})
+	}, time.Millisecond)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, time.Minute)
+
+	s.env.ExecuteWorkflow(NewTicketWorkflow(cfg), Ticket{ID: 12345})
+
+	s.True(s.env.IsWorkflowCompleted())
+	var canceledErr *temporal.CanceledError
+	s.ErrorAs(s.env.GetWorkflowError(), &canceledErr)
+}
+
+func (s *TicketWorkflowTestSuite) TestReconciledUpsertSkippedWhenUpToDate() {
+	s.mockDefaults()
+
+	updatedAt := time.Date(2025, 3, 1, 11, 30, 0, 0, time.UTC)
+	later := updatedAt.Add(time.Minute)
+	ticket := Ticket{ID: 12345, UpdatedAt: &updatedAt}
+
+	// Only the upsert for the later update runs a pass
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345, UpdatedAt: &later}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{Comments: []Comment{{Body: "comment"}}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: TicketSummary{Summary: "Summary"}}, nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345", UpdatedAt: &updatedAt})
+	}, time.Millisecond*100)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345", UpdatedAt: &later})
+	}, time.Minute)
+
+	// Caught up with the later update by now
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345", UpdatedAt: &later})
+	}, 2*time.Minute)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, 3*time.Minute)
+
+	s.env.ExecuteWorkflow(TicketWorkflow, ticket)
+
+	s.True(s.env.IsWorkflowCompleted())
+	var canceledErr *temporal.CanceledError
+	s.ErrorAs(s.env.GetWorkflowError(), &canceledErr)
+}
+
+func (s *TicketWorkflowTestSuite) TestOwnWritesSkipped() {
+	s.mockDefaults()
+
+	taxonomy := []config.TaxonomyField{{Name: "severity", Values: []string{"low", "high"}}}
+	updatedAt := time.Date(2025, 3, 1, 11, 30, 0, 0, time.UTC)
+	writtenAt := updatedAt.Add(time.Minute)
+	ticket := Ticket{ID: 12345}
+
+	// The write back bumps updated_at past the ticket fetched for the pass
+	var passes int
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345, UpdatedAt: &updatedAt}}, nil).
+		Run(func(args mock.Arguments) { passes++ })
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{Comments: []Comment{{Body: "comment"}}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: TicketSummary{Summary: "Summary"}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).ClassifyTicket, mock.Anything, mock.Anything).
+		Return(&ClassifyTicketOutput{Classification: map[string]string{"severity": "low"}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).UpdateTicketFields, mock.Anything, mock.Anything).
+		Return(&UpdateTicketFieldsOutput{UpdatedAt: &writtenAt}, nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345", UpdatedAt: &updatedAt})
+	}, time.Millisecond*100)
+
+	// The webhook echoing the write back doesn't run another pass
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345", UpdatedAt: &writtenAt})
+	}, time.Minute)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, 2*time.Minute)
+
+	cfg := DefaultWorkflowConfig
+	cfg.Classification = config.ClassificationConfig{Taxonomy: taxonomy}
+	s.env.ExecuteWorkflow(NewTicketWorkflow(cfg), ticket)
+
+	s.True(s.env.IsWorkflowCompleted())
+	var canceledErr *temporal.CanceledError
+	s.ErrorAs(s.env.GetWorkflowError(), &canceledErr)
+	s.Equal(1, passes)
+}
+
+func (s *TicketWorkflowTestSuite) TestRefreshDuringQuietPeriod() {
+	s.mockDefaults()
+
+	updatedAt := time.Date(2025, 3, 1, 11, 30, 0, 0, time.UTC)
+	ticket := Ticket{ID: 12345, UpdatedAt: &updatedAt}
+
+	// The upsert alone would be skipped, but the refresh waits for a pass
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345, UpdatedAt: &updatedAt}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{Comments: []Comment{{Body: "comment"}}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: TicketSummary{Summary: "Fresh summary"}}, nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345", UpdatedAt: &updatedAt})
+	}, time.Millisecond*100)
+
+	var refreshed *QueryTicketOutput
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(RefreshTicketUpdate, "refresh", &testsuite.TestUpdateCallback{
+			OnReject: func(err error) { s.Fail("update should have been accepted", err) },
+			OnAccept: func() {},
+			OnComplete: func(result interface{}, err error) {
+				s.NoError(err)
+				refreshed = result.(*QueryTicketOutput)
+			},
+		}, UpsertTicketInput{TicketID: "12345"})
+	}, time.Second)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, time.Minute)
+
+	s.env.ExecuteWorkflow(TicketWorkflow, ticket)
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.Require().NotNil(refreshed)
+	s.Equal("Fresh summary", refreshed.Summary.Summary)
+}
+
+func (s *TicketWorkflowTestSuite) TestDebounceMaxDelay() {
+	s.mockDefaults()
+
+	ticket := Ticket{ID: 12345}
+
+	var processedAt []time.Time
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(func(ctx context.Context, input FetchTicketInput) (*FetchTicketOutput, error) {
+			processedAt = append(processedAt, s.env.Now())
+			return &FetchTicketOutput{Ticket: Ticket{ID: 12345}}, nil
+		})
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{}, nil)
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: TicketSummary{Summary: "summary"}}, nil)
+
+	// A steady stream of signals never leaves a quiet period
+	for i := 1; i <= 30; i++ {
+		s.env.RegisterDelayedCallback(func() {
+			s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345"})
+		}, time.Duration(i)*5*time.Second)
+	}
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, 3*time.Minute)
+
+	start := s.env.Now()
+	s.env.ExecuteWorkflow(TicketWorkflow, ticket)
+
+	s.True(s.env.IsWorkflowCompleted())
+
+	// The max delay ceiling forces a pass while signals keep arriving
+	s.Require().NotEmpty(processedAt)
+	s.LessOrEqual(processedAt[0].Sub(start), 5*time.Second+DefaultWorkflowConfig.MaxDelay)
+	s.Greater(len(processedAt), 1)
+}
+
+func (s *TicketWorkflowTestSuite) TestClosedTicketCompletes() {
+	s.mockDefaults()
+
+	ticket := Ticket{ID: 12345, Status: "solved", OrganizationID: 101}
+
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345, Status: StatusClosed, OrganizationID: 101}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: TicketSummary{Summary: "Final summary"}}, nil).Once()
+
+	resolution := Resolution{RootCause: "Expired certificate", Fix: "Rotated the certificate", TimeToResolve: "48h0m0s"}
+	s.env.OnActivity((*Activity)(nil).GenResolutionSummary, mock.Anything, mock.Anything).
+		Return(&GenResolutionOutput{Resolution: resolution}, nil).Once()
+
+	// The org workflow receives the resolution rather than the running summary
+	s.env.OnActivity((*Activity)(nil).SignalOrganization, mock.Anything, mock.MatchedBy(func(input SignalOrganizationInput) bool {
+		return input.TicketSummary == resolution.String()
+	})).Return(nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345"})
+	}, time.Millisecond*100)
+
+	s.env.ExecuteWorkflow(TicketWorkflow, ticket)
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+
+	var output QueryTicketOutput
+	s.NoError(s.env.GetWorkflowResult(&output))
+	s.Equal("Final summary", output.Summary.Summary)
+	s.Equal(StatusClosed, output.Status)
+	s.Equal(&resolution, output.Resolution)
+	s.Require().Len(output.Events, 1)
+	s.Equal(EventStatusChanged, output.Events[0].Type)
+}
+
+func (s *TicketWorkflowTestSuite) TestEscalation() {
+	cfg := DefaultWorkflowConfig
+	cfg.RelatedTickets = true
+
+	ticket := Ticket{ID: 12345, OrganizationID: 101}
+
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345, Status: "open", OrganizationID: 101}}, nil).Times(3)
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{}, nil).Times(3)
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: TicketSummary{Summary: "Summary"}}, nil).Times(3)
+	s.env.OnActivity((*Activity)(nil).SignalOrganization, mock.Anything, mock.Anything).
+		Return(nil).Times(3)
+	s.env.OnActivity((*Activity)(nil).DetectLanguage, mock.Anything, mock.Anything).
+		Return(&DetectLanguageOutput{Language: "en"}, nil).Once()
+
+	// The summary doesn't change between passes so it's only indexed once
+	s.env.OnActivity((*Activity)(nil).IndexSummary, mock.Anything, mock.Anything).Return(nil).Once()
+
+	// Calm, then angry and urgent, then still angry
+	s.env.OnActivity((*Activity)(nil).ScoreTicket, mock.Anything, mock.Anything).
+		Return(&ScoreTicketOutput{Scores: Scores{Sentiment: 0.1, FrustrationTrend: TrendStable, Urgency: 0.2}}, nil).Once()
+	// Later passes don't send the state kept from earlier passes
+	s.env.OnActivity((*Activity)(nil).ScoreTicket, mock.Anything, mock.MatchedBy(func(input ScoreTicketInput) bool {
+		return input.Ticket.Scores == nil && input.Ticket.Summary == nil && input.Ticket.SummaryHistory == nil
+	})).Return(&ScoreTicketOutput{Scores: Scores{Sentiment: -0.8, FrustrationTrend: TrendWorsening, Urgency: 0.9}}, nil).Twice()
+
+	// Only the update that crosses the thresholds escalates
+	s.env.OnActivity((*Activity)(nil).EscalateOrganization, mock.Anything, mock.MatchedBy(func(input EscalateOrganizationInput) bool {
+		return input.OrganizationID == 101 &&
+			input.Escalation.TicketID == 12345 &&
+			input.Escalation.Sentiment == -0.8 &&
+			input.Escalation.Urgency == 0.9
+	})).Return(nil).Once()
+
+	for i := 1; i <= 3; i++ {
+		s.env.RegisterDelayedCallback(func() {
+			s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345"})
+		}, time.Duration(i)*time.Minute)
+	}
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, 4*time.Minute)
+
+	s.env.ExecuteWorkflow(NewTicketWorkflow(cfg), ticket)
+
+	s.True(s.env.IsWorkflowCompleted())
+
+	var output QueryTicketOutput
+	future, err := s.env.QueryWorkflow(QueryTicketSummary)
+	s.NoError(err)
+	s.NoError(future.Get(&output))
+	s.Equal(&Scores{Sentiment: -0.8, FrustrationTrend: TrendWorsening, Urgency: 0.9}, output.Scores)
+
+	var escalations []TicketEvent
+	for _, event := range output.Events {
+		if event.Type == EventEscalated {
+			escalations = append(escalations, event)
+		}
+	}
+	s.Require().Len(escalations, 1)
+	s.Contains(escalations[0].To, "sentiment")
+	s.Contains(escalations[0].To, "urgency")
+}
+
+func (s *TicketWorkflowTestSuite) TestClassification() {
+	s.mockDefaults()
+
+	taxonomy := []config.TaxonomyField{
+		{Name: "severity", Values: []string{"low", "high"}},
+		{Name: "product_area", Values: []string{"Billing", "API"}},
+	}
+	ticket := Ticket{ID: 12345}
+
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345, Status: "open"}}, nil).Times(3)
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{}, nil).Times(3)
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: TicketSummary{Summary: "Summary"}}, nil).Times(3)
+
+	// Each field is classified and written back once, so our own writes
+	// echoing back through the webhook don't rewrite the ticket
+	s.env.OnActivity((*Activity)(nil).ClassifyTicket, mock.Anything, mock.MatchedBy(func(input ClassifyTicketInput) bool {
+		return len(input.Taxonomy) == 2
+	})).Return(&ClassifyTicketOutput{Classification: map[string]string{"severity": "low"}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).UpdateTicketFields, mock.Anything, UpdateTicketFieldsInput{
+		TicketID:       12345,
+		Taxonomy:       taxonomy,
+		Classification: map[string]string{"severity": "low"},
+	}).Return(&UpdateTicketFieldsOutput{}, nil).Once()
+
+	// The field left out is classified on the next update
+	s.env.OnActivity((*Activity)(nil).ClassifyTicket, mock.Anything, mock.MatchedBy(func(input ClassifyTicketInput) bool {
+		return assert.ObjectsAreEqual(taxonomy[1:], input.Taxonomy)
+	})).Return(&ClassifyTicketOutput{Classification: map[string]string{"product_area": "API"}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).UpdateTicketFields, mock.Anything, UpdateTicketFieldsInput{
+		TicketID:       12345,
+		Taxonomy:       taxonomy[1:],
+		Classification: map[string]string{"product_area": "API"},
+	}).Return(&UpdateTicketFieldsOutput{}, nil).Once()
+
+	for i := 1; i <= 3; i++ {
+		s.env.RegisterDelayedCallback(func() {
+			s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345"})
+		}, time.Duration(i)*time.Minute)
+	}
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, 4*time.Minute)
+
+	cfg := DefaultWorkflowConfig
+	cfg.Classification = config.ClassificationConfig{Taxonomy: taxonomy}
+	s.env.ExecuteWorkflow(NewTicketWorkflow(cfg), ticket)
+
+	s.True(s.env.IsWorkflowCompleted())
+
+	var output QueryTicketOutput
+	future, err := s.env.QueryWorkflow(QueryTicketSummary)
+	s.NoError(err)
+	s.NoError(future.Get(&output))
+	s.Equal(map[string]string{"severity": "low", "product_area": "API"}, output.Classification)
+}
+
+func (s *TicketWorkflowTestSuite) TestClassificationIsBestEffort() {
+	s.mockDefaults()
+
+	taxonomy := []config.TaxonomyField{{Name: "severity", Values: []string{"low", "high"}}}
+	ticket := Ticket{ID: 12345}
+
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345, Status: "open"}}, nil).Times(2)
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{}, nil).Times(2)
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: TicketSummary{Summary: "Summary"}}, nil).Times(2)
+	s.env.OnActivity((*Activity)(nil).ClassifyTicket, mock.Anything, mock.Anything).
+		Return(&ClassifyTicketOutput{Classification: map[string]string{"severity": "low"}}, nil).Times(2)
+
+	// A failed write back leaves the ticket workflow running and the field
+	// unclassified, so the next update writes it again
+	s.env.OnActivity((*Activity)(nil).UpdateTicketFields, mock.Anything, mock.Anything).
+		Return(nil, temporal.NewNonRetryableApplicationError("field is read-only", "Forbidden", nil)).Once()
+	s.env.OnActivity((*Activity)(nil).UpdateTicketFields, mock.Anything, mock.Anything).
+		Return(&UpdateTicketFieldsOutput{}, nil).Once()
+
+	for i := 1; i <= 2; i++ {
+		s.env.RegisterDelayedCallback(func() {
+			s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345"})
+		}, time.Duration(i)*time.Minute)
+	}
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, 3*time.Minute)
+
+	cfg := DefaultWorkflowConfig
+	cfg.Classification = config.ClassificationConfig{Taxonomy: taxonomy}
+	s.env.ExecuteWorkflow(NewTicketWorkflow(cfg), ticket)
+
+	s.True(s.env.IsWorkflowCompleted())
+	var canceledErr *temporal.CanceledError
+	s.ErrorAs(s.env.GetWorkflowError(), &canceledErr)
+
+	var output QueryTicketOutput
+	future, err := s.env.QueryWorkflow(QueryTicketSummary)
+	s.NoError(err)
+	s.NoError(future.Get(&output))
+	s.Equal(map[string]string{"severity": "low"}, output.Classification)
+}
+
+func (s *TicketWorkflowTestSuite) TestClassificationDryRun() {
+	s.mockDefaults()
+
+	ticket := Ticket{ID: 12345}
+
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345, Status: "open"}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: TicketSummary{Summary: "Summary"}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).ClassifyTicket, mock.Anything, mock.Anything).
+		Return(&ClassifyTicketOutput{Classification: map[string]string{"severity": "high"}}, nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345"})
+	}, time.Millisecond*100)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, time.Minute)
+
+	cfg := DefaultWorkflowConfig
+	cfg.Classification = config.ClassificationConfig{
+		Taxonomy: []config.TaxonomyField{{Name: "severity", Values: []string{"low", "high"}}},
+		DryRun:   true,
+	}
+	s.env.ExecuteWorkflow(NewTicketWorkflow(cfg), ticket)
+
+	s.True(s.env.IsWorkflowCompleted())
+
+	// Suggestions are recorded without calling UpdateTicketFields
+	var output QueryTicketOutput
+	future, err := s.env.QueryWorkflow(QueryTicketSummary)
+	s.NoError(err)
+	s.NoError(future.Get(&output))
+	s.Equal(map[string]string{"severity": "high"}, output.Classification)
+}
+
+func (s *TicketWorkflowTestSuite) TestSummaryNote() {
+	s.mockDefaults()
+
+	ticket := Ticket{ID: 12345}
+	publicComment := Comment{Body: "Still broken", Public: true}
+
+	// Created, two public comments, then reassigned
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345, Status: "open", AssigneeID: 1, Assignee: "Agent A"}}, nil).Times(3)
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345, Status: "open", AssigneeID: 2, Assignee: "Agent B"}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{Comments: []Comment{publicComment, {Body: "Internal", Public: false}}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{Comments: []Comment{publicComment}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: TicketSummary{Summary: "Summary"}}, nil).Times(4)
+
+	// Posted once the second public comment arrives and again on reassignment
+	s.env.OnActivity((*Activity)(nil).PostSummaryNote, mock.Anything, PostSummaryNoteInput{
+		TicketID: 12345,
+		Summary:  TicketSummary{Summary: "Summary"},
+	}).Return(&PostSummaryNoteOutput{}, nil).Twice()
+
+	for i := 1; i <= 4; i++ {
+		s.env.RegisterDelayedCallback(func() {
+			s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345"})
+		}, time.Duration(i)*time.Minute)
+	}
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, 5*time.Minute)
+
+	cfg := DefaultWorkflowConfig
+	cfg.SummaryNote = config.SummaryNoteConfig{Enabled: true, OnReassignment: true, EveryPublicComments: 2}
+	s.env.ExecuteWorkflow(NewTicketWorkflow(cfg), ticket)
+
+	s.True(s.env.IsWorkflowCompleted())
+}
+
+func (s *TicketWorkflowTestSuite) TestSummaryNoteIsBestEffort() {
+	s.mockDefaults()
+
+	ticket := Ticket{ID: 12345}
+
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345, Status: "open"}}, nil).Times(2)
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{Comments: []Comment{{Body: "Still broken", Public: true}}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: TicketSummary{Summary: "Summary"}}, nil).Times(2)
+
+	// A note that can't be posted leaves the ticket workflow running and is
+	// posted on the next update instead
+	s.env.OnActivity((*Activity)(nil).PostSummaryNote, mock.Anything, mock.Anything).
+		Return(nil, temporal.NewNonRetryableApplicationError("ticket is locked", "Forbidden", nil)).Once()
+	s.env.OnActivity((*Activity)(nil).PostSummaryNote, mock.Anything, mock.Anything).
+		Return(&PostSummaryNoteOutput{}, nil).Once()
+
+	for i := 1; i <= 2; i++ {
+		s.env.RegisterDelayedCallback(func() {
+			s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345"})
+		}, time.Duration(i)*time.Minute)
+	}
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, 3*time.Minute)
+
+	cfg := DefaultWorkflowConfig
+	cfg.SummaryNote = config.SummaryNoteConfig{Enabled: true, EveryPublicComments: 1}
+	s.env.ExecuteWorkflow(NewTicketWorkflow(cfg), ticket)
+
+	s.True(s.env.IsWorkflowCompleted())
+	var canceledErr *temporal.CanceledError
+	s.ErrorAs(s.env.GetWorkflowError(), &canceledErr)
+}
+
+func (s *TicketWorkflowTestSuite) TestDraftReply() {
+	s.mockDefaults()
+
+	ticket := Ticket{ID: 0}
+
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345, Status: "open"}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{Comments: []Comment{{Body: "Login keeps failing"}}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: TicketSummary{Summary: "Summary"}}, nil).Once()
+
+	// The draft is generated from the full thread and the agent's instruction
+	s.env.OnActivity((*Activity)(nil).GenDraftReply, mock.Anything, mock.MatchedBy(func(input GenDraftReplyInput) bool {
+		return input.Instruction == "be brief" && input.Ticket.ID == 12345 && len(input.Ticket.Comments) == 1
+	})).Return(&GenDraftReplyOutput{Draft: "Please try again now."}, nil).Once()
+
+	// Rejected before the ticket has been fetched
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(DraftReplyUpdate, "early", &testsuite.TestUpdateCallback{
+			OnReject: func(err error) {
+				s.ErrorContains(err, "ticket has not been fetched yet")
+			},
+			OnAccept:   func() { s.Fail("update should have been rejected") },
+			OnComplete: func(interface{}, error) {},
+		}, DraftReplyInput{})
+	}, time.Millisecond*50)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345"})
+	}, time.Millisecond*100)
+
+	var draft DraftReplyOutput
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(DraftReplyUpdate, "draft", &testsuite.TestUpdateCallback{
+			OnReject: func(err error) { s.Fail("update should have been accepted", err) },
+			OnAccept: func() {},
+			OnComplete: func(result interface{}, err error) {
+				s.NoError(err)
+				draft = result.(DraftReplyOutput)
+			},
+		}, DraftReplyInput{Instruction: "be brief"})
+	}, time.Minute)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, 2*time.Minute)
+
+	s.env.ExecuteWorkflow(TicketWorkflow, ticket)
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.Equal("Please try again now.", draft.Draft)
+}
+
+func (s *TicketWorkflowTestSuite) TestRelatedTicketsLookup() {
+	s.mockDefaults()
+
+	ticket := Ticket{ID: 0}
+	summary := TicketSummary{Intent: "Log in", Summary: "Login keeps failing"}
+
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345, Status: "open", OrganizationID: 101}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{Comments: []Comment{{Body: "Login keeps failing"}}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: summary}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).SignalOrganization, mock.Anything, mock.Anything).Return(nil).Once()
+
+	lookup := func(input RelatedTicketsInput) (FindRelatedTicketsInput, error) {
+		var output FindRelatedTicketsInput
+		future, err := s.env.QueryWorkflow(QueryRelatedTicketsLookup, input)
+		if err != nil {
+			return output, err
+		}
+		return output, future.Get(&output)
+	}
+
+	// Failed before the ticket has been summarized
+	s.env.RegisterDelayedCallback(func() {
+		_, err := lookup(RelatedTicketsInput{})
+		s.ErrorContains(err, "ticket has not been summarized yet")
+	}, time.Millisecond*50)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345"})
+	}, time.Millisecond*100)
+
+	s.env.RegisterDelayedCallback(func() {
+		_, err := lookup(RelatedTicketsInput{Limit: MaxRelatedTickets + 1})
+		s.ErrorContains(err, "limit must be between")
+
+		output, err := lookup(RelatedTicketsInput{})
+		s.NoError(err)
+		s.Equal(FindRelatedTicketsInput{TicketID: 12345, OrganizationID: 101, Summary: summary, Limit: DefaultRelatedTickets}, output)
+	}, time.Minute)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, 2*time.Minute)
+
+	s.env.ExecuteWorkflow(TicketWorkflow, ticket)
+
+	s.True(s.env.IsWorkflowCompleted())
+}
+
+func (s *TicketWorkflowTestSuite) TestRelatedTicketsWorkflow() {
+	input := FindRelatedTicketsInput{TicketID: 12345, Summary: TicketSummary{Intent: "Log in"}, Limit: DefaultRelatedTickets}
+	matches := []related.Match{{TicketID: 678, OrganizationID: 202, Score: 0.93, Summary: `{"intent":"Log in"}`}}
+
+	// Looked up on the worker serving the index
+	s.env.OnActivity((*Activity)(nil).FindRelatedTickets, mock.Anything, input).
+		Return(func(ctx context.Context, _ FindRelatedTicketsInput) (*FindRelatedTicketsOutput, error) {
+			s.Equal(related.TaskQueue, activity.GetInfo(ctx).TaskQueue)
+			return &FindRelatedTicketsOutput{Tickets: matches}, nil
+		}).Once()
+
+	s.env.ExecuteWorkflow(RelatedTicketsWorkflow, input)
+
+	s.True(s.env.IsWorkflowCompleted())
+	var output RelatedTicketsOutput
+	s.NoError(s.env.GetWorkflowResult(&output))
+	s.Equal(matches, output.Tickets)
+}
+
+func (s *TicketWorkflowTestSuite) TestRefresh() {
+	s.mockDefaults()
+
+	ticket := Ticket{ID: 12345, Status: "solved", OrganizationID: 101}
+
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345, Status: StatusClosed, OrganizationID: 101}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: TicketSummary{Summary: "Fresh summary"}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).GenResolutionSummary, mock.Anything, mock.Anything).
+		Return(&GenResolutionOutput{Resolution: Resolution{RootCause: "Expired certificate"}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).SignalOrganization, mock.Anything, mock.Anything).Return(nil).Once()
+
+	// The refresh runs without waiting for the quiet period and returns the summary
+	var refreshed *QueryTicketOutput
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(RefreshTicketUpdate, "refresh", &testsuite.TestUpdateCallback{
+			OnReject: func(err error) { s.Fail("update should have been accepted", err) },
+			OnAccept: func() {},
+			OnComplete: func(result interface{}, err error) {
+				s.NoError(err)
+				refreshed = result.(*QueryTicketOutput)
+			},
+		}, UpsertTicketInput{TicketID: "12345"})
+	}, time.Millisecond*100)
+
+	s.env.ExecuteWorkflow(TicketWorkflow, ticket)
+
+	// The ticket was closed by the refresh so the workflow completes without a signal
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	s.Require().NotNil(refreshed)
+	s.Equal("Fresh summary", refreshed.Summary.Summary)
+	s.Equal(StatusClosed, refreshed.Status)
+}
+
+func (s *TicketWorkflowTestSuite) TestRefreshWhileContinuingAsNew() {
+	defer func(n int) { updatesBeforeContinueAsNew = n }(updatesBeforeContinueAsNew)
+	updatesBeforeContinueAsNew = 1
+
+	s.mockDefaults()
+
+	ticket := Ticket{ID: 12345, Status: "open"}
+
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345, Status: "open"}}, nil).Twice()
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{}, nil).Twice()
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: TicketSummary{Summary: "Summary"}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: TicketSummary{Summary: "Fresh summary"}}, nil).Once()
+
+	// Keeps the run draining after the first pass
+	s.env.OnActivity((*Activity)(nil).GenDraftReply, mock.Anything, mock.Anything).
+		After(time.Minute).Return(&GenDraftReplyOutput{Draft: "Draft"}, nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(DraftReplyUpdate, "draft", &testsuite.TestUpdateCallback{
+			OnReject:   func(err error) { s.Fail("update should have been accepted", err) },
+			OnAccept:   func() {},
+			OnComplete: func(interface{}, error) {},
+		}, DraftReplyInput{})
+	}, time.Millisecond*100)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345"})
+	}, time.Millisecond*200)
+
+	// The refresh accepted while draining is still served
+	var refreshed *QueryTicketOutput
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(RefreshTicketUpdate, "refresh", &testsuite.TestUpdateCallback{
+			OnReject: func(err error) { s.Fail("update should have been accepted", err) },
+			OnAccept: func() {},
+			OnComplete: func(result interface{}, err error) {
+				s.NoError(err)
+				refreshed = result.(*QueryTicketOutput)
+			},
+		}, UpsertTicketInput{TicketID: "12345"})
+	}, 30*time.Second)
+
+	s.env.ExecuteWorkflow(TicketWorkflow, ticket)
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.True(workflow.IsContinueAsNewError(s.env.GetWorkflowError()))
+	s.Require().NotNil(refreshed)
+	s.Equal("Fresh summary", refreshed.Summary.Summary)
+}
+
+func (s *TicketWorkflowTestSuite) TestOrganizationMove() {
+	s.mockDefaults()
+
+	ticket := Ticket{ID: 12345, Status: "open", OrganizationID: 101}
+
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345, Status: "open", OrganizationID: 202}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: TicketSummary{Summary: "Summary"}}, nil).Once()
+
+	// The previous org drops the ticket and the new org picks it up
+	s.env.OnActivity((*Activity)(nil).RemoveFromOrganization, mock.Anything, RemoveFromOrganizationInput{
+		OrganizationID: 101,
+		TicketID:       12345,
+	}).Return(nil).Once()
+	s.env.OnActivity((*Activity)(nil).SignalOrganization, mock.Anything, mock.MatchedBy(func(input SignalOrganizationInput) bool {
+		return input.OrganizationID == 202 && input.TicketID == 12345
+	})).Return(nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345"})
+	}, time.Millisecond*100)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, time.Minute)
+
+	s.env.ExecuteWorkflow(TicketWorkflow, ticket)
+
+	s.True(s.env.IsWorkflowCompleted())
+}
+
+func (s *TicketWorkflowTestSuite) TestTranslate() {
+	ticket := Ticket{ID: 12345, Status: "open", Summary: &TicketSummary{Intent: "Login", Summary: "Login fails", NextStep: "Reset password"}}
+	translated := TicketSummary{Intent: "Connexion", Summary: "La connexion échoue", NextStep: "Réinitialiser le mot de passe"}
+
+	// Translated once, then served from the cache
+	s.env.OnActivity((*Activity)(nil).TranslateSummary, mock.Anything, TranslateSummaryInput{Summary: *ticket.Summary, Locale: "fr-CA"}).
+		Return(&TranslateSummaryOutput{Summary: translated}, nil).Once()
+
+	var outputs []*QueryTicketOutput
+	for i, locale := range []string{"fr-ca", "fr_CA"} {
+		s.env.RegisterDelayedCallback(func() {
+			s.env.UpdateWorkflow(TranslateTicketUpdate, locale, &testsuite.TestUpdateCallback{
+				OnReject: func(err error) { s.Fail("update should have been accepted", err) },
+				OnAccept: func() {},
+				OnComplete: func(result interface{}, err error) {
+					s.NoError(err)
+					outputs = append(outputs, result.(*QueryTicketOutput))
+				},
+			}, TranslateInput{Locale: locale})
+		}, time.Duration(i+1)*time.Second)
+	}
+
+	var rejected error
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(TranslateTicketUpdate, "invalid", &testsuite.TestUpdateCallback{
+			OnReject:   func(err error) { rejected = err },
+			OnAccept:   func() { s.Fail("update should have been rejected") },
+			OnComplete: func(interface{}, error) {},
+		}, TranslateInput{Locale: "not a locale"})
+	}, 3*time.Second)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, time.Minute)
+
+	s.env.ExecuteWorkflow(TicketWorkflow, ticket)
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.Require().Len(outputs, 2)
+	for _, output := range outputs {
+		s.Equal("fr-CA", output.Locale)
+		s.Equal(&translated, output.Summary)
+	}
+	s.Error(rejected)
+
+	// The canonical summary is untouched
+	var output QueryTicketOutput
+	future, err := s.env.QueryWorkflow(QueryTicketSummary)
+	s.NoError(err)
+	s.NoError(future.Get(&output))
+	s.Equal("Login fails", output.Summary.Summary)
+	s.Empty(output.Locale)
+
+	// The cached translation is served by a query, other locales aren't
+	future, err = s.env.QueryWorkflow(QueryTicketTranslation, TranslateInput{Locale: "fr_ca"})
+	s.NoError(err)
+	s.NoError(future.Get(&output))
+	s.Equal("fr-CA", output.Locale)
+	s.Equal(&translated, output.Summary)
+
+	_, err = s.env.QueryWorkflow(QueryTicketTranslation, TranslateInput{Locale: "de"})
+	s.ErrorContains(err, "summary has not been translated to the locale yet")
+}
+
+func (s *TicketWorkflowTestSuite) TestTranslationsContinueAsNew() {
+	defer func(n int) { updatesBeforeContinueAsNew = n }(updatesBeforeContinueAsNew)
+	updatesBeforeContinueAsNew = 1
+
+	ticket := Ticket{ID: 12345, Status: "open", Summary: &TicketSummary{Summary: "Login fails"}}
+
+	s.env.OnActivity((*Activity)(nil).TranslateSummary, mock.Anything, mock.Anything).
+		Return(&TranslateSummaryOutput{Summary: TicketSummary{Summary: "La connexion échoue"}}, nil).Once()
+
+	// A translation counts toward continuing as new like an upsert
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(TranslateTicketUpdate, "translate", &testsuite.TestUpdateCallback{
+			OnReject:   func(err error) { s.Fail("update should have been accepted", err) },
+			OnAccept:   func() {},
+			OnComplete: func(_ interface{}, err error) { s.NoError(err) },
+		}, TranslateInput{Locale: "fr"})
+	}, time.Second)
+
+	s.env.ExecuteWorkflow(TicketWorkflow, ticket)
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.True(workflow.IsContinueAsNewError(s.env.GetWorkflowError()))
+}
+
+func (s *TicketWorkflowTestSuite) TestTranslationsDroppedOnNewSummary() {
+	s.mockDefaults()
+
+	ticket := Ticket{
+		ID:           12345,
+		Status:       "open",
+		Summary:      &TicketSummary{Summary: "Old summary"},
+		Translations: map[string]TicketSummary{"fr": {Summary: "Ancien résumé"}},
+	}
+
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345, Status: "open"}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: TicketSummary{Summary: "New summary"}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).TranslateSummary, mock.Anything, TranslateSummaryInput{Summary: TicketSummary{Summary: "New summary"}, Locale: "fr"}).
+		Return(&TranslateSummaryOutput{Summary: TicketSummary{Summary: "Nouveau résumé"}}, nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345"})
+	}, time.Millisecond*100)
+
+	var translated *QueryTicketOutput
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(TranslateTicketUpdate, "translate", &testsuite.TestUpdateCallback{
+			OnReject: func(err error) { s.Fail("update should have been accepted", err) },
+			OnAccept: func() {},
+			OnComplete: func(result interface{}, err error) {
+				s.NoError(err)
+				translated = result.(*QueryTicketOutput)
+			},
+		}, TranslateInput{Locale: "fr"})
+	}, time.Minute)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, 2*time.Minute)
+
+	s.env.ExecuteWorkflow(TicketWorkflow, ticket)
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.Require().NotNil(translated)
+	s.Equal("Nouveau résumé", translated.Summary.Summary)
+	s.Equal("en", translated.Language)
+}
+
+func (s *TicketWorkflowTestSuite) TestMetrics() {
+	s.mockDefaults()
+
+	created := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	customer := Author{ID: 1, Name: "Customer", Role: RoleEndUser}
+	agent := Author{ID: 2, Name: "Agent", Role: "agent"}
+
+	ticket := Ticket{ID: 0}
+
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345, Status: "open", OrganizationID: 101, CreatedAt: &created}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{Comments: []Comment{
+			{Author: customer, Body: "Login fails", Public: true, CreatedAt: created},
+			{Author: agent, Body: "Looking into it", Public: false, CreatedAt: created.Add(10 * time.Minute)},
+			{Author: agent, Body: "Please try again", Public: true, CreatedAt: created.Add(45 * time.Minute)},
+		}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: TicketSummary{Summary: "Summary"}}, nil).Once()
+
+	// The org receives the metrics along with the summary
+	s.env.OnActivity((*Activity)(nil).SignalOrganization, mock.Anything, mock.MatchedBy(func(input SignalOrganizationInput) bool {
+		return input.Metrics != nil && input.Metrics.AgentTouches == 1
+	})).Return(nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345"})
+	}, time.Millisecond*100)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, time.Minute)
+
+	s.env.ExecuteWorkflow(TicketWorkflow, ticket)
+
+	s.True(s.env.IsWorkflowCompleted())
+
+	var output QueryTicketOutput
+	future, err := s.env.QueryWorkflow(QueryTicketSummary)
+	s.NoError(err)
+	s.NoError(future.Get(&output))
+	s.Require().NotNil(output.Metrics.FirstResponseSeconds)
+	s.Equal(int64(45*60), *output.Metrics.FirstResponseSeconds)
+	s.Equal(int64(45*60), output.Metrics.CustomerWaitSeconds)
+	s.Equal(1, output.Metrics.AgentTouches)
+	// Time in status starts when the ticket was first seen, not created
+	s.Contains(output.Metrics.TimeInStatusSeconds, "open")
+	s.LessOrEqual(output.Metrics.TimeInStatusSeconds["open"], int64(time.Minute/time.Second))
+}
+
+func (s *TicketWorkflowTestSuite) TestMetricsAgentOpenedTicket() {
+	s.mockDefaults()
+
+	created := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	customer := Author{ID: 1, Name: "Customer", Role: RoleEndUser}
+	agent := Author{ID: 2, Name: "Agent", Role: "agent"}
+
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345, Status: "open", CreatedAt: &created}}, nil).Once()
+
+	// The agent's opening comment is the description, not a reply
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, FetchCommentsInput{ID: "12345"}).
+		Return(&FetchCommentsOutput{Comments: []Comment{
+			{ID: 1, Author: agent, Body: "Your renewal is due", Public: true, CreatedAt: created},
+			{ID: 2, Author: customer, Body: "Which plan?", Public: true, CreatedAt: created.Add(time.Hour)},
+		}, NextCursor: "cursor-2"}, nil).Once()
+
+	// Later batches don't open the thread, so the agent's comment is a reply
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, FetchCommentsInput{ID: "12345", Cursor: "cursor-2"}).
+		Return(&FetchCommentsOutput{Comments: []Comment{
+			{ID: 3, Author: agent, Body: "The annual plan", Public: true, CreatedAt: created.Add(90 * time.Minute)},
+		}, NextCursor: "cursor-3"}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345, Status: "open", CreatedAt: &created}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: TicketSummary{Summary: "Summary"}}, nil).Twice()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345"})
+	}, time.Millisecond*100)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345"})
+	}, time.Minute)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, 2*time.Minute)
+
+	s.env.ExecuteWorkflow(TicketWorkflow, Ticket{})
+
+	s.True(s.env.IsWorkflowCompleted())
+
+	var output QueryTicketOutput
+	future, err := s.env.QueryWorkflow(QueryTicketSummary)
+	s.NoError(err)
+	s.NoError(future.Get(&output))
+	s.Require().NotNil(output.Metrics.FirstResponseSeconds)
+	s.Equal(int64(90*60), *output.Metrics.FirstResponseSeconds)
+	s.Equal(int64(30*60), output.Metrics.CustomerWaitSeconds)
+	s.Equal(1, output.Metrics.AgentTouches)
+}
+
+func (s *TicketWorkflowTestSuite) TestDeletedTicket() {
+	cfg := DefaultWorkflowConfig
+	cfg.RelatedTickets = true
+
+	ticket := Ticket{ID: 12345, Status: "open", OrganizationID: 101}
+
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(nil, temporal.NewNonRetryableApplicationError("failed to find the ticket", ErrTypeNotFound, nil)).Once()
+
+	// The deleted ticket is dropped from the index and the org
+	s.env.OnActivity((*Activity)(nil).UnindexTicket, mock.Anything, UnindexTicketInput{TicketID: 12345}).
+		Return(nil).Once()
+	s.env.OnActivity((*Activity)(nil).RemoveFromOrganization, mock.Anything, RemoveFromOrganizationInput{
+		OrganizationID: 101,
+		TicketID:       12345,
+	}).Return(nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345"})
+	}, time.Millisecond*100)
+
+	s.env.ExecuteWorkflow(NewTicketWorkflow(cfg), ticket)
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+
+	var output QueryTicketOutput
+	s.NoError(s.env.GetWorkflowResult(&output))
+	s.Equal("deleted", output.Status)
+	s.Require().NotNil(output.Tombstone)
+	s.Equal(TombstoneDeleted, output.Tombstone.Reason)
+}
+
+func (s *TicketWorkflowTestSuite) TestMergedTicket() {
+	cfg := DefaultWorkflowConfig
+	cfg.RelatedTickets = true
+
+	ticket := Ticket{ID: 12345, Status: "open", OrganizationID: 101}
+
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345, Status: StatusClosed, OrganizationID: 101, Tags: []string{MergedTag}}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{Comments: []Comment{
+			{Body: "This request was closed and merged into request #4567 \"Login issues\"."},
+		}}, nil).Once()
+
+	// The org redirects the ticket to the one it was merged into instead of
+	// receiving a resolution
+	s.env.OnActivity((*Activity)(nil).UnindexTicket, mock.Anything, UnindexTicketInput{TicketID: 12345}).
+		Return(nil).Once()
+	s.env.OnActivity((*Activity)(nil).RemoveFromOrganization, mock.Anything, RemoveFromOrganizationInput{
+		OrganizationID: 101,
+		TicketID:       12345,
+		MergedInto:     4567,
+	}).Return(nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345"})
+	}, time.Millisecond*100)
+
+	s.env.ExecuteWorkflow(NewTicketWorkflow(cfg), ticket)
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+
+	var output QueryTicketOutput
+	s.NoError(s.env.GetWorkflowResult(&output))
+	s.Equal("merged into #4567", output.Status)
+	s.Require().NotNil(output.Tombstone)
+	s.Equal(int64(4567), output.Tombstone.MergedInto)
+}
+
+func TestTicketWorkflowSuite(t *testing.T) {
+	suite.Run(t, new(TicketWorkflowTestSuite))
+}
+
+func (s *TicketWorkflowTestSuite) TestContinueAsNewSuggested() {
+	s.mockDefaults()
+
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345, Status: "open"}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{}, nil).Once()
+	// The history grows large well before updatesBeforeContinueAsNew
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.Anything).
+		Return(func(context.Context, GenSummaryInput) (*GenSummaryOutput, error) {
+			s.env.SetContinueAsNewSuggested(true)
+			return &GenSummaryOutput{Summary: TicketSummary{Summary: "Summary"}}, nil
+		}).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345"})
+	}, time.Millisecond)
+
+	s.env.ExecuteWorkflow(TicketWorkflow, Ticket{ID: 12345, Status: "open"})
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.True(workflow.IsContinueAsNewError(s.env.GetWorkflowError()))
+}
+
+func (s *TicketWorkflowTestSuite) TestIndexingIsBestEffort() {
+	cfg := DefaultWorkflowConfig
+	cfg.RelatedTickets = true
+
+	s.env.OnActivity((*Activity)(nil).ScoreTicket, mock.Anything, mock.Anything).
+		Return(&ScoreTicketOutput{Scores: Scores{FrustrationTrend: TrendStable, Urgency: 0.2}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).DetectLanguage, mock.Anything, mock.Anything).
+		Return(&DetectLanguageOutput{Language: "en"}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345, Status: "open", OrganizationID: 101}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: TicketSummary{Summary: "Summary"}}, nil).Once()
+
+	// The index worker doesn't pick the task up, yet the rest of the pipeline runs
+	s.env.OnActivity((*Activity)(nil).IndexSummary, mock.Anything, mock.Anything).
+		After(indexScheduleToStartTimeout).
+		Return(temporal.NewNonRetryableApplicationError("activity ScheduleToStart timeout", "Timeout", nil)).Once()
+	s.env.OnActivity((*Activity)(nil).SignalOrganization, mock.Anything, mock.Anything).Return(nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345"})
+	}, time.Millisecond)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, 2*time.Minute)
+
+	s.env.ExecuteWorkflow(NewTicketWorkflow(cfg), Ticket{ID: 12345})
+
+	s.True(s.env.IsWorkflowCompleted())
+	var canceledErr *temporal.CanceledError
+	s.ErrorAs(s.env.GetWorkflowError(), &canceledErr)
+}
+
+func (s *TicketWorkflowTestSuite) TestIndexingSkippedWithoutIndexWorker() {
+	s.mockDefaults()
+
+	ticket := Ticket{ID: 12345, Status: "open", OrganizationID: 101}
+
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: ticket}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: TicketSummary{Summary: "Summary"}}, nil).Once()
+
+	// Without an index worker nothing picks index tasks up, so they aren't
+	// scheduled and the pass doesn't wait for them to time out
+	s.env.OnActivity((*Activity)(nil).IndexSummary, mock.Anything, mock.Anything).
+		After(indexScheduleToStartTimeout).
+		Return(temporal.NewNonRetryableApplicationError("activity ScheduleToStart timeout", "Timeout", nil)).Never()
+
+	var signaledAt time.Time
+	s.env.OnActivity((*Activity)(nil).SignalOrganization, mock.Anything, mock.Anything).
+		Run(func(mock.Arguments) { signaledAt = s.env.Now() }).
+		Return(nil).Once()
+
+	var upsertedAt time.Time
+	s.env.RegisterDelayedCallback(func() {
+		upsertedAt = s.env.Now()
+		s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345"})
+	}, time.Millisecond)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, 2*time.Minute)
+
+	s.env.ExecuteWorkflow(TicketWorkflow, Ticket{ID: 12345})
+
+	s.True(s.env.IsWorkflowCompleted())
+	var canceledErr *temporal.CanceledError
+	s.ErrorAs(s.env.GetWorkflowError(), &canceledErr)
+
+	// Only the quiet period passed before the summary reached the org
+	s.Less(signaledAt.Sub(upsertedAt), indexScheduleToStartTimeout)
+}
+
+func (s *TicketWorkflowTestSuite) TestScoringIsBestEffort() {
+	s.env.OnActivity((*Activity)(nil).DetectLanguage, mock.Anything, mock.Anything).
+		Return(&DetectLanguageOutput{Language: "en"}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchTicket, mock.Anything, mock.Anything).
+		Return(&FetchTicketOutput{Ticket: Ticket{ID: 12345, Status: "open", OrganizationID: 101}}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).FetchComments, mock.Anything, mock.Anything).
+		Return(&FetchCommentsOutput{}, nil).Once()
+	s.env.OnActivity((*Activity)(nil).GenTicketSummary, mock.Anything, mock.Anything).
+		Return(&GenSummaryOutput{Summary: TicketSummary{Summary: "Summary"}}, nil).Once()
+
+	// Scoring keeps failing, yet the summary still reaches the organization
+	s.env.OnActivity((*Activity)(nil).ScoreTicket, mock.Anything, mock.Anything).
+		Return(nil, temporal.NewNonRetryableApplicationError("model unavailable", "Unavailable", nil)).Once()
+	s.env.OnActivity((*Activity)(nil).SignalOrganization, mock.Anything, mock.Anything).Return(nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(UpsertTicketSignal, UpsertTicketInput{TicketID: "12345"})
+	}, time.Millisecond)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, time.Minute)
+
+	s.env.ExecuteWorkflow(TicketWorkflow, Ticket{ID: 12345})
+
+	s.True(s.env.IsWorkflowCompleted())
+	var canceledErr *temporal.CanceledError
+	s.ErrorAs(s.env.GetWorkflowError(), &canceledErr)
+
+	var output QueryTicketOutput
+	future, err := s.env.QueryWorkflow(QueryTicketSummary)
+	s.NoError(err)
+	s.NoError(future.Get(&output))
+	s.Equal("Summary", output.Summary.Summary)
+	s.Nil(output.Scores)
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/nukosuke/go-zendesk/zendesk"
+	zd "github.com/taonic/ticketfu/zendesk"
 )
 
 type (
@@ -17,9 +18,13 @@ type (
 	}
 )
 
+const triggerTitle = "Notify TicketFu"
+
+// CreateTrigger creates the trigger notifying the webhook of ticket updates,
+// or updates the existing one so it has the current conditions
 func (a *Activity) CreateTrigger(ctx context.Context, input CreateTriggerInput) (*CreateTriggerOutput, error) {
 	trigger := zendesk.Trigger{
-		Title:    "Notify TicketFu",
+		Title:    triggerTitle,
 		Active:   true,
 		Position: 1,
 		Actions: []zendesk.TriggerAction{
@@ -28,7 +33,8 @@ func (a *Activity) CreateTrigger(ctx context.Context, input CreateTriggerInput)
 				Value: []interface{}{
 					input.WebhookID,
 					`{
-						"ticket_url": "{{ticket.url}}"
+						"ticket_url": "{{ticket.url}}",
+						"updated_at": "{{ticket.updated_at_with_timestamp}}"
 					}`,
 				},
 			},
@@ -49,6 +55,29 @@ func (a *Activity) CreateTrigger(ctx context.Context, input CreateTriggerInput)
 		},
 	}
 
+	// Ignore TicketFu's own summary notes to avoid a loop. Its tags and
+	// fields do notify it, with the updated_at its workflow recorded for the
+	// write, so the workflow skips them as already up to date.
+	trigger.Conditions.All = []zendesk.TriggerCondition{
+		{
+			Field:    "comment_includes_word",
+			Operator: "is_not",
+			Value:    zd.NoteMarker,
+		},
+	}
+
+	existing, found, err := a.findTrigger(ctx, input.WebhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	if found {
+		if _, err := a.zClient.UpdateTrigger(ctx, existing.ID, trigger); err != nil {
+			return nil, fmt.Errorf("failed to update trigger: %w", err)
+		}
+		return &CreateTriggerOutput{TriggerID: fmt.Sprintf("%d", existing.ID)}, nil
+	}
+
 	createdTrigger, err := a.zClient.CreateTrigger(ctx, trigger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create trigger: %w", err)
@@ -60,3 +89,35 @@ func (a *Activity) CreateTrigger(ctx context.Context, input CreateTriggerInput)
 
 	return &output, nil
 }
+
+// findTrigger returns the trigger notifying the webhook, if it was created
+func (a *Activity) findTrigger(ctx context.Context, webhookID string) (zendesk.Trigger, bool, error) {
+	opts := &zendesk.TriggerListOptions{PageOptions: zendesk.PageOptions{PerPage: 100, Page: 1}}
+	for {
+		triggers, page, err := a.zClient.GetTriggers(ctx, opts)
+		if err != nil {
+			return zendesk.Trigger{}, false, fmt.Errorf("failed to list triggers: %w", err)
+		}
+
+		for _, trigger := range triggers {
+			if trigger.Title == triggerTitle && notifiesWebhook(trigger, webhookID) {
+				return trigger, true, nil
+			}
+		}
+
+		if !page.HasNext() {
+			return zendesk.Trigger{}, false, nil
+		}
+		opts.Page++
+	}
+}
+
+func notifiesWebhook(trigger zendesk.Trigger, webhookID string) bool {
+	for _, action := range trigger.Actions {
+		values, ok := action.Value.([]interface{})
+		if action.Field == "notification_webhook" && ok && len(values) > 0 && values[0] == webhookID {
+			return true
+		}
+	}
+	return false
+}
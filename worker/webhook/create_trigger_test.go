@@ -2,6 +2,7 @@ package webhook
 
 import (
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/nukosuke/go-zendesk/zendesk"
@@ -16,6 +17,20 @@ func TestCreateTrigger(t *testing.T) {
 	testSuite := testsuite.WorkflowTestSuite{}
 	testEnv := testSuite.NewTestActivityEnvironment()
 
+	expectedConditions := []zendesk.TriggerCondition{
+		{Field: "comment_includes_word", Operator: "is_not", Value: zd.NoteMarker},
+	}
+	webhookTrigger := func(id int64, webhookID string) zendesk.Trigger {
+		return zendesk.Trigger{
+			ID:      id,
+			Title:   "Notify TicketFu",
+			Actions: []zendesk.TriggerAction{{Field: "notification_webhook", Value: []interface{}{webhookID, "{}"}}},
+		}
+	}
+	existingTrigger := webhookTrigger(12345, "webhook-123")
+	otherWebhookTrigger := webhookTrigger(2, "webhook-old")
+	nextPage := "https://example.zendesk.com/api/v2/triggers.json?page=2"
+
 	testCases := []struct {
 		name           string
 		webhookID      string
@@ -27,6 +42,12 @@ func TestCreateTrigger(t *testing.T) {
 			name:      "Successful Trigger Creation",
 			webhookID: "webhook-123",
 			setupMock: func(m *zd.MockZendeskClient) {
+				// Other triggers, and a TicketFu trigger of a previous webhook
+				m.On("GetTriggers", mock.Anything, mock.Anything).Return([]zendesk.Trigger{
+					{ID: 1, Title: "Notify team"},
+					otherWebhookTrigger,
+				}, zendesk.Page{}, nil).Once()
+
 				// Expect CreateTrigger to be called with the expected parameters
 				m.On("CreateTrigger", mock.Anything, mock.MatchedBy(func(trigger zendesk.Trigger) bool {
 					// Verify the trigger is configured correctly
@@ -34,6 +55,11 @@ func TestCreateTrigger(t *testing.T) {
 						return false
 					}
 
+					// Check that TicketFu's own notes don't notify TicketFu
+					if !assert.ObjectsAreEqual(expectedConditions, trigger.Conditions.All) {
+						return false
+					}
+
 					// Check that we have at least one action
 					if len(trigger.Actions) != 1 {
 						return false
@@ -51,7 +77,9 @@ func TestCreateTrigger(t *testing.T) {
 						return false
 					}
 
-					return webhookValues[0] == "webhook-123"
+					// The body carries updated_at so the workflow can skip its own writes
+					body, _ := webhookValues[1].(string)
+					return webhookValues[0] == "webhook-123" && strings.Contains(body, "{{ticket.updated_at_with_timestamp}}")
 				})).Return(zendesk.Trigger{
 					ID:     12345,
 					Title:  "Notify TicketFu",
@@ -62,10 +90,27 @@ func TestCreateTrigger(t *testing.T) {
 				TriggerID: "12345",
 			},
 		},
+		{
+			name:      "Existing Trigger Updated",
+			webhookID: "webhook-123",
+			setupMock: func(m *zd.MockZendeskClient) {
+				m.On("GetTriggers", mock.Anything, mock.MatchedBy(func(opts *zendesk.TriggerListOptions) bool { return opts.Page == 1 })).
+					Return([]zendesk.Trigger{{ID: 1, Title: "Notify team"}}, zendesk.Page{NextPage: &nextPage}, nil).Once()
+				m.On("GetTriggers", mock.Anything, mock.MatchedBy(func(opts *zendesk.TriggerListOptions) bool { return opts.Page == 2 })).
+					Return([]zendesk.Trigger{existingTrigger}, zendesk.Page{}, nil).Once()
+				m.On("UpdateTrigger", mock.Anything, int64(12345), mock.MatchedBy(func(trigger zendesk.Trigger) bool {
+					return assert.ObjectsAreEqual(expectedConditions, trigger.Conditions.All)
+				})).Return(existingTrigger, nil).Once()
+			},
+			expectedOutput: &CreateTriggerOutput{
+				TriggerID: "12345",
+			},
+		},
 		{
 			name:      "API Error",
 			webhookID: "webhook-123",
 			setupMock: func(m *zd.MockZendeskClient) {
+				m.On("GetTriggers", mock.Anything, mock.Anything).Return([]zendesk.Trigger{}, zendesk.Page{}, nil).Once()
 				m.On("CreateTrigger", mock.Anything, mock.Anything).
 					Return(zendesk.Trigger{}, errors.New("API error")).Once()
 			},
@@ -37,6 +37,9 @@ type (
 
 const (
 	UpsertWebhookSignal = "UpsertWebhookSignal"
+
+	// updateTriggerChangeID versions updating the trigger of existing webhooks
+	updateTriggerChangeID = "update-trigger"
 )
 
 var (
@@ -109,26 +112,32 @@ func (s *webhookWorkflow) processPendingUpsert(upsert *UpsertWebhookInput) error
 		return fmt.Errorf("failed to create webhook %w", err)
 	}
 
-	// Only create trigger if new webhook is created
-	if createWebhookOutput.WebhookID != s.webhook.ID {
+	webhookCreated := createWebhookOutput.WebhookID != s.webhook.ID
+	if webhookCreated {
 		s.webhook.ID = createWebhookOutput.WebhookID
 		s.logger.Debug("Created a new webhook with ID", tag.Value(createWebhookOutput.WebhookID))
+	}
 
-		// Create Zendesk trigger based on the webhook ID
-		createTriggerInput := CreateTriggerInput{
-			WebhookID: s.webhook.ID,
-		}
-		var createTriggerOutput CreateTriggerOutput
-		err = workflow.ExecuteActivity(s.Context, s.activity.CreateTrigger, createTriggerInput).
-			Get(s.Context, &createTriggerOutput)
-		if err != nil {
-			return fmt.Errorf("failed to create trigger %w", err)
-		}
-
-		s.logger.Debug("Created trigger with ID", tag.Value(createTriggerOutput.TriggerID))
-	} else {
+	// The trigger of an existing webhook is updated too, so it picks up
+	// conditions added since it was created
+	updateTrigger := workflow.GetVersion(s, updateTriggerChangeID, workflow.DefaultVersion, 1) == 1
+	if !webhookCreated && !updateTrigger {
 		s.logger.Debug("Skipping trigger creation as webhook already exist", tag.Value(createWebhookOutput.WebhookID))
+		return nil
 	}
 
+	// Create or update the Zendesk trigger based on the webhook ID
+	createTriggerInput := CreateTriggerInput{
+		WebhookID: s.webhook.ID,
+	}
+	var createTriggerOutput CreateTriggerOutput
+	err = workflow.ExecuteActivity(s.Context, s.activity.CreateTrigger, createTriggerInput).
+		Get(s.Context, &createTriggerOutput)
+	if err != nil {
+		return fmt.Errorf("failed to create trigger %w", err)
+	}
+
+	s.logger.Debug("Upserted trigger with ID", tag.Value(createTriggerOutput.TriggerID))
+
 	return nil
 }
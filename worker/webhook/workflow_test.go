@@ -76,7 +76,7 @@ func (s *WebhookWorkflowTestSuite) TestSuccessfulWebhookCreation() {
 	s.True(errors.As(s.env.GetWorkflowError(), &canErr))
 }
 
-func (s *WebhookWorkflowTestSuite) TestExistingWebhookNoTriggerCreation() {
+func (s *WebhookWorkflowTestSuite) TestExistingWebhookUpdatesTrigger() {
 	// Initial webhook with existing ID
 	webhook := Webhook{
 		ID:             "existing-webhook-123",
@@ -91,8 +91,12 @@ func (s *WebhookWorkflowTestSuite) TestExistingWebhookNoTriggerCreation() {
 		WebhookID: "existing-webhook-123",
 	}, nil).Once()
 
-	// We should NOT see a call to create trigger since the webhook already exists
-	// No need to mock CreateTrigger
+	// The existing webhook's trigger is updated to the current conditions
+	s.env.OnActivity((*Activity)(nil).CreateTrigger, mock.Anything, CreateTriggerInput{
+		WebhookID: "existing-webhook-123",
+	}).Return(&CreateTriggerOutput{
+		TriggerID: "456",
+	}, nil).Once()
 
 	// Send signal to trigger webhook check
 	s.env.RegisterDelayedCallback(func() {
@@ -133,14 +137,14 @@ func (s *WebhookWorkflowTestSuite) TestMultipleUpsertSignals() {
 		WebhookID: "webhook-123",
 	}, nil).Once()
 
-	// First trigger creation
+	// Trigger created, then updated by the second signal
 	s.env.OnActivity((*Activity)(nil).CreateTrigger, mock.Anything, CreateTriggerInput{
 		WebhookID: "webhook-123",
 	}).Return(&CreateTriggerOutput{
 		TriggerID: "456",
-	}, nil).Once()
+	}, nil).Twice()
 
-	// Second API call with updated webhook, but returns same ID (so no new trigger)
+	// Second API call with updated webhook, but returns same ID
 	s.env.OnActivity((*Activity)(nil).CreateWebhook, mock.Anything, mock.MatchedBy(func(input CreateWebhookInput) bool {
 		return input.Webhook.BaseURL == "https://updated.com" &&
 			input.Webhook.ServerAPIToken == "updated-token" &&
@@ -149,8 +153,6 @@ func (s *WebhookWorkflowTestSuite) TestMultipleUpsertSignals() {
 		WebhookID: "webhook-123",
 	}, nil).Once()
 
-	// Don't expect a second trigger creation since webhook ID is the same
-
 	// Send first signal
 	s.env.RegisterDelayedCallback(func() {
 		s.env.SignalWorkflow(UpsertWebhookSignal, UpsertWebhookInput{
@@ -6,12 +6,16 @@ import (
 	"github.com/taonic/ticketfu/config"
 	"github.com/taonic/ticketfu/genai"
 	"github.com/taonic/ticketfu/temporal"
+	"github.com/taonic/ticketfu/worker/backfill"
 	"github.com/taonic/ticketfu/worker/org"
+	"github.com/taonic/ticketfu/worker/reconcile"
+	"github.com/taonic/ticketfu/worker/related"
 	"github.com/taonic/ticketfu/worker/ticket"
 	"github.com/taonic/ticketfu/worker/webhook"
 	"github.com/taonic/ticketfu/zendesk"
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
 	"go.temporal.io/server/common/log"
 	"go.temporal.io/server/common/log/tag"
 	"go.uber.org/fx"
@@ -23,11 +27,14 @@ const (
 
 type Worker struct {
 	worker.Worker
+	indexWorker          worker.Worker // Serves the related tickets index, nil unless configured
 	logger               log.Logger
 	config               config.WorkerConfig
 	ticketActivity       *ticket.Activity
 	organizationActivity *org.Activity
 	webhookActivities    *webhook.Activity
+	reconcileActivity    *reconcile.Activity
+	backfillActivity     *backfill.Activity
 	tClient              client.Client
 }
 
@@ -37,8 +44,19 @@ func NewWorker(
 	webhookActivity *webhook.Activity,
 	ticketActivity *ticket.Activity,
 	organizationActivity *org.Activity,
+	reconcileActivity *reconcile.Activity,
+	backfillActivity *backfill.Activity,
 	tClient client.Client,
 ) *Worker {
+	// register related tickets index activities on the worker serving the index
+	var indexWorker worker.Worker
+	if config.ServeEmbeddingIndex {
+		indexWorker = worker.New(tClient, related.TaskQueue, worker.Options{})
+		indexWorker.RegisterActivity(ticketActivity.IndexSummary)
+		indexWorker.RegisterActivity(ticketActivity.UnindexTicket)
+		indexWorker.RegisterActivity(ticketActivity.FindRelatedTickets)
+	}
+
 	worker := worker.New(tClient, TaskQueue, worker.Options{})
 
 	// register webhook workflow and activities
@@ -47,23 +65,51 @@ func NewWorker(
 	worker.RegisterActivity(webhookActivity.CreateTrigger)
 
 	// register ticket workflow and activities
-	worker.RegisterWorkflow(ticket.TicketWorkflow)
+	worker.RegisterWorkflowWithOptions(ticket.NewTicketWorkflow(config.TicketWorkflow), workflow.RegisterOptions{
+		Name: ticket.TicketWorkflowName,
+	})
+	worker.RegisterWorkflow(ticket.RelatedTicketsWorkflow)
 	worker.RegisterActivity(ticketActivity.FetchTicket)
 	worker.RegisterActivity(ticketActivity.FetchComments)
+	worker.RegisterActivity(ticketActivity.ExtractAttachments)
 	worker.RegisterActivity(ticketActivity.GenTicketSummary)
+	worker.RegisterActivity(ticketActivity.CompactComments)
+	worker.RegisterActivity(ticketActivity.SummarizeChunk)
+	worker.RegisterActivity(ticketActivity.GenResolutionSummary)
 	worker.RegisterActivity(ticketActivity.SignalOrganization)
+	worker.RegisterActivity(ticketActivity.RemoveFromOrganization)
+	worker.RegisterActivity(ticketActivity.ScoreTicket)
+	worker.RegisterActivity(ticketActivity.EscalateOrganization)
+	worker.RegisterActivity(ticketActivity.ClassifyTicket)
+	worker.RegisterActivity(ticketActivity.UpdateTicketFields)
+	worker.RegisterActivity(ticketActivity.PostSummaryNote)
+	worker.RegisterActivity(ticketActivity.GenDraftReply)
+	worker.RegisterActivity(ticketActivity.DetectLanguage)
+	worker.RegisterActivity(ticketActivity.TranslateSummary)
 
 	// register org workflow and activities
 	worker.RegisterWorkflow(org.OrganizationWorkflow)
 	worker.RegisterActivity(organizationActivity.FetchOrganization)
 	worker.RegisterActivity(organizationActivity.GenOrgSummary)
+	worker.RegisterActivity(organizationActivity.TranslateSummary)
+
+	// register reconcile workflow and activities
+	worker.RegisterWorkflow(reconcile.ReconcileWorkflow)
+	worker.RegisterActivity(reconcileActivity.ReconcileTickets)
+
+	// register backfill workflow and activities
+	worker.RegisterWorkflow(backfill.BackfillWorkflow)
+	worker.RegisterActivity(backfillActivity.BackfillTickets)
 
 	return &Worker{
 		Worker:               worker,
+		indexWorker:          indexWorker,
 		logger:               logger,
 		config:               config,
 		ticketActivity:       ticketActivity,
 		organizationActivity: organizationActivity,
+		reconcileActivity:    reconcileActivity,
+		backfillActivity:     backfillActivity,
 		tClient:              tClient,
 	}
 }
@@ -75,6 +121,16 @@ func (w *Worker) OnStart(ctx context.Context) error {
 	if err != nil {
 		w.logger.Fatal("Unable to start worker", tag.Error(err))
 	}
+	if w.indexWorker != nil {
+		if err := w.indexWorker.Start(); err != nil {
+			w.logger.Fatal("Unable to start embedding index worker", tag.Error(err))
+		}
+	}
+
+	// Webhooks can be missed, so the tickets updated since are reconciled periodically
+	if err := reconcile.EnsureSchedule(ctx, w.tClient, TaskQueue, w.config.ReconcileInterval); err != nil {
+		w.logger.Error("Unable to schedule ticket reconciliation", tag.Error(err))
+	}
 	return nil
 }
 
@@ -82,6 +138,9 @@ func (w *Worker) OnStart(ctx context.Context) error {
 func (w *Worker) OnStop(ctx context.Context) error {
 	w.logger.Info("Stopping worker")
 	w.Stop()
+	if w.indexWorker != nil {
+		w.indexWorker.Stop()
+	}
 	return nil
 }
 
@@ -91,9 +150,12 @@ var Module = fx.Options(
 	fx.Provide(temporal.NewClient),
 	fx.Provide(zendesk.NewClient),
 	fx.Provide(genai.NewAPI),
+	fx.Provide(related.NewIndex),
 	fx.Provide(webhook.NewActivity),
 	fx.Provide(ticket.NewActivity),
 	fx.Provide(org.NewActivity),
+	fx.Provide(reconcile.NewActivity),
+	fx.Provide(backfill.NewActivity),
 	fx.Invoke(func(lc fx.Lifecycle, worker *Worker) {
 		lc.Append(fx.Hook{
 			OnStart: worker.OnStart,
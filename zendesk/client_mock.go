@@ -17,6 +17,16 @@ func (m *MockZendeskClient) GetTicket(ctx context.Context, id int64) (zendesk.Ti
 	return args.Get(0).(zendesk.Ticket), args.Error(1)
 }
 
+func (m *MockZendeskClient) UpdateTicket(ctx context.Context, id int64, ticket zendesk.Ticket) (zendesk.Ticket, error) {
+	args := m.Called(ctx, id, ticket)
+	return args.Get(0).(zendesk.Ticket), args.Error(1)
+}
+
+func (m *MockZendeskClient) AddTicketTagsAndFields(ctx context.Context, ticketID int64, tags []string, customFields []zendesk.CustomField) (zendesk.Ticket, error) {
+	args := m.Called(ctx, ticketID, tags, customFields)
+	return args.Get(0).(zendesk.Ticket), args.Error(1)
+}
+
 func (m *MockZendeskClient) GetUser(ctx context.Context, id int64) (zendesk.User, error) {
 	args := m.Called(ctx, id)
 	return args.Get(0).(zendesk.User), args.Error(1)
@@ -32,6 +42,14 @@ func (m *MockZendeskClient) GetTicketCommentsCBP(ctx context.Context, opts *zend
 	return args.Get(0).([]zendesk.TicketComment), args.Get(1).(zendesk.CursorPaginationMeta), args.Error(2)
 }
 
+func (m *MockZendeskClient) DownloadAttachment(ctx context.Context, contentURL string, maxBytes int64) ([]byte, error) {
+	args := m.Called(ctx, contentURL, maxBytes)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
+
 func (m *MockZendeskClient) CreateWebhook(ctx context.Context, hook *zendesk.Webhook) (*zendesk.Webhook, error) {
 	args := m.Called(ctx, hook)
 	if args.Get(0) == nil {
@@ -52,3 +70,18 @@ func (m *MockZendeskClient) CreateTrigger(ctx context.Context, trigger zendesk.T
 	args := m.Called(ctx, trigger)
 	return args.Get(0).(zendesk.Trigger), args.Error(1)
 }
+
+func (m *MockZendeskClient) GetTriggers(ctx context.Context, opts *zendesk.TriggerListOptions) ([]zendesk.Trigger, zendesk.Page, error) {
+	args := m.Called(ctx, opts)
+	return args.Get(0).([]zendesk.Trigger), args.Get(1).(zendesk.Page), args.Error(2)
+}
+
+func (m *MockZendeskClient) UpdateTrigger(ctx context.Context, triggerID int64, trigger zendesk.Trigger) (zendesk.Trigger, error) {
+	args := m.Called(ctx, triggerID, trigger)
+	return args.Get(0).(zendesk.Trigger), args.Error(1)
+}
+
+func (m *MockZendeskClient) GetIncrementalTickets(ctx context.Context, startTime int64) (IncrementalTicketsPage, error) {
+	args := m.Called(ctx, startTime)
+	return args.Get(0).(IncrementalTicketsPage), args.Error(1)
+}
@@ -7,6 +7,10 @@ import (
 	"strings"
 )
 
+// NoteMarker opens every internal note posted by TicketFu so its own notes can
+// be excluded from summaries and from the trigger that notifies TicketFu
+const NoteMarker = "[TicketFu summary]"
+
 // ParseTicketURL extracts the subdomain and ticket_id from a Zendesk ticket URL
 func ParseTicketURL(zendeskURL string) (string, string, error) {
 	if !strings.HasPrefix(zendeskURL, "http") {
@@ -9,7 +9,7 @@
  * @param {string} serverUrl - TicketFu server URL
  * @param {string} subdomain - Zendesk subdomain
  * @param {string} ticketId - Ticket ID
- * @returns {Promise<Object>} - Parsed summary data
+ * @returns {Promise<Object>} - Summary data with intent, summary and next_step
  */
 export async function getTicketSummary(client, serverUrl, subdomain, ticketId) {
   try {
@@ -23,14 +23,8 @@ export async function getTicketSummary(client, serverUrl, subdomain, ticketId) {
       secure: true,
     };
     const response = await client.request(options);
-    const summary = response.summary;
-    // Parse JSON if it's in JSON format
-    if (summary.startsWith("```json")) {
-      const cleanedString = summary.replace(/```json\n/, '').replace(/\n```/, '');
-      return JSON.parse(cleanedString);
-    }
-
-    return JSON.parse(summary);
+    // The summary is validated and typed by the server
+    return response.summary;
   } catch (error) {
     console.error('Error getting ticket summary:', error);
     throw error;